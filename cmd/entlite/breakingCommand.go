@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/guntisdev/entlite/internal/breaking"
+	"github.com/guntisdev/entlite/internal/protolock"
+	"github.com/guntisdev/entlite/internal/schema"
+	"github.com/guntisdev/entlite/internal/snapshot"
+)
+
+// breakingReport is what entlite breaking prints, for CI to read
+type breakingReport struct {
+	Against string            `json:"against"`
+	Changes []breaking.Change `json:"changes"`
+}
+
+func breakingCommand(args []string) {
+	fs := flag.NewFlagSet("breaking", flag.ExitOnError)
+	against := fs.String("against", "", "git ref or schema snapshot file to compare with, e.g. main or migrations/schema_snapshot.json")
+	fs.Parse(args)
+
+	if *against == "" {
+		fmt.Fprintln(os.Stderr, "Error: --against is required")
+		os.Exit(1)
+	}
+
+	report, err := checkBreaking("./schema", *against)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed checking breaking changes: %v\n", err)
+		os.Exit(1)
+	}
+
+	out, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed writing report: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(string(out))
+
+	if len(report.Changes) > 0 {
+		os.Exit(1)
+	}
+}
+
+// checkBreaking compares the schema in entityDir to the one at against: a
+// snapshot file when one exists at that path, else a git ref
+func checkBreaking(entityDir, against string) (breakingReport, error) {
+	current, _, err := loadEntities(entityDir)
+	if err != nil {
+		return breakingReport{}, fmt.Errorf("loading entities: %w", err)
+	}
+
+	var previous []schema.Entity
+	if _, statErr := os.Stat(against); statErr == nil {
+		previous, err = snapshot.Read(against)
+	} else {
+		previous, err = loadEntitiesAt(entityDir, against)
+	}
+	if err != nil {
+		return breakingReport{}, err
+	}
+
+	changes := breaking.Check(previous, current)
+	if changes == nil {
+		changes = []breaking.Change{}
+	}
+	return breakingReport{Against: against, Changes: changes}, nil
+}
+
+// loadEntitiesAt parses the schema directory as it is at a git ref, with the
+// entlite.lock of that ref
+func loadEntitiesAt(entityDir, ref string) ([]schema.Entity, error) {
+	dir, err := filepath.Abs(entityDir)
+	if err != nil {
+		return nil, fmt.Errorf("resolving path %s: %w", entityDir, err)
+	}
+
+	files, err := gitOutput(dir, "ls-tree", "--name-only", ref, "./")
+	if err != nil {
+		return nil, fmt.Errorf("listing %s at %q: %w", entityDir, ref, err)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "entlite-breaking-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	schemaDir := filepath.Join(tmpDir, filepath.Base(dir))
+	if err := os.MkdirAll(schemaDir, 0755); err != nil {
+		return nil, err
+	}
+	for _, file := range strings.Split(strings.TrimSpace(files), "\n") {
+		if !strings.HasSuffix(file, ".go") {
+			continue
+		}
+		content, err := gitOutput(dir, "show", ref+":./"+file)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s at %q: %w", file, ref, err)
+		}
+		if err := os.WriteFile(filepath.Join(schemaDir, file), []byte(content), 0644); err != nil {
+			return nil, err
+		}
+	}
+
+	// no lock at that ref is fine, numbers were assigned in field order then
+	if lock, err := gitOutput(filepath.Dir(dir), "show", ref+":./"+protolock.FileName); err == nil {
+		if err := os.WriteFile(protoLockPath(schemaDir), []byte(lock), 0644); err != nil {
+			return nil, err
+		}
+	}
+
+	entities, _, err := loadEntities(schemaDir)
+	return entities, err
+}
+
+func gitOutput(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return string(out), err
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/guntisdev/entlite/pkg/entlite/migrate"
+)
+
+func TestCheckDrift(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	schemaDir := filepath.Join(tmpDir, "ent", "schema")
+	logicDir := filepath.Join(tmpDir, "ent", "logic")
+	migrationsDir := filepath.Join(tmpDir, "ent", "migrations")
+	for _, dir := range []string{schemaDir, logicDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", dir, err)
+		}
+	}
+	writeTestGoMod(t, tmpDir)
+	writeTestUserSchema(t, schemaDir)
+	writeTestLogic(t, logicDir)
+
+	sqlcYamlContent := `version: "2"
+sql:
+  - schema: "contract/sqlc/schema.sql"
+    queries: "contract/sqlc/queries.sql"
+    engine: "sqlite"
+    gen:
+      go:
+        package: "internal"
+        out: "gen/db/internal"`
+	sqlcYamlPath := filepath.Join(tmpDir, "ent", "sqlc.yaml")
+	if err := os.WriteFile(sqlcYamlPath, []byte(sqlcYamlContent), 0644); err != nil {
+		t.Fatalf("Failed to write sqlc.yaml: %v", err)
+	}
+
+	// the database is what the first migration creates
+	if _, err := migrateDiff(schemaDir, sqlcYamlPath, migrationsDir, "initial", time.Now()); err != nil {
+		t.Fatalf("migrateDiff failed: %v", err)
+	}
+	dsn := filepath.Join(tmpDir, "app.db")
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+	migrator, err := migrate.New(db, migrate.SQLite, os.DirFS(migrationsDir))
+	if err != nil {
+		t.Fatalf("migrate.New: %v", err)
+	}
+	if err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+
+	drifts, err := checkDrift(schemaDir, sqlcYamlPath, dsn)
+	if err != nil {
+		t.Fatalf("checkDrift failed: %v", err)
+	}
+	if len(drifts) != 0 {
+		t.Fatalf("migrated database drifted: %+v", drifts)
+	}
+
+	// a hotfix adds a column by hand
+	if _, err := db.Exec(`ALTER TABLE "user" ADD COLUMN note TEXT`); err != nil {
+		t.Fatalf("alter: %v", err)
+	}
+	drifts, err = checkDrift(schemaDir, sqlcYamlPath, dsn)
+	if err != nil {
+		t.Fatalf("checkDrift failed: %v", err)
+	}
+	want := migrate.Drift{Kind: migrate.ColumnExtra, Table: "user", Name: "note", Message: "column note TEXT is not in the schema"}
+	if len(drifts) != 1 || drifts[0] != want {
+		t.Errorf("drifts = %+v, want %+v", drifts, want)
+	}
+}
@@ -7,31 +7,44 @@ import (
 	"strings"
 
 	"github.com/guntisdev/entlite/internal/parser"
+	"github.com/guntisdev/entlite/internal/protolock"
 	"github.com/guntisdev/entlite/internal/schema"
 )
 
-// loadEntities discovers and parses entities from the given directory
-func loadEntities(entityDir string) ([]schema.Entity, error) {
+// loadEntities discovers and parses entities from the given directory, with
+// the proto numbers of entlite.lock next to it. The lock is returned so gen
+// records the numbers in the one the fields were numbered from.
+func loadEntities(entityDir string) ([]schema.Entity, *protolock.Lock, error) {
 	dir, err := filepath.Abs(entityDir)
 	if err != nil {
-		return nil, fmt.Errorf("resolving path %s: %w", entityDir, err)
+		return nil, nil, fmt.Errorf("resolving path %s: %w", entityDir, err)
 	}
 
 	if _, err := os.Stat(dir); os.IsNotExist(err) {
-		return nil, fmt.Errorf("entity directory does not exist: %s", dir)
+		return nil, nil, fmt.Errorf("entity directory does not exist: %s", dir)
 	}
 
 	discoveredEntities, err := parser.DiscoverEntities(dir)
 	if err != nil {
-		return nil, fmt.Errorf("discovering entities: %w", err)
+		return nil, nil, fmt.Errorf("discovering entities: %w", err)
+	}
+
+	lock, err := protolock.Read(protoLockPath(dir))
+	if err != nil {
+		return nil, nil, err
 	}
 
-	parsedEntities, err := parser.ParseEntities(discoveredEntities)
+	parsedEntities, err := parser.ParseEntities(discoveredEntities, lock)
 	if err != nil {
-		return nil, fmt.Errorf("parsing entities: %w", err)
+		return nil, nil, fmt.Errorf("parsing entities: %w", err)
 	}
 
-	return parsedEntities, nil
+	return parsedEntities, lock, nil
+}
+
+// protoLockPath is entlite.lock in the directory that holds the schema dir
+func protoLockPath(entityDir string) string {
+	return filepath.Join(filepath.Dir(entityDir), protolock.FileName)
 }
 
 func getEntityImports(entityDir string) (map[string]parser.ImportInfo, error) {
@@ -7,6 +7,7 @@ import (
 
 	"github.com/guntisdev/entlite/internal/generator/proto"
 	"github.com/guntisdev/entlite/internal/generator/sqlc"
+	"github.com/guntisdev/entlite/internal/protolock"
 	"github.com/guntisdev/entlite/internal/schema"
 	"github.com/guntisdev/entlite/internal/util"
 )
@@ -18,7 +19,7 @@ func genCommand(args []string) {
 	}
 
 	entityDir := args[0]
-	parsedEntities, err := loadEntities(entityDir)
+	parsedEntities, lock, err := loadEntities(entityDir)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error loading entities: %v\n", err)
 		os.Exit(1)
@@ -39,12 +40,34 @@ func genCommand(args []string) {
 	}
 
 	// PROTO
+	lockPath := protoLockPath(dir)
+	lock.Record(parsedEntities)
+
 	protoEntities := schema.FilterPROTO(parsedEntities)
 	if len(protoEntities) > 0 {
-		if err := proto.Generate(protoEntities, protoDir); err != nil {
+		if err := proto.Generate(protoEntities, protoDir, lock); err != nil {
 			fmt.Fprintf(os.Stderr, "Failed generating proto: %v\n", err)
 			os.Exit(1)
 		}
+
+		if hasGoogleTypeField(protoEntities) {
+			bufYamlPath := filepath.Join(filepath.Dir(dir), "buf.yaml")
+			updated, err := util.EnsureBufDep(bufYamlPath, util.GoogleAPIsDep)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed adding %s to buf.yaml: %v\n", util.GoogleAPIsDep, err)
+				os.Exit(1)
+			}
+			if updated {
+				fmt.Printf("Added %s to %s\n", util.GoogleAPIsDep, bufYamlPath)
+			}
+		}
+	}
+
+	if lock.Changed() {
+		if err := lock.Write(lockPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed writing %s: %v\n", protolock.FileName, err)
+			os.Exit(1)
+		}
 	}
 
 	// SQLC
@@ -57,6 +80,17 @@ func genCommand(args []string) {
 			os.Exit(1)
 		}
 
+		if hasJSONColumn(sqlcEntities) {
+			updated, err := util.EnsureJSONOverrides(sqlcYamlPath, sqlcConfig.Dialect)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed adding json overrides to sqlc.yaml: %v\n", err)
+				os.Exit(1)
+			}
+			if updated {
+				fmt.Printf("Added json overrides to %s\n", sqlcYamlPath)
+			}
+		}
+
 		sqlcGenerator := sqlc.NewGenerator(sqlcConfig.Dialect)
 		if err := sqlcGenerator.Generate(sqlcEntities, sqlcDir); err != nil {
 			fmt.Fprintf(os.Stderr, "Failed generating sqlc: %v\n", err)
@@ -64,3 +98,28 @@ func genCommand(args []string) {
 		}
 	}
 }
+
+// hasJSONColumn reports a stored json field, its column type needs sqlc overrides
+func hasJSONColumn(entities []schema.Entity) bool {
+	for _, entity := range entities {
+		for _, field := range entity.Fields {
+			if field.Type == schema.FieldTypeJSON && !field.IsVirtual() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasGoogleTypeField reports a date or latlng field, schema.proto imports them
+// from google/type which buf fetches from googleapis
+func hasGoogleTypeField(entities []schema.Entity) bool {
+	for _, entity := range entities {
+		for _, field := range entity.Fields {
+			if field.Type == schema.FieldTypeDate || field.Type == schema.FieldTypeLatLng {
+				return true
+			}
+		}
+	}
+	return false
+}
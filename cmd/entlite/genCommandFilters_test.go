@@ -0,0 +1,130 @@
+package main
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+const optionalFiltersSchema = `package schema
+
+import (
+	"github.com/guntisdev/entlite/pkg/entlite"
+	"github.com/guntisdev/entlite/pkg/entlite/field"
+	"github.com/guntisdev/entlite/pkg/entlite/filter"
+	"github.com/guntisdev/entlite/pkg/entlite/query"
+)
+
+type Device struct {
+	entlite.Schema
+}
+
+func (Device) Contracts() []entlite.Contract {
+	return []entlite.Contract{
+		entlite.SQLC(),
+	}
+}
+
+func (Device) Fields() []entlite.Field {
+	return []entlite.Field{
+		field.String("kind"),
+		field.JSON("settings"),
+		field.Strings("labels"),
+		field.Ints("channels"),
+	}
+}
+
+func (Device) Queries() []entlite.Query {
+	return []entlite.Query{
+		query.ListBy(filter.JSONPath("settings", "theme").Optional(), filter.Eq("kind")).Name("DevicesByTheme"),
+		query.ListBy(filter.Contains("labels").Optional()).Name("DevicesWithLabel"),
+		query.ListBy(filter.Contains("channels").Optional()).Name("DevicesOnChannel"),
+	}
+}
+`
+
+// TestGenCommandOptionalFilters runs the generated list queries on sqlite, an
+// optional filter left null matches every row
+func TestGenCommandOptionalFilters(t *testing.T) {
+	entDir := filepath.Join(t.TempDir(), "ent")
+	schemaDir := filepath.Join(entDir, "schema")
+	if err := os.MkdirAll(schemaDir, 0755); err != nil {
+		t.Fatalf("failed to create schema directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(schemaDir, "device.go"), []byte(optionalFiltersSchema), 0644); err != nil {
+		t.Fatalf("failed to write entity file: %v", err)
+	}
+	sqlcYaml := strings.Replace(gatingSqlcYaml, `engine: "postgresql"`, `engine: "sqlite"`, 1)
+	if err := os.WriteFile(filepath.Join(entDir, "sqlc.yaml"), []byte(sqlcYaml), 0644); err != nil {
+		t.Fatalf("failed to write sqlc.yaml: %v", err)
+	}
+
+	genCommand([]string{schemaDir})
+
+	schemaSQL, err := os.ReadFile(filepath.Join(entDir, "contract", "sqlc", "schema.sql"))
+	if err != nil {
+		t.Fatalf("failed to read schema.sql: %v", err)
+	}
+	queriesSQL, err := os.ReadFile(filepath.Join(entDir, "contract", "sqlc", "queries.sql"))
+	if err != nil {
+		t.Fatalf("failed to read queries.sql: %v", err)
+	}
+
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(string(schemaSQL)); err != nil {
+		t.Fatalf("failed to create tables: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO "device" (kind, settings, labels, channels) VALUES
+		('probe', '{"theme": "dark"}', '["a"]', '[1]'),
+		('probe', '{"theme": "light"}', '["b"]', '[2]')`); err != nil {
+		t.Fatalf("failed to insert rows: %v", err)
+	}
+
+	tests := []struct {
+		query string
+		args  []any
+		want  int
+	}{
+		{query: "DevicesByTheme", args: []any{sql.Named("settings_theme", "dark"), sql.Named("kind", "probe")}, want: 1},
+		{query: "DevicesByTheme", args: []any{sql.Named("settings_theme", nil), sql.Named("kind", "probe")}, want: 2},
+		{query: "DevicesWithLabel", args: []any{sql.Named("labels", "b")}, want: 1},
+		{query: "DevicesWithLabel", args: []any{sql.Named("labels", nil)}, want: 2},
+		{query: "DevicesOnChannel", args: []any{sql.Named("channels", 1)}, want: 1},
+		{query: "DevicesOnChannel", args: []any{sql.Named("channels", nil)}, want: 2},
+	}
+	for _, tt := range tests {
+		statement := sqlcQuery(t, string(queriesSQL), tt.query)
+		var count int
+		if err := db.QueryRow("SELECT COUNT(*) FROM ("+statement+")", tt.args...).Scan(&count); err != nil {
+			t.Fatalf("%s: %v\n%s", tt.query, err, statement)
+		}
+		if count != tt.want {
+			t.Errorf("%s with %v = %d rows, want %d\n%s", tt.query, tt.args, count, tt.want, statement)
+		}
+	}
+}
+
+var sqlcNarg = regexp.MustCompile(`sqlc\.narg\('(\w+)'\)`)
+
+// sqlcQuery is the statement of a named query with its params as sqlite
+// named params, sqlc.narg('x') becomes @x
+func sqlcQuery(t *testing.T, queries, name string) string {
+	t.Helper()
+
+	_, rest, ok := strings.Cut(queries, "-- name: "+name+" ")
+	if !ok {
+		t.Fatalf("query %s not generated:\n%s", name, queries)
+	}
+	_, rest, _ = strings.Cut(rest, "\n")
+	statement, _, _ := strings.Cut(rest, ";")
+	return sqlcNarg.ReplaceAllString(statement, "@$1")
+}
@@ -3,8 +3,10 @@ package main
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/guntisdev/entlite/internal/protolock"
 	testutil "github.com/guntisdev/entlite/internal/util"
 )
 
@@ -56,6 +58,7 @@ sql:
 		filepath.Join(tmpDir, "ent", "contract", "proto", "schema.proto"),
 		filepath.Join(tmpDir, "ent", "contract", "sqlc", "schema.sql"),
 		filepath.Join(tmpDir, "ent", "contract", "sqlc", "queries.sql"),
+		filepath.Join(tmpDir, "ent", "entlite.lock"),
 	}
 
 	for _, dir := range expectedDirs {
@@ -256,3 +259,215 @@ DELETE FROM "user" WHERE ID = $1;`
 		}
 	}
 }
+
+func TestGenCommandReservesRemovedFields(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	schemaDir := filepath.Join(tmpDir, "ent", "schema")
+	logicDir := filepath.Join(tmpDir, "ent", "logic")
+
+	if err := os.MkdirAll(schemaDir, 0755); err != nil {
+		t.Fatalf("Failed to create schema directory: %v", err)
+	}
+
+	if err := os.MkdirAll(logicDir, 0755); err != nil {
+		t.Fatalf("Failed to create logic directory: %v", err)
+	}
+
+	writeTestGoMod(t, tmpDir)
+	writeTestUserSchema(t, schemaDir)
+	writeTestLogic(t, logicDir)
+
+	sqlcYamlContent := `version: "2"
+sql:
+  - schema: "contract/sqlc/schema.sql"
+    queries: "contract/sqlc/queries.sql"
+    engine: "postgresql"
+    gen:
+      go:
+        package: "internal"
+        out: "gen/db/internal"`
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "ent", "sqlc.yaml"), []byte(sqlcYamlContent), 0644); err != nil {
+		t.Fatalf("Failed to write sqlc.yaml: %v", err)
+	}
+
+	genCommand([]string{schemaDir})
+
+	// last_login_ms (12) goes away, nickname is added in its place
+	userSchemaPath := filepath.Join(schemaDir, "user.go")
+	content, err := os.ReadFile(userSchemaPath)
+	if err != nil {
+		t.Fatalf("Failed to read user schema: %v", err)
+	}
+	changed := strings.Replace(string(content), `field.Int64("last_login_ms"),`, `field.String("nickname").Optional(),`, 1)
+	if err := os.WriteFile(userSchemaPath, []byte(changed), 0644); err != nil {
+		t.Fatalf("Failed to write user schema: %v", err)
+	}
+
+	genCommand([]string{schemaDir})
+
+	protoPath := filepath.Join(tmpDir, "ent", "contract", "proto", "schema.proto")
+	proto, err := os.ReadFile(protoPath)
+	if err != nil {
+		t.Fatalf("Failed to read schema.proto: %v", err)
+	}
+
+	reserved := "  reserved 12;\n  reserved \"last_login_ms\";\n}"
+	for _, message := range []string{"message User {", "message CreateUserRequest {", "message UpdateUserRequest {"} {
+		start := strings.Index(string(proto), message)
+		if start < 0 {
+			t.Fatalf("schema.proto has no %q", message)
+		}
+		end := strings.Index(string(proto[start:]), "}") + 1
+		if body := string(proto[start : start+end]); !strings.HasSuffix(body, reserved) {
+			t.Errorf("%s does not end with the reserved field:\n%s", message, body)
+		}
+	}
+	if !strings.Contains(string(proto), "  optional string nickname = 13;") {
+		t.Errorf("nickname should take the next free number, not the reserved one:\n%s", proto)
+	}
+}
+
+func TestGenCommandKeepsRenamedFieldNumbers(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	schemaDir := filepath.Join(tmpDir, "ent", "schema")
+	logicDir := filepath.Join(tmpDir, "ent", "logic")
+
+	if err := os.MkdirAll(schemaDir, 0755); err != nil {
+		t.Fatalf("Failed to create schema directory: %v", err)
+	}
+
+	if err := os.MkdirAll(logicDir, 0755); err != nil {
+		t.Fatalf("Failed to create logic directory: %v", err)
+	}
+
+	writeTestGoMod(t, tmpDir)
+	writeTestUserSchema(t, schemaDir)
+	writeTestLogic(t, logicDir)
+
+	sqlcYamlContent := `version: "2"
+sql:
+  - schema: "contract/sqlc/schema.sql"
+    queries: "contract/sqlc/queries.sql"
+    engine: "postgresql"
+    gen:
+      go:
+        package: "internal"
+        out: "gen/db/internal"`
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "ent", "sqlc.yaml"), []byte(sqlcYamlContent), 0644); err != nil {
+		t.Fatalf("Failed to write sqlc.yaml: %v", err)
+	}
+
+	genCommand([]string{schemaDir})
+
+	// last_login_ms (12) is renamed, it keeps its number and json name
+	userSchemaPath := filepath.Join(schemaDir, "user.go")
+	content, err := os.ReadFile(userSchemaPath)
+	if err != nil {
+		t.Fatalf("Failed to read user schema: %v", err)
+	}
+	changed := strings.Replace(string(content), `field.Int64("last_login_ms"),`, `field.Int64("last_seen_ms").RenamedFrom("last_login_ms"),`, 1)
+	if err := os.WriteFile(userSchemaPath, []byte(changed), 0644); err != nil {
+		t.Fatalf("Failed to write user schema: %v", err)
+	}
+
+	genCommand([]string{schemaDir})
+
+	proto, err := os.ReadFile(filepath.Join(tmpDir, "ent", "contract", "proto", "schema.proto"))
+	if err != nil {
+		t.Fatalf("Failed to read schema.proto: %v", err)
+	}
+	want := `  int64 last_seen_ms = 12 [json_name = "lastLoginMs", (buf.validate.field).required = true];`
+	if !strings.Contains(string(proto), want) {
+		t.Errorf("schema.proto does not contain:\n%s\ngot:\n%s", want, proto)
+	}
+	if strings.Contains(string(proto), "reserved") {
+		t.Errorf("a renamed field is not removed, nothing should be reserved:\n%s", proto)
+	}
+
+	lock, err := protolock.Read(filepath.Join(tmpDir, "ent", protolock.FileName))
+	if err != nil {
+		t.Fatalf("Failed to read lock: %v", err)
+	}
+	fields := lock.Fields("User")
+	if _, ok := fields["last_login_ms"]; ok || fields["last_seen_ms"] != 12 {
+		t.Errorf("lock should move number 12 to last_seen_ms, got %v", fields)
+	}
+}
+
+func TestGenCommandAddsGoogleAPIsDep(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	schemaDir := filepath.Join(tmpDir, "ent", "schema")
+	logicDir := filepath.Join(tmpDir, "ent", "logic")
+
+	if err := os.MkdirAll(schemaDir, 0755); err != nil {
+		t.Fatalf("Failed to create schema directory: %v", err)
+	}
+
+	if err := os.MkdirAll(logicDir, 0755); err != nil {
+		t.Fatalf("Failed to create logic directory: %v", err)
+	}
+
+	writeTestGoMod(t, tmpDir)
+	writeTestUserSchema(t, schemaDir)
+	writeTestLogic(t, logicDir)
+
+	sqlcYamlContent := `version: "2"
+sql:
+  - schema: "contract/sqlc/schema.sql"
+    queries: "contract/sqlc/queries.sql"
+    engine: "postgresql"
+    gen:
+      go:
+        package: "internal"
+        out: "gen/db/internal"`
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "ent", "sqlc.yaml"), []byte(sqlcYamlContent), 0644); err != nil {
+		t.Fatalf("Failed to write sqlc.yaml: %v", err)
+	}
+	if err := createBufYamlFile(filepath.Join(tmpDir, "ent")); err != nil {
+		t.Fatalf("Failed to write buf.yaml: %v", err)
+	}
+	bufYamlPath := filepath.Join(tmpDir, "ent", "buf.yaml")
+	bufYaml, err := os.ReadFile(bufYamlPath)
+	if err != nil {
+		t.Fatalf("Failed to read buf.yaml: %v", err)
+	}
+
+	// no date or latlng field, buf.yaml is left as entlite new wrote it
+	genCommand([]string{schemaDir})
+
+	content, err := os.ReadFile(bufYamlPath)
+	if err != nil {
+		t.Fatalf("Failed to read buf.yaml: %v", err)
+	}
+	if string(content) != string(bufYaml) {
+		t.Errorf("buf.yaml should be unchanged, got:\n%s", content)
+	}
+
+	userSchemaPath := filepath.Join(schemaDir, "user.go")
+	content, err = os.ReadFile(userSchemaPath)
+	if err != nil {
+		t.Fatalf("Failed to read user schema: %v", err)
+	}
+	changed := strings.Replace(string(content), `field.Int64("last_login_ms"),`, `field.Int64("last_login_ms"),
+		field.Date("birthday").Optional(),`, 1)
+	if err := os.WriteFile(userSchemaPath, []byte(changed), 0644); err != nil {
+		t.Fatalf("Failed to write user schema: %v", err)
+	}
+
+	genCommand([]string{schemaDir})
+
+	content, err = os.ReadFile(bufYamlPath)
+	if err != nil {
+		t.Fatalf("Failed to read buf.yaml: %v", err)
+	}
+	want := "deps:\n  - buf.build/bufbuild/protovalidate\n  - buf.build/googleapis/googleapis\n"
+	if !strings.Contains(string(content), want) {
+		t.Errorf("buf.yaml does not contain:\n%s\ngot:\n%s", want, content)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/guntisdev/entlite/internal/schema"
+)
+
+func TestImportDB(t *testing.T) {
+	tmpDir := t.TempDir()
+	dbPath := filepath.Join(tmpDir, "legacy.db")
+	schemaDir := filepath.Join(tmpDir, "ent", "schema")
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	_, err = db.Exec(`CREATE TABLE customer (id INTEGER PRIMARY KEY, email TEXT NOT NULL UNIQUE, nickname TEXT)`)
+	db.Close()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	entities, err := importDB(schema.SQLite, dbPath, "", schemaDir)
+	if err != nil {
+		t.Fatalf("importDB failed: %v", err)
+	}
+	if len(entities) != 1 || len(entities[0].Unmapped) != 0 {
+		t.Fatalf("expected one fully mapped entity, got %+v", entities)
+	}
+	assertFileContains(t, filepath.Join(schemaDir, "customer.go"), `		field.String("email").Unique(),
+		field.String("nickname").Optional(),
+`)
+
+	if _, err := importDB("oracle", dbPath, "", schemaDir); err == nil {
+		t.Error("expected an error for an unknown dialect")
+	}
+	if _, err := importDB(schema.PostgreSQL, "", filepath.Join(tmpDir, "dump.sql"), schemaDir); err == nil {
+		t.Error("expected an error for a postgres DDL dump, only a sqlite one loads")
+	}
+}
+
+func TestImportProto(t *testing.T) {
+	tmpDir := t.TempDir()
+	protoPath := filepath.Join(tmpDir, "api.proto")
+	schemaDir := filepath.Join(tmpDir, "ent", "schema")
+
+	api := `syntax = "proto3";
+message Customer {
+  int32 ID = 1;
+  string email = 7;
+  optional string nickname = 3;
+}
+message GetCustomerByEmailRequest {
+  string email = 7;
+}
+service CustomerService {
+  rpc GetByEmail(GetCustomerByEmailRequest) returns (Customer);
+}
+`
+	if err := os.WriteFile(protoPath, []byte(api), 0644); err != nil {
+		t.Fatalf("Failed to write proto: %v", err)
+	}
+
+	entities, orphans, err := importProto(protoPath, schemaDir)
+	if err != nil {
+		t.Fatalf("importProto failed: %v", err)
+	}
+	if len(entities) != 1 || len(entities[0].Unmapped) != 0 || len(orphans) != 0 {
+		t.Fatalf("expected one fully mapped entity, got %d entities, orphans %q", len(entities), orphans)
+	}
+	assertFileContains(t, filepath.Join(schemaDir, "customer.go"), `		field.String("email").ProtoField(7),
+		field.String("nickname").ProtoField(3).Optional(),
+`)
+	assertFileContains(t, filepath.Join(schemaDir, "customer.go"), `		query.GetBy("email"),
+`)
+}
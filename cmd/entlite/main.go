@@ -23,6 +23,14 @@ func main() {
 		sqlcWrapCommand()
 	case "proto-validate":
 		protoValidate()
+	case "migrate":
+		migrateCommand(os.Args[2:])
+	case "breaking":
+		breakingCommand(os.Args[2:])
+	case "import":
+		importCommand(os.Args[2:])
+	case "drift":
+		driftCommand(os.Args[2:])
 	default:
 		// TODO print usage with new and gen commands
 		fmt.Println("Unknow argument")
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMigrateDiff(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	schemaDir := filepath.Join(tmpDir, "ent", "schema")
+	logicDir := filepath.Join(tmpDir, "ent", "logic")
+	migrationsDir := filepath.Join(tmpDir, "ent", "migrations")
+
+	if err := os.MkdirAll(schemaDir, 0755); err != nil {
+		t.Fatalf("Failed to create schema directory: %v", err)
+	}
+
+	if err := os.MkdirAll(logicDir, 0755); err != nil {
+		t.Fatalf("Failed to create logic directory: %v", err)
+	}
+
+	writeTestGoMod(t, tmpDir)
+	writeTestUserSchema(t, schemaDir)
+	writeTestLogic(t, logicDir)
+
+	sqlcYamlContent := `version: "2"
+sql:
+  - schema: "contract/sqlc/schema.sql"
+    queries: "contract/sqlc/queries.sql"
+    engine: "postgresql"
+    gen:
+      go:
+        package: "internal"
+        out: "gen/db/internal"`
+
+	sqlcYamlPath := filepath.Join(tmpDir, "ent", "sqlc.yaml")
+	if err := os.WriteFile(sqlcYamlPath, []byte(sqlcYamlContent), 0644); err != nil {
+		t.Fatalf("Failed to write sqlc.yaml: %v", err)
+	}
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	// First migration creates the tables
+	files, err := migrateDiff(schemaDir, sqlcYamlPath, migrationsDir, "Initial schema", now)
+	if err != nil {
+		t.Fatalf("migrateDiff failed: %v", err)
+	}
+	wantFiles := []string{
+		filepath.Join(migrationsDir, "20260301120000_initial_schema.up.sql"),
+		filepath.Join(migrationsDir, "20260301120000_initial_schema.down.sql"),
+	}
+	if strings.Join(files, ",") != strings.Join(wantFiles, ",") {
+		t.Fatalf("files = %v, want %v", files, wantFiles)
+	}
+	assertFileContains(t, files[0], `CREATE TABLE "user"(`)
+	assertFileContains(t, files[1], `DROP TABLE "user";`)
+	assertFileContains(t, filepath.Join(migrationsDir, "migrations.go"), "//go:embed *.sql\nvar FS embed.FS")
+
+	// Nothing changed, nothing written
+	files, err = migrateDiff(schemaDir, sqlcYamlPath, migrationsDir, "noop", now)
+	if err != nil {
+		t.Fatalf("migrateDiff failed: %v", err)
+	}
+	if len(files) != 0 {
+		t.Fatalf("expected no migration for an unchanged schema, got %v", files)
+	}
+
+	// Age becomes required with a default, last_login_ms goes away
+	userSchemaPath := filepath.Join(schemaDir, "user.go")
+	content, err := os.ReadFile(userSchemaPath)
+	if err != nil {
+		t.Fatalf("Failed to read user schema: %v", err)
+	}
+	changed := strings.Replace(string(content), `field.Int("age").Optional(),`, `field.Int("age").Default(18),`, 1)
+	changed = strings.Replace(changed, `field.Int64("last_login_ms"),`, "", 1)
+	if err := os.WriteFile(userSchemaPath, []byte(changed), 0644); err != nil {
+		t.Fatalf("Failed to write user schema: %v", err)
+	}
+
+	files, err = migrateDiff(schemaDir, sqlcYamlPath, migrationsDir, "age default", now)
+	if err != nil {
+		t.Fatalf("migrateDiff failed: %v", err)
+	}
+	if len(files) != 2 || filepath.Base(files[0]) != "20260301120001_age_default.up.sql" {
+		t.Fatalf("expected the version after the initial one, got %v", files)
+	}
+
+	expectedUp := `-- user table
+ALTER TABLE "user" DROP COLUMN last_login_ms;
+UPDATE "user" SET age = 18 WHERE age IS NULL;
+ALTER TABLE "user" ALTER COLUMN age SET DEFAULT 18;
+ALTER TABLE "user" ALTER COLUMN age SET NOT NULL;
+`
+	assertFileContains(t, files[0], expectedUp)
+
+	expectedDown := `-- user table
+ALTER TABLE "user" ALTER COLUMN age DROP DEFAULT;
+ALTER TABLE "user" ALTER COLUMN age DROP NOT NULL;
+-- TODO user: last_login_ms is NOT NULL without a default, this fails on a table with rows, edit this migration by hand
+ALTER TABLE "user" ADD COLUMN last_login_ms BIGINT NOT NULL;
+`
+	assertFileContains(t, files[1], expectedDown)
+
+	steps, err := manualSteps(files)
+	if err != nil {
+		t.Fatalf("manualSteps failed: %v", err)
+	}
+	wantSteps := []string{files[1] + ": -- TODO user: last_login_ms is NOT NULL without a default, this fails on a table with rows, edit this migration by hand"}
+	if !slices.Equal(steps, wantSteps) {
+		t.Errorf("manualSteps = %q, want %q", steps, wantSteps)
+	}
+}
+
+func TestMigrateDiffRenames(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	schemaDir := filepath.Join(tmpDir, "ent", "schema")
+	logicDir := filepath.Join(tmpDir, "ent", "logic")
+	migrationsDir := filepath.Join(tmpDir, "ent", "migrations")
+
+	if err := os.MkdirAll(schemaDir, 0755); err != nil {
+		t.Fatalf("Failed to create schema directory: %v", err)
+	}
+
+	if err := os.MkdirAll(logicDir, 0755); err != nil {
+		t.Fatalf("Failed to create logic directory: %v", err)
+	}
+
+	writeTestGoMod(t, tmpDir)
+	writeTestUserSchema(t, schemaDir)
+	writeTestLogic(t, logicDir)
+
+	sqlcYamlPath := filepath.Join(tmpDir, "ent", "sqlc.yaml")
+	if err := os.WriteFile(sqlcYamlPath, []byte(`version: "2"
+sql:
+  - schema: "contract/sqlc/schema.sql"
+    queries: "contract/sqlc/queries.sql"
+    engine: "postgresql"
+    gen:
+      go:
+        package: "internal"
+        out: "gen/db/internal"`), 0644); err != nil {
+		t.Fatalf("Failed to write sqlc.yaml: %v", err)
+	}
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	if _, err := migrateDiff(schemaDir, sqlcYamlPath, migrationsDir, "initial", now); err != nil {
+		t.Fatalf("migrateDiff failed: %v", err)
+	}
+
+	// User becomes Account and name becomes full_name
+	userSchemaPath := filepath.Join(schemaDir, "user.go")
+	content, err := os.ReadFile(userSchemaPath)
+	if err != nil {
+		t.Fatalf("Failed to read user schema: %v", err)
+	}
+	changed := strings.ReplaceAll(string(content), "User", "Account")
+	changed = strings.Replace(changed, `field.String("name")`, `field.String("full_name").RenamedFrom("name")`, 1)
+	changed = strings.Replace(changed, `query.ListBy("name", "age")`, `query.ListBy("full_name", "age")`, 1)
+	changed += "\nfunc (Account) RenamedFrom() string {\n\treturn \"User\"\n}\n"
+	if err := os.WriteFile(userSchemaPath, []byte(changed), 0644); err != nil {
+		t.Fatalf("Failed to write user schema: %v", err)
+	}
+
+	files, err := migrateDiff(schemaDir, sqlcYamlPath, migrationsDir, "rename", now)
+	if err != nil {
+		t.Fatalf("migrateDiff failed: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("expected a rename migration, got %v", files)
+	}
+
+	expectedUp := `-- account table
+ALTER TABLE "user" RENAME TO "account";
+ALTER TABLE "account" RENAME COLUMN name TO full_name;
+DO $$
+DECLARE key_name text;
+BEGIN
+  SELECT conname INTO key_name FROM pg_constraint WHERE conrelid = '"account"'::regclass AND contype = 'u'
+    AND conkey = ARRAY[(SELECT attnum FROM pg_attribute WHERE attrelid = '"account"'::regclass AND attname = 'email')];
+  IF key_name IS NULL THEN
+    RAISE EXCEPTION 'no unique constraint on account.email';
+  END IF;
+  EXECUTE format('ALTER TABLE "account" RENAME CONSTRAINT %I TO "account_email_key"', key_name);
+END $$;
+`
+	assertFileContains(t, files[0], expectedUp)
+
+	expectedDown := `-- user table
+ALTER TABLE "account" RENAME TO "user";
+ALTER TABLE "user" RENAME COLUMN full_name TO name;
+DO $$
+`
+	assertFileContains(t, files[1], expectedDown)
+
+	// The snapshot holds the new names, the rename is not made twice
+	files, err = migrateDiff(schemaDir, sqlcYamlPath, migrationsDir, "noop", now)
+	if err != nil {
+		t.Fatalf("migrateDiff failed: %v", err)
+	}
+	if len(files) != 0 {
+		t.Fatalf("expected no migration after the rename, got %v", files)
+	}
+}
+
+func assertFileContains(t *testing.T, path, want string) {
+	t.Helper()
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", path, err)
+	}
+	if !strings.Contains(string(content), want) {
+		t.Errorf("%s does not contain:\n%s\ngot:\n%s", path, want, content)
+	}
+}
+
+func TestMigrateDiffBackfill(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	schemaDir := filepath.Join(tmpDir, "ent", "schema")
+	logicDir := filepath.Join(tmpDir, "ent", "logic")
+	migrationsDir := filepath.Join(tmpDir, "ent", "migrations")
+
+	if err := os.MkdirAll(schemaDir, 0755); err != nil {
+		t.Fatalf("Failed to create schema directory: %v", err)
+	}
+
+	if err := os.MkdirAll(logicDir, 0755); err != nil {
+		t.Fatalf("Failed to create logic directory: %v", err)
+	}
+
+	writeTestGoMod(t, tmpDir)
+	writeTestUserSchema(t, schemaDir)
+	writeTestLogic(t, logicDir)
+
+	sqlcYamlPath := filepath.Join(tmpDir, "ent", "sqlc.yaml")
+	if err := os.WriteFile(sqlcYamlPath, []byte(`version: "2"
+sql:
+  - schema: "contract/sqlc/schema.sql"
+    queries: "contract/sqlc/queries.sql"
+    engine: "postgresql"
+    gen:
+      go:
+        package: "internal"
+        out: "gen/db/internal"`), 0644); err != nil {
+		t.Fatalf("Failed to write sqlc.yaml: %v", err)
+	}
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	initial, err := migrateDiff(schemaDir, sqlcYamlPath, migrationsDir, "initial", now)
+	if err != nil {
+		t.Fatalf("migrateDiff failed: %v", err)
+	}
+	assertFileContains(t, filepath.Join(migrationsDir, "migrations.go"), "var FS embed.FS")
+	if content, err := os.ReadFile(initial[0]); err != nil || strings.Contains(string(content), "golang-migrate") {
+		t.Errorf("a migration without Go backfills runs with any tool, its header shouldn't say otherwise:\n%s", content)
+	}
+
+	// slug is computed in Go, nickname copies the name in sql
+	userSchemaPath := filepath.Join(schemaDir, "user.go")
+	content, err := os.ReadFile(userSchemaPath)
+	if err != nil {
+		t.Fatalf("Failed to read user schema: %v", err)
+	}
+	changed := strings.Replace(string(content), `field.Int("age").Optional(),`, `field.Int("age").Optional(),
+		field.String("slug").Backfill(logic.ComputeSlug),
+		field.String("nickname").BackfillSQL("name"),`, 1)
+	if err := os.WriteFile(userSchemaPath, []byte(changed), 0644); err != nil {
+		t.Fatalf("Failed to write user schema: %v", err)
+	}
+
+	files, err := migrateDiff(schemaDir, sqlcYamlPath, migrationsDir, "add slug", now)
+	if err != nil {
+		t.Fatalf("migrateDiff failed: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("expected a backfill migration, got %v", files)
+	}
+
+	expectedUp := `-- user table
+ALTER TABLE "user" ADD COLUMN slug TEXT;
+-- entlite:backfill user slug ID github.com/guntisdev/entlite/examples/01-basic-entity/ent/logic.ComputeSlug
+ALTER TABLE "user" ADD COLUMN nickname TEXT;
+UPDATE "user" SET nickname = name WHERE nickname IS NULL;
+ALTER TABLE "user" ALTER COLUMN slug SET NOT NULL;
+ALTER TABLE "user" ALTER COLUMN nickname SET NOT NULL;
+`
+	assertFileContains(t, files[0], expectedUp)
+	assertFileContains(t, files[0], `-- Generated by entlite migrate diff from postgresql
+-- Its Go backfills only run with migrate.Up of github.com/guntisdev/entlite/pkg/entlite/migrate and
+-- migrations.Backfills, golang-migrate and goose skip the -- entlite:backfill lines as comments
+`)
+	assertFileContains(t, filepath.Join(migrationsDir, "migrations.go"),
+		`"github.com/guntisdev/entlite/examples/01-basic-entity/ent/logic.ComputeSlug": migrate.Backfill(logic.ComputeSlug),`)
+}
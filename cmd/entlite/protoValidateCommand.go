@@ -12,7 +12,7 @@ import (
 
 func protoValidate() {
 	entityDir := "./schema"
-	parsedEntities, err := loadEntities(entityDir)
+	parsedEntities, _, err := loadEntities(entityDir)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error loading entities: %v\n", err)
 		os.Exit(1)
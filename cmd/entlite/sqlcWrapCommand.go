@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/guntisdev/entlite/internal/generator/sqlc"
 	sqlcwrap "github.com/guntisdev/entlite/internal/generator/sqlcWrap"
 	"github.com/guntisdev/entlite/internal/schema"
 	"github.com/guntisdev/entlite/internal/util"
@@ -13,7 +14,7 @@ import (
 
 func sqlcWrapCommand() {
 	entityDir := "./schema"
-	parsedEntities, err := loadEntities(entityDir)
+	parsedEntities, _, err := loadEntities(entityDir)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error loading entities: %v\n", err)
 		os.Exit(1)
@@ -87,31 +88,38 @@ func sqlcWrapCommand() {
 		os.Exit(1)
 	}
 	accessFilePath := filepath.Join(inputDir, sqlcwrap.EntliteAccessFileName)
-	accessContent := sqlcwrap.GenerateAccessFile(sqlcPackageName)
+	accessContent, err := sqlcwrap.GenerateAccessFile(inputDir, sqlcPackageName, sqlcEntities)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error generating %s file: %v\n", sqlcwrap.EntliteAccessFileName, err)
+		os.Exit(1)
+	}
 	if err := os.WriteFile(accessFilePath, []byte(accessContent), 0644); err != nil {
 		fmt.Fprintf(os.Stderr, "Error writing %s file: %v\n", sqlcwrap.EntliteAccessFileName, err)
 		os.Exit(1)
 	}
 
 	// Generate convert.go file with converter helper functions
-	hasTimeField := false
-	for _, entity := range sqlcEntities {
-		for _, field := range entity.Fields {
-			if field.Type == schema.FieldTypeTime {
-				hasTimeField = true
-				break
-			}
-		}
-		if hasTimeField {
-			break
-		}
-	}
-
 	convertFilePath := filepath.Join(outputDir, "convert.go")
-	convertContent := sqlcwrap.GenerateConvertFile("db", hasTimeField)
+	convertContent := sqlcwrap.GenerateConvertFile("db", sqlcEntities, sqlcConfig.Dialect)
 	err = os.WriteFile(convertFilePath, []byte(convertContent), 0644)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error writing convert.go file: %v\n", err)
 		os.Exit(1)
 	}
+
+	// Tables CheckSchema compares a live database to
+	schemaTables, err := sqlc.NewGenerator(sqlcConfig.Dialect).SchemaTables(parsedEntities)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error building schema tables: %v\n", err)
+		os.Exit(1)
+	}
+	schemaContent, err := sqlcwrap.GenerateSchemaFile("db", schemaTables, sqlcConfig.Dialect)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error generating %s file: %v\n", sqlcwrap.SchemaFileName, err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(filepath.Join(outputDir, sqlcwrap.SchemaFileName), []byte(schemaContent), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing %s file: %v\n", sqlcwrap.SchemaFileName, err)
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,246 @@
+//go:build integration
+// +build integration
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// TestSqlcWrapCreateStream builds a project with a CreateStream query through
+// entlite gen, sqlc and sqlc-wrap, then runs the generated wrapper against
+// sqlite with items failing in the middle of a batch
+func TestSqlcWrapCreateStream(t *testing.T) {
+	repoRoot, err := filepath.Abs(filepath.Join("..", ".."))
+	if err != nil {
+		t.Fatalf("Failed to resolve the repository root: %v", err)
+	}
+
+	tmpDir := t.TempDir()
+	entDir := filepath.Join(tmpDir, "ent")
+	schemaDir := filepath.Join(entDir, "schema")
+	logicDir := filepath.Join(entDir, "logic")
+	for _, dir := range []string{schemaDir, logicDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", dir, err)
+		}
+	}
+
+	// the generated code builds against this checkout of entlite
+	goMod := `module github.com/guntisdev/entlite/examples/01-basic-entity
+
+go 1.26.0
+
+require github.com/guntisdev/entlite v0.0.0
+
+replace github.com/guntisdev/entlite => ` + repoRoot + `
+`
+	goSum, err := os.ReadFile(filepath.Join(repoRoot, "go.sum"))
+	if err != nil {
+		t.Fatalf("Failed to read go.sum: %v", err)
+	}
+	files := map[string]string{
+		filepath.Join(tmpDir, "go.mod"):                             goMod,
+		filepath.Join(tmpDir, "go.sum"):                             string(goSum),
+		filepath.Join(schemaDir, "reading.go"):                      createStreamSchema,
+		filepath.Join(entDir, "sqlc.yaml"):                          createStreamSqlcYaml,
+		filepath.Join(entDir, "gen", "db", "create_stream_test.go"): createStreamTest,
+	}
+	writeTestLogic(t, logicDir)
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+
+	genCommand([]string{schemaDir})
+	if out, err := runGo(repoRoot, "tool", "sqlc", "generate", "-f", filepath.Join(entDir, "sqlc.yaml")); err != nil {
+		t.Fatalf("sqlc generate failed: %v\nOutput:\n%s", err, out)
+	}
+
+	originalDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current directory: %v", err)
+	}
+	if err := os.Chdir(entDir); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+	sqlcWrapCommand()
+	if err := os.Chdir(originalDir); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+
+	if out, err := runGo(tmpDir, "test", "-mod=mod", "./ent/gen/db"); err != nil {
+		t.Fatalf("the generated CreateStreamReading failed: %v\nOutput:\n%s", err, out)
+	}
+}
+
+func runGo(dir string, args ...string) (string, error) {
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	return string(out), err
+}
+
+const createStreamSchema = `package ent
+
+import (
+	"github.com/guntisdev/entlite/examples/01-basic-entity/ent/logic"
+	"github.com/guntisdev/entlite/pkg/entlite"
+	"github.com/guntisdev/entlite/pkg/entlite/field"
+	"github.com/guntisdev/entlite/pkg/entlite/query"
+)
+
+type Reading struct {
+	entlite.Schema
+}
+
+func (Reading) Contracts() []entlite.Contract {
+	return []entlite.Contract{
+		entlite.SQLC(),
+	}
+}
+
+func (Reading) Fields() []entlite.Field {
+	return []entlite.Field{
+		field.String("label").Validate(logic.StartsWithCapital),
+		field.Int("serial").Unique(),
+	}
+}
+
+func (Reading) Queries() []entlite.Query {
+	return []entlite.Query{
+		query.CreateStream(),
+	}
+}
+`
+
+const createStreamSqlcYaml = `version: "2"
+sql:
+  - schema: "contract/sqlc/schema.sql"
+    queries: "contract/sqlc/queries.sql"
+    engine: "sqlite"
+    gen:
+      go:
+        package: "internal"
+        out: "gen/db/internal"
+`
+
+// createStreamTest runs inside the generated db package
+const createStreamTest = `package db
+
+import (
+	"context"
+	"database/sql"
+	"iter"
+	"os"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+// readings yields a reading per serial, the ones in lowercase fail validation
+func readings(serials []int32, lowercase map[int32]bool) iter.Seq2[CreateStreamReadingParams, error] {
+	return func(yield func(CreateStreamReadingParams, error) bool) {
+		for _, serial := range serials {
+			label := "Reading"
+			if lowercase[serial] {
+				label = "reading"
+			}
+			if !yield(CreateStreamReadingParams{Label: label, Serial: serial}, nil) {
+				return
+			}
+		}
+	}
+}
+
+func serials(from, to int32) []int32 {
+	var result []int32
+	for serial := from; serial < to; serial++ {
+		result = append(result, serial)
+	}
+	return result
+}
+
+func count(t *testing.T, database *sql.DB) int {
+	t.Helper()
+	var n int
+	if err := database.QueryRow("SELECT count(*) FROM reading").Scan(&n); err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	return n
+}
+
+func TestCreateStream(t *testing.T) {
+	ctx := context.Background()
+	database, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer database.Close()
+	database.SetMaxOpenConns(1)
+	schemaSQL, err := os.ReadFile("../../contract/sqlc/schema.sql")
+	if err != nil {
+		t.Fatalf("read schema: %v", err)
+	}
+	if _, err := database.Exec(string(schemaSQL)); err != nil {
+		t.Fatalf("schema: %v", err)
+	}
+	q := New(database)
+
+	// an invalid item in the middle of the second batch is skipped, the rest of it is inserted
+	result, err := q.CreateStreamReading(ctx, readings(serials(1, 8), map[int32]bool{5: true}), 3)
+	if err != nil {
+		t.Fatalf("CreateStreamReading: %v", err)
+	}
+	if len(result.IDs) != 6 || len(result.Errors) != 1 || result.Errors[0].Index != 4 {
+		t.Fatalf("expected 6 ids and item 4 rejected, got %+v", result)
+	}
+	if n := count(t, database); n != 6 {
+		t.Fatalf("expected 6 rows, got %d", n)
+	}
+
+	// a duplicate serial in the middle of the second batch rolls that batch back
+	result, err = q.CreateStreamReading(ctx, readings([]int32{10, 11, 12, 13, 1, 14}, nil), 3)
+	if err == nil {
+		t.Fatal("expected the duplicate serial to fail the stream")
+	}
+	if len(result.IDs) != 3 {
+		t.Fatalf("expected the first batch to be kept, got %+v", result)
+	}
+	if n := count(t, database); n != 9 {
+		t.Fatalf("expected 9 rows, got %d", n)
+	}
+
+	// batches are 100 items when no size is given
+	result, err = q.CreateStreamReading(ctx, readings(append(serials(100, 250), 1), nil), 0)
+	if err == nil || len(result.IDs) != 100 {
+		t.Fatalf("expected one batch of 100 before the duplicate, got %d ids, %v", len(result.IDs), err)
+	}
+	if n := count(t, database); n != 109 {
+		t.Fatalf("expected 109 rows, got %d", n)
+	}
+
+	// inside the caller's transaction the stream commits nothing itself
+	tx, err := database.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	result, err = New(tx).CreateStreamReading(ctx, readings(serials(300, 305), nil), 2)
+	if err != nil || len(result.IDs) != 5 {
+		t.Fatalf("expected 5 ids, got %+v, %v", result, err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	if n := count(t, database); n != 109 {
+		t.Fatalf("expected the rolled back stream to leave 109 rows, got %d", n)
+	}
+}
+`
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	sqlcwrap "github.com/guntisdev/entlite/internal/generator/sqlcWrap"
 	"github.com/guntisdev/entlite/internal/util"
 )
 
@@ -293,3 +294,290 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (*User,
 		t.Errorf("queries.sql.go content mismatch (-expected +actual):\n%s", d)
 	}
 }
+
+func TestSqlcWrapProtoJSON(t *testing.T) {
+	deviceSchema := `package ent
+
+import (
+	"github.com/guntisdev/entlite/pkg/entlite"
+	"github.com/guntisdev/entlite/pkg/entlite/field"
+)
+
+type Device struct {
+	entlite.Schema
+}
+
+func (Device) Contracts() []entlite.Contract {
+	return []entlite.Contract{
+		entlite.SQLC(),
+		entlite.PROTO(),
+	}
+}
+
+func (Device) Fields() []entlite.Field {
+	return []entlite.Field{
+		field.JSON("settings").Default(` + "`{}`" + `).ProtoStruct(),
+		field.JSON("tags").Optional().ProtoValue(),
+		field.JSON("notes"),
+	}
+}
+`
+	sqlcModels := `// Code generated by sqlc. DO NOT EDIT.
+package internal
+
+type Device struct {
+	ID       int64
+	Settings string
+	Tags     *string
+	Notes    string
+}
+`
+	outputDir := runSqlcWrap(t, "sqlite", "device.go", deviceSchema, map[string]string{"models.go": sqlcModels})
+
+	// text that isn't an object or not json at all fails the conversion
+	assertFileContains(t, filepath.Join(outputDir, "models.go"), `func (m *Device) ToProto() (*pb.Device, error) {
+	if m == nil {
+		return nil, nil
+	}
+
+	settingsProto, protoErr := TextToStruct(m.Settings)
+	if protoErr != nil {
+		return nil, fmt.Errorf("Failed converting 'Device': field 'settings' is not a google.protobuf.Struct: %w", protoErr)
+	}
+	tagsProto, protoErr := TextPtrToValue(m.Tags)
+	if protoErr != nil {
+		return nil, fmt.Errorf("Failed converting 'Device': field 'tags' is not a google.protobuf.Value: %w", protoErr)
+	}
+
+	return &pb.Device{
+		ID: m.ID,
+		Settings: settingsProto,
+		Tags: tagsProto,
+		Notes: m.Notes,
+	}, nil
+}`)
+	assertFileContains(t, filepath.Join(outputDir, "convert.go"), `func TextToStruct(text string) (*structpb.Struct, error) {
+	s := &structpb.Struct{}
+	if err := protojson.Unmarshal([]byte(text), s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}`)
+}
+
+const readingStreamSchema = `package ent
+
+import (
+	"github.com/guntisdev/entlite/pkg/entlite"
+	"github.com/guntisdev/entlite/pkg/entlite/field"
+	"github.com/guntisdev/entlite/pkg/entlite/query"
+)
+
+type Reading struct {
+	entlite.Schema
+}
+
+func (Reading) Contracts() []entlite.Contract {
+	return []entlite.Contract{
+		entlite.SQLC(),
+	}
+}
+
+func (Reading) Fields() []entlite.Field {
+	return []entlite.Field{
+		field.Int("sensor_id"),
+		field.Float("value"),
+	}
+}
+
+func (Reading) Queries() []entlite.Query {
+	return []entlite.Query{
+		query.ListBy("sensor_id").Stream(),
+	}
+}
+`
+
+const readingSqlcDB = `// Code generated by sqlc. DO NOT EDIT.
+package internal
+
+import (
+	"context"
+	"database/sql"
+)
+
+type DBTX interface {
+	ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
+	PrepareContext(context.Context, string) (*sql.Stmt, error)
+	QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
+	QueryRowContext(context.Context, string, ...interface{}) *sql.Row
+}
+
+func New(db DBTX) *Queries {
+	return &Queries{db: db}
+}
+
+type Queries struct {
+	db DBTX
+}
+
+func (q *Queries) WithTx(tx *sql.Tx) *Queries {
+	return &Queries{
+		db: tx,
+	}
+}
+`
+
+const readingSqlcModels = `// Code generated by sqlc. DO NOT EDIT.
+package internal
+
+type Reading struct {
+	ID       int64
+	SensorID int64
+	Value    float64
+}
+`
+
+func TestSqlcWrapStream(t *testing.T) {
+	sqlcQueries := `// Code generated by sqlc. DO NOT EDIT.
+// source: queries.sql
+package internal
+
+import (
+	"context"
+)
+
+const listReadingBySensorId = ` + "`" + `-- name: ListReadingBySensorId :many
+SELECT id, sensor_id, value FROM "reading" WHERE sensor_id = ?1
+` + "`" + `
+
+func (q *Queries) ListReadingBySensorId(ctx context.Context, sensorID int64) ([]Reading, error) {
+	rows, err := q.db.QueryContext(ctx, listReadingBySensorId, sensorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Reading
+	for rows.Next() {
+		var i Reading
+		if err := rows.Scan(&i.ID, &i.SensorID, &i.Value); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+`
+	outputDir := runSqlcWrap(t, "sqlite", "reading.go", readingStreamSchema, map[string]string{
+		"db.go":          readingSqlcDB,
+		"models.go":      readingSqlcModels,
+		"queries.sql.go": sqlcQueries,
+	})
+
+	// the query and scan are sqlc's, the rows are yielded instead of collected
+	assertFileContains(t, filepath.Join(outputDir, "internal", "entlite_access.go"), `// ListReadingBySensorIdSeq yields the rows of ListReadingBySensorId one by one as they are scanned.
+func (q *Queries) ListReadingBySensorIdSeq(ctx context.Context, sensorID int64) iter.Seq2[Reading, error] {
+	return func(yield func(Reading, error) bool) {
+		rows, err := q.db.QueryContext(ctx, listReadingBySensorId, sensorID)
+		if err != nil {
+			yield(Reading{}, err)
+			return
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var i Reading
+			if err := rows.Scan(&i.ID, &i.SensorID, &i.Value); err != nil {
+				yield(Reading{}, err)
+				return
+			}
+			if !yield(i, nil) {
+				return
+			}
+		}
+		if err := rows.Err(); err != nil {
+			yield(Reading{}, err)
+		}
+	}
+}`)
+	assertFileContains(t, filepath.Join(outputDir, "queries.sql.go"), `func (q *Queries) ListReadingBySensorId(ctx context.Context, sensorID int32) iter.Seq2[*Reading, error] {
+	return func(yield func(*Reading, error) bool) {
+		for dbResult, err := range (*internal.Queries)(q).ListReadingBySensorIdSeq(ctx, IntConvert[int32, int64](sensorID)) {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(ReadingFromSQL(&dbResult), nil) {
+				return
+			}
+		}
+	}
+}`)
+}
+
+// TestSqlcWrapStreamPgx checks that a streamed query sqlc generated for pgx,
+// which has no QueryContext to copy, is reported instead of wrapped
+func TestSqlcWrapStreamPgx(t *testing.T) {
+	tmpDir := t.TempDir()
+	schemaDir := filepath.Join(tmpDir, "schema")
+	inputDir := filepath.Join(tmpDir, "internal")
+	for _, dir := range []string{schemaDir, inputDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", dir, err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(schemaDir, "reading.go"), []byte(readingStreamSchema), 0644); err != nil {
+		t.Fatalf("Failed to write schema: %v", err)
+	}
+
+	sqlcQueries := `// Code generated by sqlc. DO NOT EDIT.
+// source: queries.sql
+package internal
+
+import (
+	"context"
+)
+
+const listReadingBySensorId = ` + "`" + `-- name: ListReadingBySensorId :many
+SELECT id, sensor_id, value FROM "reading" WHERE sensor_id = $1
+` + "`" + `
+
+func (q *Queries) ListReadingBySensorId(ctx context.Context, sensorID int32) ([]Reading, error) {
+	rows, err := q.db.Query(ctx, listReadingBySensorId, sensorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Reading
+	for rows.Next() {
+		var i Reading
+		if err := rows.Scan(&i.ID, &i.SensorID, &i.Value); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+`
+	if err := os.WriteFile(filepath.Join(inputDir, "queries.sql.go"), []byte(sqlcQueries), 0644); err != nil {
+		t.Fatalf("Failed to write queries.sql.go: %v", err)
+	}
+
+	entities, _, err := loadEntities(schemaDir)
+	if err != nil {
+		t.Fatalf("loadEntities: %v", err)
+	}
+
+	_, err = sqlcwrap.GenerateAccessFile(inputDir, "internal", entities)
+	want := "stream query ListReadingBySensorId: expected a sqlc :many method using database/sql"
+	if err == nil || err.Error() != want {
+		t.Fatalf("GenerateAccessFile error = %v, want %q", err, want)
+	}
+}
@@ -112,3 +112,54 @@ func GenerateAPIKey() []byte {
 		t.Fatalf("Failed to write logic file: %v", err)
 	}
 }
+
+// runSqlcWrap writes the schema and sqlc's Go files into a fresh project and
+// runs sqlc-wrap on them, it returns the directory of the wrapper
+func runSqlcWrap(t *testing.T, engine, schemaFile, schemaSource string, sqlcFiles map[string]string) string {
+	t.Helper()
+
+	tmpDir := t.TempDir()
+	schemaDir := filepath.Join(tmpDir, "ent", "schema")
+	inputDir := filepath.Join(tmpDir, "ent", "gen", "db", "internal")
+	for _, dir := range []string{schemaDir, inputDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", dir, err)
+		}
+	}
+
+	writeTestGoMod(t, tmpDir)
+	if err := os.WriteFile(filepath.Join(schemaDir, schemaFile), []byte(schemaSource), 0644); err != nil {
+		t.Fatalf("Failed to write schema: %v", err)
+	}
+	for name, content := range sqlcFiles {
+		if err := os.WriteFile(filepath.Join(inputDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	sqlcYamlContent := `version: "2"
+sql:
+  - schema: "contract/sqlc/schema.sql"
+    queries: "contract/sqlc/queries.sql"
+    engine: "` + engine + `"
+    gen:
+      go:
+        package: "internal"
+        out: "gen/db/internal"
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "ent", "sqlc.yaml"), []byte(sqlcYamlContent), 0644); err != nil {
+		t.Fatalf("Failed to write sqlc.yaml: %v", err)
+	}
+
+	originalDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current directory: %v", err)
+	}
+	if err := os.Chdir(filepath.Join(tmpDir, "ent")); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+	defer os.Chdir(originalDir)
+
+	sqlcWrapCommand()
+	return filepath.Join(tmpDir, "ent", "gen", "db")
+}
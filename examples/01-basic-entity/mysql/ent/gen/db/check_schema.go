@@ -0,0 +1,41 @@
+package db
+
+import (
+	"context"
+
+	"github.com/guntisdev/entlite/pkg/entlite/migrate"
+)
+
+// Schema is the tables schema.sql creates
+var Schema = []migrate.Table{
+	{
+		Name: "user",
+		Columns: []migrate.Column{
+			{Name: "ID", Type: "INT"},
+			{Name: "email", Type: "VARCHAR(255)", NotNull: true, Unique: true},
+			{Name: "name", Type: "VARCHAR(255)", NotNull: true},
+			{Name: "age", Type: "INT"},
+			{Name: "password", Type: "VARCHAR(255)", NotNull: true},
+			{Name: "api_key", Type: "BLOB", NotNull: true},
+			{Name: "is_active", Type: "TINYINT(1)", NotNull: true},
+			{Name: "login_count", Type: "BIGINT", NotNull: true},
+			{Name: "rating", Type: "DOUBLE", NotNull: true},
+			{Name: "preferences", Type: "JSON", NotNull: true},
+			{Name: "created_at", Type: "TIMESTAMP", NotNull: true},
+			{Name: "updated_at", Type: "TIMESTAMP", NotNull: true},
+		},
+		PrimaryKey: []string{"ID"},
+		Indexes: []migrate.Index{
+			{Name: "idx_user_age_is_active", Columns: []string{"age", "is_active"}},
+			{Name: "idx_user_is_active_created_at", Columns: []string{"is_active", "created_at DESC"}},
+			{Name: "idx_user_name_email", Unique: true, Columns: []string{"name", "email"}},
+			{Name: "idx_users_stats", Columns: []string{"login_count", "rating"}},
+		},
+	},
+}
+
+// CheckSchema reports tables, columns and indexes of the database that differ
+// from Schema, the error is a *migrate.DriftError listing them
+func CheckSchema(ctx context.Context, db DBTX) error {
+	return migrate.CheckSchema(ctx, db, migrate.MySQL, Schema)
+}
@@ -3,6 +3,7 @@ package db
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"reflect"
 	"time"
 
@@ -40,6 +41,23 @@ func ProtoToNullTime(t *timestamppb.Timestamp) sql.NullTime {
 	}
 }
 
+func NullTimeToPtr(n sql.NullTime) *time.Time {
+	if !n.Valid {
+		return nil
+	}
+	return &n.Time
+}
+
+func PtrToNullTime(p *time.Time) sql.NullTime {
+	if p == nil {
+		return sql.NullTime{Valid: false}
+	}
+	return sql.NullTime{
+		Time:  *p,
+		Valid: true,
+	}
+}
+
 // txBeginner is satisfied by *sql.DB and *sql.Conn, but deliberately not by
 // *sql.Tx: a Queries already bound to a transaction runs inside the caller's
 // one rather than opening a nested one.
@@ -223,4 +241,20 @@ func PtrBytesToNullString(p *[]byte) sql.NullString {
         String: string(*p),
         Valid:  true,
     }
-}
\ No newline at end of file
+}
+
+// --- JSON Converters, JSONB/JSON columns come back as json.RawMessage ---
+func RawJSONToPtr(raw json.RawMessage) *string {
+	if raw == nil {
+		return nil
+	}
+	s := string(raw)
+	return &s
+}
+
+func PtrToRawJSON(p *string) json.RawMessage {
+	if p == nil {
+		return nil
+	}
+	return json.RawMessage(*p)
+}
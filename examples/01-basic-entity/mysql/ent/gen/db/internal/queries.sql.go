@@ -8,6 +8,7 @@ package internal
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"time"
 )
 
@@ -40,17 +41,17 @@ INSERT INTO ` + "`" + `user` + "`" + ` (
 `
 
 type CreateBulkUserParams struct {
-	Email       string        `json:"email"`
-	Name        string        `json:"name"`
-	Age         sql.NullInt32 `json:"age"`
-	Password    string        `json:"password"`
-	ApiKey      []byte        `json:"api_key"`
-	IsActive    bool          `json:"is_active"`
-	LoginCount  int64         `json:"login_count"`
-	Rating      float64       `json:"rating"`
-	Preferences string        `json:"preferences"`
-	CreatedAt   time.Time     `json:"created_at"`
-	UpdatedAt   time.Time     `json:"updated_at"`
+	Email       string          `json:"email"`
+	Name        string          `json:"name"`
+	Age         sql.NullInt32   `json:"age"`
+	Password    string          `json:"password"`
+	ApiKey      []byte          `json:"api_key"`
+	IsActive    bool            `json:"is_active"`
+	LoginCount  int64           `json:"login_count"`
+	Rating      float64         `json:"rating"`
+	Preferences json.RawMessage `json:"preferences"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
 }
 
 func (q *Queries) CreateBulkUser(ctx context.Context, arg CreateBulkUserParams) (int64, error) {
@@ -104,17 +105,17 @@ INSERT INTO ` + "`" + `user` + "`" + ` (
 `
 
 type CreateUserParams struct {
-	Email       string        `json:"email"`
-	Name        string        `json:"name"`
-	Age         sql.NullInt32 `json:"age"`
-	Password    string        `json:"password"`
-	ApiKey      []byte        `json:"api_key"`
-	IsActive    bool          `json:"is_active"`
-	LoginCount  int64         `json:"login_count"`
-	Rating      float64       `json:"rating"`
-	Preferences string        `json:"preferences"`
-	CreatedAt   time.Time     `json:"created_at"`
-	UpdatedAt   time.Time     `json:"updated_at"`
+	Email       string          `json:"email"`
+	Name        string          `json:"name"`
+	Age         sql.NullInt32   `json:"age"`
+	Password    string          `json:"password"`
+	ApiKey      []byte          `json:"api_key"`
+	IsActive    bool            `json:"is_active"`
+	LoginCount  int64           `json:"login_count"`
+	Rating      float64         `json:"rating"`
+	Preferences json.RawMessage `json:"preferences"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
 }
 
 // Generate queries.sql
@@ -354,7 +355,7 @@ type UpdateUserParams struct {
 	IsActive    sql.NullBool    `json:"is_active"`
 	LoginCount  sql.NullInt64   `json:"login_count"`
 	Rating      sql.NullFloat64 `json:"rating"`
-	Preferences sql.NullString  `json:"preferences"`
+	Preferences json.RawMessage `json:"preferences"`
 	UpdatedAt   time.Time       `json:"updated_at"`
 	ID          int32           `json:"ID"`
 }
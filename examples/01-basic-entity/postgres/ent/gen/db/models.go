@@ -1,6 +1,7 @@
 package db
 
 import (
+	"encoding/json"
 	pb "github.com/guntisdev/entlite/examples/01-basic-entity/postgres/ent/gen/pb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 	"time"
@@ -38,7 +39,7 @@ func (m *User) UserToSQL() *internal.User {
 		IsActive: m.IsActive,
 		LoginCount: m.LoginCount,
 		Rating: m.Rating,
-		Preferences: m.Preferences,
+		Preferences: json.RawMessage(m.Preferences),
 		CreatedAt: m.CreatedAt,
 		UpdatedAt: m.UpdatedAt,
 	}
@@ -59,7 +60,7 @@ func UserFromSQL(db *internal.User) *User {
 		IsActive: db.IsActive,
 		LoginCount: db.LoginCount,
 		Rating: db.Rating,
-		Preferences: db.Preferences,
+		Preferences: string(db.Preferences),
 		CreatedAt: db.CreatedAt,
 		UpdatedAt: db.UpdatedAt,
 	}
@@ -0,0 +1,48 @@
+package db
+
+import (
+	"context"
+
+	"github.com/guntisdev/entlite/pkg/entlite/migrate"
+)
+
+// Schema is the tables schema.sql creates
+var Schema = []migrate.Table{
+	{
+		Name: "reading",
+		Columns: []migrate.Column{
+			{Name: "ID", Type: "INTEGER"},
+			{Name: "sensor_id", Type: "INTEGER", NotNull: true},
+			{Name: "value", Type: "REAL", NotNull: true},
+			{Name: "quality", Type: "INTEGER", NotNull: true},
+			{Name: "flagged", Type: "INTEGER", NotNull: true},
+			{Name: "recorded_at", Type: "DATETIME", NotNull: true},
+			{Name: "created_at", Type: "DATETIME", NotNull: true},
+		},
+		PrimaryKey: []string{"ID"},
+	},
+	{
+		Name: "sensor",
+		Columns: []migrate.Column{
+			{Name: "ID", Type: "INTEGER"},
+			{Name: "code", Type: "TEXT", NotNull: true, Unique: true},
+			{Name: "label", Type: "TEXT", NotNull: true},
+			{Name: "kind", Type: "TEXT", NotNull: true},
+			{Name: "unit", Type: "TEXT", NotNull: true},
+			{Name: "location", Type: "TEXT"},
+			{Name: "active", Type: "INTEGER", NotNull: true},
+			{Name: "firmware", Type: "TEXT", NotNull: true},
+			{Name: "sample_rate_ms", Type: "INTEGER", NotNull: true},
+			{Name: "installed_at", Type: "DATETIME", NotNull: true},
+			{Name: "created_at", Type: "DATETIME", NotNull: true},
+			{Name: "updated_at", Type: "DATETIME", NotNull: true},
+		},
+		PrimaryKey: []string{"ID"},
+	},
+}
+
+// CheckSchema reports tables, columns and indexes of the database that differ
+// from Schema, the error is a *migrate.DriftError listing them
+func CheckSchema(ctx context.Context, db DBTX) error {
+	return migrate.CheckSchema(ctx, db, migrate.SQLite, Schema)
+}
@@ -49,7 +49,7 @@ SELECT * FROM "article" WHERE author = @author;
 SELECT * FROM "article";
 
 -- name: ListArticleFilterByAuthorIsFeaturedPublishedAtTitle :many
-SELECT * FROM "article" WHERE author = @author AND is_featured = @is_featured AND published_at BETWEEN @min_published_at AND @max_published_at AND title LIKE @title;
+SELECT * FROM "article" WHERE author = @author AND is_featured = @is_featured AND (published_at >= sqlc.narg('min_published_at') OR sqlc.narg('min_published_at') IS NULL) AND (published_at <= sqlc.narg('max_published_at') OR sqlc.narg('max_published_at') IS NULL) AND title LIKE @title;
 
 -- name: UpdateArticle :one
 UPDATE "article" SET
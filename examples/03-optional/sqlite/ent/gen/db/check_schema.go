@@ -0,0 +1,37 @@
+package db
+
+import (
+	"context"
+
+	"github.com/guntisdev/entlite/pkg/entlite/migrate"
+)
+
+// Schema is the tables schema.sql creates
+var Schema = []migrate.Table{
+	{
+		Name: "article",
+		Columns: []migrate.Column{
+			{Name: "ID", Type: "TEXT"},
+			{Name: "slug", Type: "TEXT", NotNull: true, Unique: true},
+			{Name: "title", Type: "TEXT", NotNull: true},
+			{Name: "author", Type: "TEXT", NotNull: true},
+			{Name: "subtitle", Type: "TEXT"},
+			{Name: "reading_minutes", Type: "INTEGER"},
+			{Name: "last_viewed_ms", Type: "INTEGER"},
+			{Name: "rating", Type: "REAL"},
+			{Name: "cover_image", Type: "BLOB"},
+			{Name: "published_at", Type: "DATETIME"},
+			{Name: "metadata", Type: "TEXT"},
+			{Name: "is_featured", Type: "INTEGER", NotNull: true},
+			{Name: "created_at", Type: "DATETIME", NotNull: true},
+			{Name: "updated_at", Type: "DATETIME", NotNull: true},
+		},
+		PrimaryKey: []string{"ID"},
+	},
+}
+
+// CheckSchema reports tables, columns and indexes of the database that differ
+// from Schema, the error is a *migrate.DriftError listing them
+func CheckSchema(ctx context.Context, db DBTX) error {
+	return migrate.CheckSchema(ctx, db, migrate.SQLite, Schema)
+}
@@ -234,17 +234,25 @@ func (q *Queries) ListArticleByAuthor(ctx context.Context, author string) ([]Art
 }
 
 const listArticleFilterByAuthorIsFeaturedPublishedAtTitle = `-- name: ListArticleFilterByAuthorIsFeaturedPublishedAtTitle :many
-SELECT id, slug, title, author, subtitle, reading_minutes, last_viewed_ms, rating, cover_image, published_at, metadata, is_featured, created_at, updated_at FROM "article" WHERE author = ?1 AND is_featured = ?2 AND published_at BETWEEN ?3 AND ?4 AND title LIKE ?5
+SELECT id, slug, title, author, subtitle, reading_minutes, last_viewed_ms, rating, cover_image, published_at, metadata, is_featured, created_at, updated_at FROM "article" WHERE author = ?1 AND is_featured = ?2 AND (published_at >= ?3 OR ?3 IS NULL) AND (published_at <= ?4 OR ?4 IS NULL) AND title LIKE ?5
 `
 
 type ListArticleFilterByAuthorIsFeaturedPublishedAtTitleParams struct {
-	Author     string `json:"author"`
-	IsFeatured int64  `json:"is_featured"`
-	Title      string `json:"title"`
+	Author         string     `json:"author"`
+	IsFeatured     int64      `json:"is_featured"`
+	MinPublishedAt *time.Time `json:"min_published_at"`
+	MaxPublishedAt *time.Time `json:"max_published_at"`
+	Title          string     `json:"title"`
 }
 
 func (q *Queries) ListArticleFilterByAuthorIsFeaturedPublishedAtTitle(ctx context.Context, arg ListArticleFilterByAuthorIsFeaturedPublishedAtTitleParams) ([]Article, error) {
-	rows, err := q.db.QueryContext(ctx, listArticleFilterByAuthorIsFeaturedPublishedAtTitle, arg.Author, arg.IsFeatured, arg.Title)
+	rows, err := q.db.QueryContext(ctx, listArticleFilterByAuthorIsFeaturedPublishedAtTitle,
+		arg.Author,
+		arg.IsFeatured,
+		arg.MinPublishedAt,
+		arg.MaxPublishedAt,
+		arg.Title,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -96,6 +96,8 @@ func (q *Queries) ListArticleByAuthor(ctx context.Context, author string) ([]*Ar
 type ListArticleFilterByAuthorIsFeaturedPublishedAtTitleParams struct {
 	Author string `json:"author"`
 	IsFeatured bool `json:"is_featured"`
+	MinPublishedAt *time.Time `json:"min_published_at"`
+	MaxPublishedAt *time.Time `json:"max_published_at"`
 	Title string `json:"title"`
 }
 
@@ -103,6 +105,8 @@ func (q *Queries) ListArticleFilterByAuthorIsFeaturedPublishedAtTitle(ctx contex
 	internalArg := internal.ListArticleFilterByAuthorIsFeaturedPublishedAtTitleParams{
 		Author: arg.Author,
 		IsFeatured: SQLiteBoolToInt(arg.IsFeatured),
+		MinPublishedAt: arg.MinPublishedAt,
+		MaxPublishedAt: arg.MaxPublishedAt,
 		Title: arg.Title,
 	}
 	dbResults, err := (*internal.Queries)(q).ListArticleFilterByAuthorIsFeaturedPublishedAtTitle(ctx, internalArg)
@@ -0,0 +1,42 @@
+package db
+
+import (
+	"context"
+
+	"github.com/guntisdev/entlite/pkg/entlite/migrate"
+)
+
+// Schema is the tables schema.sql creates
+var Schema = []migrate.Table{
+	{
+		Name: "audit",
+		Columns: []migrate.Column{
+			{Name: "ID", Type: "INTEGER"},
+			{Name: "action", Type: "TEXT", NotNull: true},
+			{Name: "match_id", Type: "INTEGER", NotNull: true},
+			{Name: "detail", Type: "TEXT", NotNull: true},
+			{Name: "created_at", Type: "DATETIME", NotNull: true},
+		},
+		PrimaryKey: []string{"ID"},
+	},
+	{
+		Name: "match",
+		Columns: []migrate.Column{
+			{Name: "ID", Type: "INTEGER"},
+			{Name: "white", Type: "TEXT", NotNull: true},
+			{Name: "black", Type: "TEXT", NotNull: true},
+			{Name: "result", Type: "TEXT", NotNull: true},
+			{Name: "opening", Type: "TEXT"},
+			{Name: "moves", Type: "INTEGER", NotNull: true},
+			{Name: "played_at", Type: "DATETIME", NotNull: true},
+			{Name: "created_at", Type: "DATETIME", NotNull: true},
+		},
+		PrimaryKey: []string{"ID"},
+	},
+}
+
+// CheckSchema reports tables, columns and indexes of the database that differ
+// from Schema, the error is a *migrate.DriftError listing them
+func CheckSchema(ctx context.Context, db DBTX) error {
+	return migrate.CheckSchema(ctx, db, migrate.SQLite, Schema)
+}
@@ -40,6 +40,23 @@ func ProtoToNullTime(t *timestamppb.Timestamp) sql.NullTime {
 	}
 }
 
+func NullTimeToPtr(n sql.NullTime) *time.Time {
+	if !n.Valid {
+		return nil
+	}
+	return &n.Time
+}
+
+func PtrToNullTime(p *time.Time) sql.NullTime {
+	if p == nil {
+		return sql.NullTime{Valid: false}
+	}
+	return sql.NullTime{
+		Time:  *p,
+		Valid: true,
+	}
+}
+
 // txBeginner is satisfied by *sql.DB and *sql.Conn, but deliberately not by
 // *sql.Tx: a Queries already bound to a transaction runs inside the caller's
 // one rather than opening a nested one.
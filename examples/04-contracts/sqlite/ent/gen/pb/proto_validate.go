@@ -59,7 +59,8 @@ func (i *ValidateInterceptor) WrapStreamingClient(next connect.StreamingClientFu
 	return next
 }
 
-// WrapStreamingHandler implements connect.Interceptor.
+// WrapStreamingHandler implements connect.Interceptor. Server-streaming rpcs
+// read their single request through Receive as well, so it is validated here.
 func (i *ValidateInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
 	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
 		return next(ctx, &validatingHandlerConn{StreamingHandlerConn: conn})
@@ -0,0 +1,221 @@
+// Package breaking finds the changes between two versions of a schema that
+// break deployed proto clients or lose stored data.
+package breaking
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/guntisdev/entlite/internal/generator/proto"
+	"github.com/guntisdev/entlite/internal/schema"
+	"github.com/guntisdev/entlite/internal/util"
+	"github.com/guntisdev/entlite/pkg/entlite/permissions"
+)
+
+type Kind string
+
+const (
+	ProtoMessageRemoved  Kind = "proto_message_removed"
+	ProtoFieldRemoved    Kind = "proto_field_removed"
+	ProtoFieldRenumbered Kind = "proto_field_renumbered"
+	ProtoFieldRetyped    Kind = "proto_field_retyped"
+	ProtoRpcRenamed      Kind = "proto_rpc_renamed"
+	ProtoRpcRemoved      Kind = "proto_rpc_removed"
+	SQLTableDropped      Kind = "sql_table_dropped"
+	SQLColumnDropped     Kind = "sql_column_dropped"
+	SQLTypeNarrowed      Kind = "sql_type_narrowed"
+)
+
+// Change is one breaking change, Field is empty for a whole message or table
+// and holds the rpc name for rpc changes
+type Change struct {
+	Kind    Kind   `json:"kind"`
+	Entity  string `json:"entity"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// Check lists what breaks going from one schema to the next, entities in
+// schema order. Entities and fields renamed with RenamedFrom are compared
+// under their new names.
+func Check(from, to []schema.Entity) []Change {
+	var changes []Change
+	for _, old := range from {
+		i := slices.IndexFunc(to, func(e schema.Entity) bool { return e.Name == old.Name })
+		if i < 0 {
+			i = slices.IndexFunc(to, func(e schema.Entity) bool { return e.RenamedFrom == old.Name })
+		}
+		var entity schema.Entity
+		if i >= 0 {
+			entity = to[i]
+		}
+		if old.HasPROTO() {
+			changes = append(changes, checkProto(old, entity)...)
+		}
+		if old.HasSQLC() {
+			changes = append(changes, checkSQL(old, entity)...)
+		}
+	}
+	return changes
+}
+
+func checkProto(old, entity schema.Entity) []Change {
+	if !entity.HasPROTO() {
+		return []Change{{Kind: ProtoMessageRemoved, Entity: old.Name,
+			Message: fmt.Sprintf("message %s and service %sService are gone", old.Name, old.Name)}}
+	}
+
+	var changes []Change
+	for _, oldField := range old.Fields {
+		if !inProto(oldField) {
+			continue
+		}
+		field, ok := fieldOf(entity, oldField.Name)
+		switch {
+		case !ok || !inProto(field):
+			changes = append(changes, Change{Kind: ProtoFieldRemoved, Entity: old.Name, Field: oldField.Name,
+				Message: fmt.Sprintf("field %s = %d is gone", oldField.Name, oldField.ProtoField)})
+		case field.ProtoField != oldField.ProtoField:
+			changes = append(changes, Change{Kind: ProtoFieldRenumbered, Entity: old.Name, Field: oldField.Name,
+				Message: fmt.Sprintf("field %s moved from %d to %d", oldField.Name, oldField.ProtoField, field.ProtoField)})
+		case !sameWireType(proto.FieldType(oldField), proto.FieldType(field)):
+			changes = append(changes, Change{Kind: ProtoFieldRetyped, Entity: old.Name, Field: oldField.Name,
+				Message: fmt.Sprintf("field %s changed from %s to %s", oldField.Name, proto.FieldType(oldField), proto.FieldType(field))})
+		}
+	}
+
+	return append(changes, checkRpcs(old, entity)...)
+}
+
+// wireGroups are proto types that encode the same on the wire, a client of
+// the old type still reads the new one
+var wireGroups = [][]string{
+	{"int32", "int64", "bool"},
+	{"string", "bytes"},
+	{"repeated int32", "repeated int64", "repeated bool"},
+	{"repeated string", "repeated bytes"},
+}
+
+func sameWireType(from, to string) bool {
+	if from == to {
+		return true
+	}
+	for _, group := range wireGroups {
+		if slices.Contains(group, from) && slices.Contains(group, to) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkRpcs reports the rpcs clients can no longer call. A gone rpc is taken
+// as renamed when a new rpc of the same query type took its place.
+func checkRpcs(old, entity schema.Entity) []Change {
+	rpcNames := func(e schema.Entity) []string {
+		var names []string
+		for _, query := range e.Queries {
+			names = append(names, util.GenQueryRpcName(query, e.Name))
+		}
+		return names
+	}
+	oldNames, newNames := rpcNames(old), rpcNames(entity)
+
+	var added []schema.Query
+	for i, query := range entity.Queries {
+		if !slices.Contains(oldNames, newNames[i]) {
+			added = append(added, query)
+		}
+	}
+
+	var changes []Change
+	service := old.Name + "Service"
+	for i, query := range old.Queries {
+		name := oldNames[i]
+		if slices.Contains(newNames, name) {
+			continue
+		}
+		j := slices.IndexFunc(added, func(q schema.Query) bool { return q.Type == query.Type })
+		if j < 0 {
+			changes = append(changes, Change{Kind: ProtoRpcRemoved, Entity: old.Name, Field: name,
+				Message: fmt.Sprintf("rpc %s.%s is gone", service, name)})
+			continue
+		}
+		newName := util.GenQueryRpcName(added[j], entity.Name)
+		added = slices.Delete(added, j, j+1)
+		changes = append(changes, Change{Kind: ProtoRpcRenamed, Entity: old.Name, Field: name,
+			Message: fmt.Sprintf("rpc %s.%s is now %s", service, name, newName)})
+	}
+	return changes
+}
+
+func checkSQL(old, entity schema.Entity) []Change {
+	if !entity.HasSQLC() {
+		return []Change{{Kind: SQLTableDropped, Entity: old.Name,
+			Message: fmt.Sprintf("table %s and its rows are gone", old.Name)}}
+	}
+
+	var changes []Change
+	for _, oldField := range old.Fields {
+		if oldField.IsVirtual() {
+			continue
+		}
+		field, ok := fieldOf(entity, oldField.Name)
+		switch {
+		case !ok || field.IsVirtual():
+			changes = append(changes, Change{Kind: SQLColumnDropped, Entity: old.Name, Field: oldField.Name,
+				Message: fmt.Sprintf("column %s and its values are gone", oldField.Name)})
+		case !widens(oldField, field):
+			changes = append(changes, Change{Kind: SQLTypeNarrowed, Entity: old.Name, Field: oldField.Name,
+				Message: fmt.Sprintf("column %s changed from %s to %s, stored values may not fit", oldField.Name, typeName(oldField), typeName(field))})
+		}
+	}
+	return changes
+}
+
+// widens reports a type change every stored value survives, same type included
+func widens(from, to schema.Field) bool {
+	if from.Type == schema.FieldTypeDecimal && to.Type == schema.FieldTypeDecimal {
+		return to.Scale >= from.Scale && to.Precision-to.Scale >= from.Precision-from.Scale
+	}
+	if from.Type == to.Type {
+		return true
+	}
+
+	switch from.Type {
+	case schema.FieldTypeInt:
+		return slices.Contains([]schema.FieldType{schema.FieldTypeInt64, schema.FieldTypeFloat, schema.FieldTypeString}, to.Type) ||
+			(to.Type == schema.FieldTypeDecimal && to.Precision-to.Scale >= 10)
+	case schema.FieldTypeInt64:
+		return to.Type == schema.FieldTypeString ||
+			(to.Type == schema.FieldTypeDecimal && to.Precision-to.Scale >= 19)
+	case schema.FieldTypeDecimal:
+		return to.Type == schema.FieldTypeString
+	case schema.FieldTypeDate:
+		return to.Type == schema.FieldTypeTime
+	}
+	return false
+}
+
+// fieldOf finds a field by name, or the field renamed from it
+func fieldOf(entity schema.Entity, name string) (schema.Field, bool) {
+	if field, ok := entity.GetFieldByName(name); ok {
+		return field, true
+	}
+	i := slices.IndexFunc(entity.Fields, func(f schema.Field) bool { return f.RenamedFrom == name })
+	if i < 0 {
+		return schema.Field{}, false
+	}
+	return entity.Fields[i], true
+}
+
+func typeName(field schema.Field) string {
+	if field.Type == schema.FieldTypeDecimal {
+		return fmt.Sprintf("decimal(%d,%d)", field.Precision, field.Scale)
+	}
+	return string(field.Type)
+}
+
+// inProto reports a field some message of the entity carries
+func inProto(field schema.Field) bool {
+	return field.Permissions&(permissions.ApiRead|permissions.ApiWrite) != 0
+}
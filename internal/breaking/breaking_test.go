@@ -0,0 +1,119 @@
+package breaking
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/guntisdev/entlite/internal/schema"
+	"github.com/guntisdev/entlite/pkg/entlite/permissions"
+)
+
+func testEntity(fields []schema.Field, queries []schema.Query) schema.Entity {
+	return schema.Entity{
+		Name:      "User",
+		Fields:    fields,
+		Contracts: []schema.Contract{{Type: schema.ContractSQLC}, {Type: schema.ContractPROTO}},
+		Queries:   queries,
+	}
+}
+
+func TestCheck(t *testing.T) {
+	id := schema.Field{Name: "ID", Type: schema.FieldTypeInt, ProtoField: 1, Permissions: permissions.Default}
+	field := func(name string, fieldType schema.FieldType, number int) schema.Field {
+		return schema.Field{Name: name, Type: fieldType, ProtoField: number, Permissions: permissions.Default}
+	}
+	listByName := schema.Query{Type: schema.QueryListBy, Fields: []string{"name"}}
+
+	old := testEntity([]schema.Field{
+		id,
+		field("name", schema.FieldTypeString, 2),
+		field("age", schema.FieldTypeInt, 3),
+		field("score", schema.FieldTypeInt64, 4),
+		field("email", schema.FieldTypeString, 5),
+		{Name: "price", Type: schema.FieldTypeDecimal, Precision: 10, Scale: 2, ProtoField: 6, Permissions: permissions.Default},
+	}, []schema.Query{{Type: schema.QueryCreate}, listByName, {Type: schema.QueryDelete}})
+
+	tests := []struct {
+		name string
+		to   []schema.Entity
+		want []Kind
+	}{
+		{
+			name: "unchanged",
+			to:   []schema.Entity{old},
+			want: nil,
+		},
+		{
+			name: "widened types break nothing",
+			to: []schema.Entity{testEntity([]schema.Field{
+				id,
+				field("name", schema.FieldTypeString, 2),
+				field("age", schema.FieldTypeInt64, 3),
+				field("score", schema.FieldTypeInt64, 4),
+				field("email", schema.FieldTypeString, 5),
+				{Name: "price", Type: schema.FieldTypeDecimal, Precision: 14, Scale: 4, ProtoField: 6, Permissions: permissions.Default},
+			}, old.Queries)},
+			want: nil, // int32 and int64 are both varints on the wire
+		},
+		{
+			name: "removed, renumbered and narrowed",
+			to: []schema.Entity{testEntity([]schema.Field{
+				id,
+				field("name", schema.FieldTypeString, 2),
+				field("age", schema.FieldTypeInt, 7),
+				field("score", schema.FieldTypeString, 4),
+				{Name: "price", Type: schema.FieldTypeDecimal, Precision: 10, Scale: 1, ProtoField: 6, Permissions: permissions.Default},
+			}, old.Queries)},
+			want: []Kind{ProtoFieldRenumbered, ProtoFieldRetyped, ProtoFieldRemoved, SQLColumnDropped, SQLTypeNarrowed},
+		},
+		{
+			name: "narrowed to a wire compatible type",
+			to: []schema.Entity{testEntity([]schema.Field{
+				id,
+				field("name", schema.FieldTypeString, 2),
+				field("age", schema.FieldTypeInt, 3),
+				field("score", schema.FieldTypeInt, 4),
+				field("email", schema.FieldTypeString, 5),
+				old.Fields[5],
+			}, old.Queries)},
+			want: []Kind{SQLTypeNarrowed},
+		},
+		{
+			name: "renamed field breaks nothing",
+			to: []schema.Entity{testEntity([]schema.Field{
+				id,
+				{Name: "full_name", Type: schema.FieldTypeString, ProtoField: 2, Permissions: permissions.Default, RenamedFrom: "name"},
+				field("age", schema.FieldTypeInt, 3),
+				field("score", schema.FieldTypeInt64, 4),
+				field("email", schema.FieldTypeString, 5),
+				old.Fields[5],
+			}, []schema.Query{{Type: schema.QueryCreate}, {Type: schema.QueryListBy, Fields: []string{"full_name"}}, {Type: schema.QueryDelete}})},
+			want: []Kind{ProtoRpcRenamed}, // ListUserByName follows the field to ListUserByFullName
+		},
+		{
+			name: "rpc renamed and removed",
+			to: []schema.Entity{testEntity(old.Fields, []schema.Query{
+				{Type: schema.QueryCreate},
+				{Type: schema.QueryListBy, Fields: []string{"email"}},
+			})},
+			want: []Kind{ProtoRpcRenamed, ProtoRpcRemoved},
+		},
+		{
+			name: "entity removed",
+			to:   nil,
+			want: []Kind{ProtoMessageRemoved, SQLTableDropped},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var kinds []Kind
+			for _, change := range Check([]schema.Entity{old}, tt.to) {
+				kinds = append(kinds, change.Kind)
+			}
+			if !slices.Equal(kinds, tt.want) {
+				t.Errorf("kinds = %v, want %v", kinds, tt.want)
+			}
+		})
+	}
+}
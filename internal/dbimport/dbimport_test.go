@@ -0,0 +1,212 @@
+package dbimport
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/guntisdev/entlite/internal/parser"
+	"github.com/guntisdev/entlite/internal/schema"
+	"github.com/guntisdev/entlite/pkg/entlite/migrate"
+
+	_ "modernc.org/sqlite"
+)
+
+const legacyDDL = `
+CREATE TABLE sensor (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  code VARCHAR(32) NOT NULL UNIQUE,
+  -- Human friendly name
+  label TEXT NOT NULL DEFAULT 'unnamed',
+  active BOOLEAN NOT NULL DEFAULT 1,
+  archived INTEGER DEFAULT false NOT NULL,
+  flagged BOOLEAN,
+  price DECIMAL(10,2) DEFAULT 0.00,
+  serial INTEGER NOT NULL UNIQUE,
+  installed_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  location POINT,
+  CHECK (price >= 0)
+);
+CREATE INDEX idx_sensor_label ON sensor(label);
+CREATE UNIQUE INDEX sensor_label_active ON sensor(label, installed_at DESC, active) WHERE active = 1;
+CREATE INDEX sensor_lower_label ON sensor(lower(label));
+
+CREATE TABLE sensor_reading (
+  sensor_id INTEGER NOT NULL REFERENCES sensor(id),
+  taken_on DATE NOT NULL,
+  value REAL,
+  PRIMARY KEY (sensor_id, taken_on)
+);
+
+CREATE TABLE schema_migrations (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL);
+`
+
+func TestImportSQLite(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if _, err := db.ExecContext(ctx, legacyDDL); err != nil {
+		t.Fatalf("loading ddl: %v", err)
+	}
+
+	tables, err := migrate.Inspect(ctx, db, migrate.SQLite)
+	if err != nil {
+		t.Fatalf("Inspect: %v", err)
+	}
+	entities, err := Entities(tables)
+	if err != nil {
+		t.Fatalf("Entities: %v", err)
+	}
+	if len(entities) != 2 || entities[0].Name != "Sensor" || entities[1].Name != "SensorReading" {
+		t.Fatalf("expected Sensor and SensorReading, got %+v", entities)
+	}
+
+	sensor := string(entities[0].Source)
+	for _, want := range []string{
+		`field.String("code").Unique(),`,
+		`field.String("label").Default("unnamed").Comment("Human friendly name"),`,
+		`field.Bool("active").Default(true),`,
+		`field.Bool("archived").Default(false),`,
+		`field.Decimal("price").Precision(10, 2).Optional().Default("0.00"),`,
+		`field.Int("serial"),`,
+		`field.Time("installed_at").DefaultFunc(time.Now),`,
+		`field.String("location").Optional(),`,
+		`index.Fields("serial").Unique(),`,
+		`index.Fields("label"),`,
+		`index.Fields("label").Desc("installed_at").Asc("active").Unique().Where("active = 1").Name("sensor_label_active"),`,
+	} {
+		if !strings.Contains(sensor, want) {
+			t.Errorf("sensor.go does not contain %s:\n%s", want, sensor)
+		}
+	}
+	if strings.Contains(sensor, `field.Int("id")`) {
+		t.Errorf("the INTEGER id is the one entlite adds, it shouldn't be declared:\n%s", sensor)
+	}
+
+	wantUnmapped := []string{
+		`column flagged is a nullable bool, field.Bool has no Optional()`,
+		`column location type "POINT" has no field type, imported as field.String`,
+		`index sensor_lower_label covers an expression, only column indexes are imported`,
+		`CHECK constraints are not imported`,
+	}
+	if !slices.Equal(entities[0].Unmapped, wantUnmapped) {
+		t.Errorf("sensor unmapped = %q, want %q", entities[0].Unmapped, wantUnmapped)
+	}
+	if reading := string(entities[1].Source); !strings.Contains(reading, `index.Primary("sensor_id", "taken_on"),`) {
+		t.Errorf("sensor_reading.go has no compound primary key:\n%s", reading)
+	}
+	if unmapped := strings.Join(entities[1].Unmapped, "\n"); !strings.Contains(unmapped, "foreign key sensor_id references sensor(id)") {
+		t.Errorf("sensor_reading should report its foreign key, got:\n%s", unmapped)
+	}
+
+	// the files parse as a schema
+	dir := t.TempDir()
+	for _, entity := range entities {
+		if err := os.WriteFile(filepath.Join(dir, entity.FileName()), entity.Source, 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	discovered, err := parser.DiscoverEntities(dir)
+	if err != nil {
+		t.Fatalf("DiscoverEntities: %v", err)
+	}
+	parsed, err := parser.ParseEntities(discovered, nil)
+	if err != nil {
+		t.Fatalf("ParseEntities: %v", err)
+	}
+	i := slices.IndexFunc(parsed, func(e schema.Entity) bool { return e.Name == "Sensor" })
+	if i < 0 || len(parsed[i].Indexes) != 3 {
+		t.Fatalf("expected Sensor with 3 indexes, got %+v", parsed)
+	}
+}
+
+// TestImportCatalogTypes maps tables as migrate.Inspect reads them from the
+// postgres and mysql catalogs
+func TestImportCatalogTypes(t *testing.T) {
+	tables := []migrate.Table{
+		{
+			Name:       "device",
+			PrimaryKey: []string{"id"},
+			Columns: []migrate.Column{
+				{Name: "id", Type: "integer", NotNull: true, Default: "nextval('device_id_seq'::regclass)"},
+				{Name: "name", Type: "character varying(64)", NotNull: true, Default: "'unnamed'", Comment: "Shown in the app"},
+				{Name: "offset", Type: "integer", NotNull: true, Default: "'-1'"},
+				{Name: "labels", Type: "text[]"},
+				{Name: "channels", Type: "integer[]"},
+				{Name: "price", Type: "numeric(10,2)"},
+				{Name: "seen_at", Type: "timestamp with time zone", NotNull: true, Default: "now()"},
+				{Name: "serial", Type: "bigint", NotNull: true, Unique: true},
+			},
+			Indexes: []migrate.Index{
+				{Name: "device_seen", Columns: []string{"seen_at DESC", `"offset"`}, Partial: true, Where: "serial > 0"},
+				{Name: "device_lower_name", Columns: []string{"lower((name)::text)"}},
+			},
+		},
+		{
+			Name:       "reading",
+			PrimaryKey: []string{"id"},
+			Columns: []migrate.Column{
+				{Name: "id", Type: "bigint", NotNull: true},
+				{Name: "payload", Type: "longblob"},
+				{Name: "note", Type: "mediumtext", Default: "'n/a'"},
+				{Name: "ok", Type: "tinyint(1)", NotNull: true, Default: "1"},
+				{Name: "taken_at", Type: "timestamp", NotNull: true, Default: "CURRENT_TIMESTAMP"},
+				{Name: "count", Type: "int unsigned", NotNull: true, Default: "0"},
+			},
+		},
+	}
+
+	entities, err := Entities(tables)
+	if err != nil {
+		t.Fatalf("Entities: %v", err)
+	}
+
+	device := string(entities[0].Source)
+	for _, want := range []string{
+		`field.String("name").Default("unnamed").Comment("Shown in the app"),`,
+		`field.Int("offset").Default(-1),`,
+		`field.Strings("labels").Optional(),`,
+		`field.Ints("channels").Optional(),`,
+		`field.Decimal("price").Precision(10, 2).Optional(),`,
+		`field.Time("seen_at").DefaultFunc(time.Now),`,
+		`field.Int64("serial"),`,
+		`index.Fields("serial").Unique(),`,
+		`index.Fields("seen_at", "offset").Where("serial > 0").Name("device_seen"),`,
+	} {
+		if !strings.Contains(device, want) {
+			t.Errorf("device.go does not contain %s:\n%s", want, device)
+		}
+	}
+	if strings.Contains(device, `field.Int("id")`) {
+		t.Errorf("the integer id is the one entlite adds, it shouldn't be declared:\n%s", device)
+	}
+	wantUnmapped := []string{
+		`index device_seen sorts seen_at DESC, the first column of an index is imported ascending`,
+		`index device_lower_name covers an expression, only column indexes are imported`,
+	}
+	if !slices.Equal(entities[0].Unmapped, wantUnmapped) {
+		t.Errorf("device unmapped = %q, want %q", entities[0].Unmapped, wantUnmapped)
+	}
+
+	reading := string(entities[1].Source)
+	for _, want := range []string{
+		`field.Int64("id"),`,
+		`field.Byte("payload").Optional(),`,
+		`field.String("note").Optional().Default("n/a"),`,
+		`field.Bool("ok").Default(true),`,
+		`field.Time("taken_at").DefaultFunc(time.Now),`,
+		`field.Int64("count").Default(0),`,
+	} {
+		if !strings.Contains(reading, want) {
+			t.Errorf("reading.go does not contain %s:\n%s", want, reading)
+		}
+	}
+}
@@ -0,0 +1,338 @@
+// Package dbimport writes the entlite schema that generates the tables of an
+// existing database, as migrate.Inspect reads them.
+package dbimport
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/guntisdev/entlite/internal/schemafile"
+	"github.com/guntisdev/entlite/pkg/entlite/migrate"
+)
+
+// Entity is the schema file of one table and what in the table it couldn't
+// express. The schema still builds without those, they are left to do by hand.
+type Entity struct {
+	Name     string
+	Table    string
+	Source   []byte
+	Unmapped []string
+}
+
+// FileName is the schema file of the entity, e.g. sensor.go
+func (e Entity) FileName() string {
+	return strings.ToLower(e.Name) + ".go"
+}
+
+// Entities maps tables to schema files, in table order
+func Entities(tables []migrate.Table) ([]Entity, error) {
+	var entities []Entity
+	for _, table := range tables {
+		entity, err := tableEntity(table)
+		if err != nil {
+			return nil, fmt.Errorf("table %s: %w", table.Name, err)
+		}
+		entities = append(entities, entity)
+	}
+	return entities, nil
+}
+
+// field is one field.* call of Fields()
+type field struct {
+	constructor string // e.g. String, Decimal
+	name        string
+	modifiers   []string // e.g. Optional(), Default(0)
+	unique      bool     // only String and Byte have Unique()
+}
+
+type entityBuilder struct {
+	table    migrate.Table
+	name     string
+	fields   []field
+	indexes  []string // index.* expressions
+	unmapped []string
+}
+
+func (b *entityBuilder) report(format string, args ...any) {
+	b.unmapped = append(b.unmapped, fmt.Sprintf(format, args...))
+}
+
+func tableEntity(table migrate.Table) (Entity, error) {
+	b := &entityBuilder{table: table, name: entityName(table.Name)}
+	if strings.ToLower(b.name) != table.Name {
+		b.report("entlite names the table of %s %q, rename the table or move the data", b.name, strings.ToLower(b.name))
+	}
+
+	idKey := len(table.PrimaryKey) == 1 && strings.EqualFold(table.PrimaryKey[0], "id")
+	if !idKey && !hasColumn(table, "id") {
+		b.report("no id column, entlite adds an ID INTEGER PRIMARY KEY")
+	}
+	if len(table.PrimaryKey) > 0 && !idKey {
+		b.indexes = append(b.indexes, fmt.Sprintf("index.Primary(%s)", quoteAll(table.PrimaryKey)))
+	}
+
+	for _, column := range table.Columns {
+		// the id entlite adds is an INTEGER PRIMARY KEY, only another type is declared
+		if idKey && strings.EqualFold(column.Name, "id") && columnFieldType(column.Type) == "Int" {
+			continue
+		}
+		b.fields = append(b.fields, b.field(column))
+	}
+
+	// a one column UNIQUE goes on the field when it can take it
+	for _, column := range table.Columns {
+		if !column.Unique || (idKey && strings.EqualFold(column.Name, "id")) {
+			continue
+		}
+		i := slices.IndexFunc(b.fields, func(f field) bool { return f.name == column.Name })
+		if i >= 0 && (b.fields[i].constructor == "String" || b.fields[i].constructor == "Byte") {
+			b.fields[i].unique = true
+		} else {
+			b.indexes = append(b.indexes, fmt.Sprintf("index.Fields(%q).Unique()", column.Name))
+		}
+	}
+
+	for _, idx := range table.Indexes {
+		b.index(idx)
+	}
+	for _, fk := range table.ForeignKeys {
+		b.report("foreign key %s references %s(%s), entlite has no foreign keys", fk.Column, fk.RefTable, fk.RefColumn)
+	}
+	if table.HasCheck {
+		b.report("CHECK constraints are not imported")
+	}
+
+	source, err := b.source()
+	if err != nil {
+		return Entity{}, err
+	}
+	return Entity{Name: b.name, Table: table.Name, Source: source, Unmapped: b.unmapped}, nil
+}
+
+var typeSize = regexp.MustCompile(`\(\s*(\d+)\s*(?:,\s*(\d+)\s*)?\)`)
+
+// fieldTypes are the column types entlite and the usual tools write, as
+// declared on sqlite and as information_schema names them on postgres and
+// mysql. sqlite takes any name, its affinity rules would read POINT as an
+// INTEGER, so an unknown name is reported rather than guessed.
+var fieldTypes = map[string]string{
+	"BOOLEAN": "Bool", "BOOL": "Bool",
+	"BIGINT": "Int64", "INT8": "Int64", "UNSIGNED BIG INT": "Int64", "INT UNSIGNED": "Int64", "INTEGER UNSIGNED": "Int64",
+	"INTEGER": "Int", "INT": "Int", "INT2": "Int", "INT4": "Int", "TINYINT": "Int", "SMALLINT": "Int", "MEDIUMINT": "Int",
+	"TINYINT UNSIGNED": "Int", "SMALLINT UNSIGNED": "Int", "MEDIUMINT UNSIGNED": "Int",
+	"DECIMAL": "Decimal", "NUMERIC": "Decimal",
+	"JSON": "JSON", "JSONB": "JSON",
+	"DATETIME": "Time", "TIMESTAMP": "Time", "TIMESTAMPTZ": "Time",
+	"TIMESTAMP WITH TIME ZONE": "Time", "TIMESTAMP WITHOUT TIME ZONE": "Time",
+	"DATE": "Date",
+	"TEXT": "String", "VARCHAR": "String", "CHAR": "String", "CHARACTER": "String", "NVARCHAR": "String", "NCHAR": "String",
+	"VARYING CHARACTER": "String", "CHARACTER VARYING": "String", "NATIVE CHARACTER": "String", "CLOB": "String", "UUID": "String",
+	"TINYTEXT": "String", "MEDIUMTEXT": "String", "LONGTEXT": "String",
+	"BLOB": "Byte", "BYTEA": "Byte", "TINYBLOB": "Byte", "MEDIUMBLOB": "Byte", "LONGBLOB": "Byte", "BINARY": "Byte", "VARBINARY": "Byte",
+	"REAL": "Float", "DOUBLE": "Float", "DOUBLE PRECISION": "Float", "FLOAT": "Float",
+	"TEXT[]": "Strings", "CHARACTER VARYING[]": "Strings", "VARCHAR[]": "Strings",
+	"INTEGER[]": "Ints", "INT[]": "Ints", "INT4[]": "Ints",
+}
+
+// columnFieldType is the field constructor of a column type, empty when none
+// fits
+func columnFieldType(declared string) string {
+	upper := strings.ToUpper(strings.Join(strings.Fields(declared), " "))
+	if upper == "TINYINT(1)" {
+		return "Bool"
+	}
+	return fieldTypes[strings.Join(strings.Fields(typeSize.ReplaceAllString(upper, "")), " ")]
+}
+
+func (b *entityBuilder) field(column migrate.Column) field {
+	f := field{constructor: columnFieldType(column.Type), name: column.Name}
+	// entlite keeps a bool in a sqlite INTEGER, its default gives it away
+	if f.constructor == "Int" && slices.Contains([]string{"true", "false"}, strings.ToLower(column.Default)) {
+		f.constructor = "Bool"
+	}
+	if f.constructor == "" {
+		b.report("column %s type %q has no field type, imported as field.String", column.Name, column.Type)
+		f.constructor = "String"
+	}
+
+	if f.constructor == "Decimal" {
+		size := typeSize.FindStringSubmatch(column.Type)
+		if size == nil {
+			b.report("column %s %s has no precision, imported as Precision(38, 10)", column.Name, column.Type)
+			f.modifiers = append(f.modifiers, "Precision(38, 10)")
+		} else {
+			scale := size[2]
+			if scale == "" {
+				scale = "0"
+			}
+			f.modifiers = append(f.modifiers, fmt.Sprintf("Precision(%s, %s)", size[1], scale))
+		}
+	}
+
+	if !column.NotNull && !strings.EqualFold(column.Name, "id") {
+		if f.constructor == "Bool" {
+			b.report("column %s is a nullable bool, field.Bool has no Optional()", column.Name)
+		} else {
+			f.modifiers = append(f.modifiers, "Optional()")
+		}
+	}
+
+	if column.Default != "" && !strings.EqualFold(column.Default, "NULL") {
+		if modifier, ok := b.defaultModifier(f.constructor, column.Default); ok {
+			f.modifiers = append(f.modifiers, modifier)
+		} else {
+			b.report("column %s default %s is not imported", column.Name, column.Default)
+		}
+	}
+	if column.Comment != "" {
+		f.modifiers = append(f.modifiers, fmt.Sprintf("Comment(%s)", strconv.Quote(column.Comment)))
+	}
+	return f
+}
+
+var (
+	intLiteral   = regexp.MustCompile(`^-?\d+$`)
+	floatLiteral = regexp.MustCompile(`^-?\d+(\.\d+)?$`)
+	nowDefault   = regexp.MustCompile(`(?i)^\(?\s*(CURRENT_TIMESTAMP(\(\))?|CURRENT_DATE|now\(\)|datetime\('now'\)|date\('now'\))\s*\)?$`)
+)
+
+// defaultModifier turns a column default into a Default or DefaultFunc call
+func (b *entityBuilder) defaultModifier(constructor, dflt string) (string, bool) {
+	dflt = strings.TrimSpace(dflt)
+	text, quoted := unquoteSQL(dflt)
+	switch constructor {
+	case "String", "JSON":
+		if quoted {
+			return fmt.Sprintf("Default(%s)", strconv.Quote(text)), true
+		}
+	case "Decimal":
+		if quoted && floatLiteral.MatchString(text) {
+			return fmt.Sprintf("Default(%q)", text), true
+		}
+		if floatLiteral.MatchString(dflt) {
+			return fmt.Sprintf("Default(%q)", dflt), true
+		}
+	case "Int", "Int64":
+		// postgres quotes a negative number, '-1'::integer
+		if intLiteral.MatchString(text) {
+			return fmt.Sprintf("Default(%s)", text), true
+		}
+	case "Float":
+		if floatLiteral.MatchString(text) {
+			return fmt.Sprintf("Default(%s)", text), true
+		}
+	case "Bool":
+		switch strings.ToLower(dflt) {
+		case "1", "true":
+			return "Default(true)", true
+		case "0", "false":
+			return "Default(false)", true
+		}
+	case "Time", "Date":
+		if nowDefault.MatchString(dflt) {
+			return "DefaultFunc(time.Now)", true
+		}
+	}
+	return "", false
+}
+
+// unquoteSQL reads a 'text' literal
+func unquoteSQL(s string) (string, bool) {
+	if len(s) < 2 || s[0] != '\'' || s[len(s)-1] != '\'' {
+		return s, false
+	}
+	return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), true
+}
+
+// indexColumn is an index column as the catalog writes it, e.g. "code DESC"
+// or "\"order\"", anything else is an expression
+var indexColumn = regexp.MustCompile("^[\"`]?([A-Za-z_][A-Za-z0-9_]*)[\"`]?( DESC)?$")
+
+func (b *entityBuilder) index(idx migrate.Index) {
+	var columns []string
+	var desc []bool
+	for _, column := range idx.Columns {
+		match := indexColumn.FindStringSubmatch(column)
+		if match == nil {
+			b.report("index %s covers an expression, only column indexes are imported", idx.Name)
+			return
+		}
+		columns = append(columns, match[1])
+		desc = append(desc, match[2] != "")
+	}
+
+	// index.Fields takes the leading ascending columns, the rest are chained
+	leading := 1
+	for leading < len(columns) && !desc[leading] {
+		leading++
+	}
+	if desc[0] {
+		b.report("index %s sorts %s DESC, the first column of an index is imported ascending", idx.Name, columns[0])
+	}
+	var expr strings.Builder
+	fmt.Fprintf(&expr, "index.Fields(%s)", quoteAll(columns[:leading]))
+	for i := leading; i < len(columns); i++ {
+		if desc[i] {
+			fmt.Fprintf(&expr, ".Desc(%q)", columns[i])
+		} else {
+			fmt.Fprintf(&expr, ".Asc(%q)", columns[i])
+		}
+	}
+	if idx.Unique {
+		expr.WriteString(".Unique()")
+	}
+	if idx.Where != "" {
+		fmt.Fprintf(&expr, ".Where(%s)", strconv.Quote(idx.Where))
+	}
+	// sqlite names a table UNIQUE itself, entlite's name is as good
+	defaultName := strings.Join(append([]string{"idx", strings.ToLower(b.name)}, columns...), "_")
+	if !idx.Constraint && idx.Name != defaultName {
+		fmt.Fprintf(&expr, ".Name(%q)", idx.Name)
+	}
+	b.indexes = append(b.indexes, expr.String())
+}
+
+func (b *entityBuilder) source() ([]byte, error) {
+	file := schemafile.File{
+		Name:    b.name,
+		Doc:     fmt.Sprintf("%s is imported from table %s", b.name, b.table.Name),
+		Indexes: b.indexes,
+	}
+	for _, f := range b.fields {
+		modifiers := f.modifiers
+		if f.unique {
+			modifiers = append([]string{"Unique()"}, modifiers...)
+		}
+		file.Fields = append(file.Fields, schemafile.Field{Constructor: f.constructor, Name: f.name, Modifiers: modifiers})
+	}
+	return file.Source()
+}
+
+// entityName is the Go name of a table, e.g. sensor_reading is SensorReading
+func entityName(table string) string {
+	var name strings.Builder
+	for part := range strings.FieldsFuncSeq(table, func(r rune) bool { return r == '_' || r == '-' || r == ' ' }) {
+		name.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return name.String()
+}
+
+func hasColumn(table migrate.Table, name string) bool {
+	for _, column := range table.Columns {
+		if strings.EqualFold(column.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func quoteAll(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = strconv.Quote(name)
+	}
+	return strings.Join(quoted, ", ")
+}
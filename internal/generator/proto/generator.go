@@ -4,15 +4,20 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"unicode"
 
+	"github.com/guntisdev/entlite/internal/protolock"
 	"github.com/guntisdev/entlite/internal/schema"
 	"github.com/guntisdev/entlite/internal/util"
 	"github.com/guntisdev/entlite/pkg/entlite/permissions"
 )
 
-func Generate(entities []schema.Entity, dir string) error {
-	protoContent := generateSchemaProto(entities)
+// Generate writes schema.proto. Request messages numbered by position take
+// their numbers from lock and record new ones in it, lock may be nil.
+func Generate(entities []schema.Entity, dir string, lock *protolock.Lock) error {
+	protoContent := generateSchemaProto(entities, lock)
 
 	fileName := "schema.proto"
 	filePath := filepath.Join(dir, fileName)
@@ -24,7 +29,7 @@ func Generate(entities []schema.Entity, dir string) error {
 	return nil
 }
 
-func generateSchemaProto(entities []schema.Entity) string {
+func generateSchemaProto(entities []schema.Entity, lock *protolock.Lock) string {
 	var content strings.Builder
 
 	content.WriteString("syntax = \"proto3\";\n\n")
@@ -38,6 +43,19 @@ func generateSchemaProto(entities []schema.Entity) string {
 	if needsEmptyImportForEntities(entities) {
 		imports = append(imports, "google/protobuf/empty.proto")
 	}
+	if needsStructImport(entities) {
+		imports = append(imports, "google/protobuf/struct.proto")
+	}
+	if needsFieldTypeImport(entities, schema.FieldTypeDuration) {
+		imports = append(imports, "google/protobuf/duration.proto")
+	}
+	// google/type is not bundled with protoc, entlite gen adds googleapis to buf.yaml, see util.EnsureBufDep
+	if needsFieldTypeImport(entities, schema.FieldTypeDate) {
+		imports = append(imports, "google/type/date.proto")
+	}
+	if needsFieldTypeImport(entities, schema.FieldTypeLatLng) {
+		imports = append(imports, "google/type/latlng.proto")
+	}
 	imports = append(imports, "buf/validate/validate.proto")
 	for _, imp := range imports {
 		content.WriteString(fmt.Sprintf("import \"%s\";\n", imp))
@@ -62,7 +80,7 @@ func generateSchemaProto(entities []schema.Entity) string {
 				continue
 			}
 			writeFieldComment(&content, field.Comment)
-			protoType := getProtoType(field.Type)
+			protoType := getProtoType(field)
 			var optional string
 			var required string
 			if field.Optional {
@@ -73,8 +91,10 @@ func generateSchemaProto(entities []schema.Entity) string {
 			} else {
 				required = fmt.Sprintf(" %s", requiredStr)
 			}
-			content.WriteString(fmt.Sprintf("  %s%s %s = %d%s;\n", optional, protoType, field.Name, field.ProtoField, required))
+			optional, required = listLabels(field, optional, required)
+			content.WriteString(fmt.Sprintf("  %s%s %s = %d%s;\n", optional, protoType, field.Name, field.ProtoField, withJSONName(field, required)))
 		}
+		writeReserved(&content, removedFields(entity, lock))
 
 		content.WriteString("}")
 		if i < len(entities)-1 {
@@ -92,16 +112,16 @@ func generateSchemaProto(entities []schema.Entity) string {
 			content.WriteString("\n\n")
 		}
 
-		content.WriteString(generateServiceProto(entity))
+		content.WriteString(generateServiceProto(entity, lock))
 	}
 
 	return content.String()
 }
 
-func generateServiceProto(entity schema.Entity) string {
+func generateServiceProto(entity schema.Entity, lock *protolock.Lock) string {
 	var content strings.Builder
 
-	content.WriteString(generateResponseMessages(entity))
+	content.WriteString(generateResponseMessages(entity, lock))
 	content.WriteString("\n\n")
 
 	serviceName := fmt.Sprintf("%sService", entity.Name)
@@ -117,9 +137,10 @@ func generateServiceProto(entity schema.Entity) string {
 	return content.String()
 }
 
-func generateResponseMessages(entity schema.Entity) string {
+func generateResponseMessages(entity schema.Entity, lock *protolock.Lock) string {
 	var content strings.Builder
 	var requiredStr = "[(buf.validate.field).required = true]"
+	removed := removedFields(entity, lock)
 
 	for i, query := range entity.Queries {
 		if i > 0 {
@@ -132,10 +153,12 @@ func generateResponseMessages(entity schema.Entity) string {
 		case schema.QueryCreate:
 			content.WriteString(fmt.Sprintf("message %sRequest {\n", messageName))
 			writeCreateFields(&content, entity)
+			writeReserved(&content, removed)
 			content.WriteString("}")
 		case schema.QueryCreateBulk:
 			content.WriteString(fmt.Sprintf("message %sItem {\n", messageName))
 			writeCreateFields(&content, entity)
+			writeReserved(&content, removed)
 			content.WriteString("}\n\n")
 
 			content.WriteString(fmt.Sprintf("message %sRequest {\n", messageName))
@@ -145,6 +168,21 @@ func generateResponseMessages(entity schema.Entity) string {
 			content.WriteString(fmt.Sprintf("message %sResponse {\n", messageName))
 			content.WriteString(fmt.Sprintf("  repeated %s %ss = 1;\n", entity.Name, strings.ToLower(entity.Name)))
 			content.WriteString("}")
+		case schema.QueryCreateStream:
+			content.WriteString(fmt.Sprintf("message %sItem {\n", messageName))
+			writeCreateFields(&content, entity)
+			writeReserved(&content, removed)
+			content.WriteString("}\n\n")
+
+			content.WriteString(fmt.Sprintf("message %sError {\n", messageName))
+			content.WriteString("  int32 index = 1;\n")
+			content.WriteString("  string message = 2;\n")
+			content.WriteString("}\n\n")
+
+			content.WriteString(fmt.Sprintf("message %sResponse {\n", messageName))
+			content.WriteString(fmt.Sprintf("  repeated %s ids = 1;\n", getProtoType(entity.GetIdField())))
+			content.WriteString(fmt.Sprintf("  repeated %sError errors = 2;\n", messageName))
+			content.WriteString("}")
 		case schema.QueryGetBy:
 			content.WriteString(fmt.Sprintf("message %sRequest {\n", messageName))
 
@@ -154,8 +192,8 @@ func generateResponseMessages(entity schema.Entity) string {
 					continue
 				}
 
-				protoType := getProtoType(field.Type)
-				content.WriteString(fmt.Sprintf("  %s %s = %d %s;\n", protoType, field.Name, field.ProtoField, requiredStr))
+				protoType := getProtoType(field)
+				content.WriteString(fmt.Sprintf("  %s %s = %d%s;\n", protoType, field.Name, field.ProtoField, withJSONName(field, " "+requiredStr)))
 			}
 			content.WriteString("}")
 		case schema.QueryUpdate:
@@ -168,7 +206,7 @@ func generateResponseMessages(entity schema.Entity) string {
 					}
 				}
 				writeFieldComment(&content, field.Comment)
-				protoType := getProtoType(field.Type)
+				protoType := getProtoType(field)
 				var optional string
 				var required string
 				// special case for psw etc - if not readable then no obligatory to update
@@ -178,8 +216,10 @@ func generateResponseMessages(entity schema.Entity) string {
 				} else {
 					required = fmt.Sprintf(" %s", requiredStr)
 				}
-				content.WriteString(fmt.Sprintf("  %s%s %s = %d%s;\n", optional, protoType, field.Name, field.ProtoField, required))
+				optional, required = listLabels(field, optional, required)
+				content.WriteString(fmt.Sprintf("  %s%s %s = %d%s;\n", optional, protoType, field.Name, field.ProtoField, withJSONName(field, required)))
 			}
+			writeReserved(&content, removed)
 			content.WriteString("}")
 		case schema.QueryDelete:
 			content.WriteString(fmt.Sprintf("message %sRequest {\n", messageName))
@@ -190,58 +230,76 @@ func generateResponseMessages(entity schema.Entity) string {
 			content.WriteString("}")
 		case schema.QueryListAll:
 			content.WriteString(fmt.Sprintf("message %sRequest {\n", messageName))
-			content.WriteString("}\n\n")
-
-			content.WriteString(fmt.Sprintf("message %sResponse {\n", messageName))
-			content.WriteString(fmt.Sprintf("  repeated %s %ss = 1;\n", entity.Name, strings.ToLower(entity.Name)))
 			content.WriteString("}")
+			writeListResponse(&content, entity, query, messageName)
 		case schema.QueryListBy:
 			content.WriteString(fmt.Sprintf("message %sRequest {\n", messageName))
 			// TODO proly change int type depending on ID field type
-			content.WriteString(fmt.Sprintf("  int32 limit = 1 %s;\n", requiredStr))
-			content.WriteString("  int32 offset = 2;\n")
-
-			protoFieldNum := 3
+			params := []listParam{{protoType: "int32", name: "limit", required: true}, {protoType: "int32", name: "offset"}}
 			for _, fieldName := range query.Fields {
 				field, found := entity.GetFieldByName(fieldName)
 				if !found {
 					continue
 				}
 
-				protoType := getProtoType(field.Type)
-				content.WriteString(fmt.Sprintf("  %s %s = %d %s;\n", protoType, field.Name, protoFieldNum, requiredStr))
-				protoFieldNum++
+				params = append(params, listParam{protoType: getProtoType(field), name: field.Name, required: true})
 			}
 			for _, filter := range query.Filters {
-				field, found := entity.GetFieldByName(filter.Field)
+				fieldName := filter.Field
+				if filter.Type == schema.QueryFilterAnyOf {
+					// the group shares one param typed after its (same typed) fields
+					fieldName = filter.Filters[0].Field
+				}
+				field, found := entity.GetFieldByName(fieldName)
 				if !found {
 					continue
 				}
 
-				protoType := getProtoType(field.Type)
+				protoType := getProtoType(field)
+				if filter.Type == schema.QueryFilterJSONPath {
+					protoType = "string" // the value at the path, compared as text
+				}
 
-				// Range filters expand to min_/max_ params, matching sqlc.
-				var names []string
+				// Near takes the point plus a radius in meters
+				if filter.Type == schema.QueryFilterNear {
+					params = append(params,
+						listParam{protoType: protoType, name: filter.Field, required: true},
+						listParam{protoType: "double", name: filter.Field + "_radius", required: true},
+					)
+					continue
+				}
+
+				// Range filters expand to min_/max_ params, matching sqlc, each bound optional on its own.
 				if filter.Type == schema.QueryFilterRange {
-					names = []string{"min_" + filter.Field, "max_" + filter.Field}
+					params = append(params,
+						listParam{protoType: protoType, name: "min_" + filter.Field, optional: filter.OptionalMin, required: !filter.OptionalMin},
+						listParam{protoType: protoType, name: "max_" + filter.Field, optional: filter.OptionalMax, required: !filter.OptionalMax},
+					)
 				} else {
-					names = []string{filter.Field}
+					params = append(params, listParam{protoType: protoType, name: filter.ParamName(), optional: filter.Optional, required: !filter.Optional})
 				}
+			}
 
-				for _, name := range names {
-					if filter.Optional {
-						content.WriteString(fmt.Sprintf("  optional %s %s = %d;\n", protoType, name, protoFieldNum))
-					} else {
-						content.WriteString(fmt.Sprintf("  %s %s = %d %s;\n", protoType, name, protoFieldNum, requiredStr))
-					}
-					protoFieldNum++
+			// params are numbered by position, the lock keeps a number when one is added before it
+			requestName := messageName + "Request"
+			names := make([]string, len(params))
+			for i, param := range params {
+				names[i] = param.name
+			}
+			numbers := lock.Assign(requestName, names)
+			for _, param := range params {
+				switch {
+				case param.optional:
+					content.WriteString(fmt.Sprintf("  optional %s %s = %d;\n", param.protoType, param.name, numbers[param.name]))
+				case param.required:
+					content.WriteString(fmt.Sprintf("  %s %s = %d %s;\n", param.protoType, param.name, numbers[param.name], requiredStr))
+				default:
+					content.WriteString(fmt.Sprintf("  %s %s = %d;\n", param.protoType, param.name, numbers[param.name]))
 				}
 			}
-			content.WriteString("}\n\n")
-
-			content.WriteString(fmt.Sprintf("message %sResponse {\n", messageName))
-			content.WriteString(fmt.Sprintf("  repeated %s %ss = 1;\n", entity.Name, strings.ToLower(entity.Name)))
+			writeReserved(&content, lock.Removed(requestName, names))
 			content.WriteString("}")
+			writeListResponse(&content, entity, query, messageName)
 		}
 
 	}
@@ -249,6 +307,51 @@ func generateResponseMessages(entity schema.Entity) string {
 	return content.String()
 }
 
+// listParam is one field of a ListBy request. offset is neither optional nor
+// required, proto reads a missing one as 0.
+type listParam struct {
+	protoType string
+	name      string
+	optional  bool
+	required  bool
+}
+
+// removedFields are the fields an entity had in entlite.lock but no longer has
+func removedFields(entity schema.Entity, lock *protolock.Lock) []protolock.Field {
+	var names []string
+	for _, field := range entity.Fields {
+		names = append(names, field.Name)
+	}
+	return lock.Removed(entity.Name, names)
+}
+
+// writeReserved keeps the numbers and names of removed fields from being
+// given to new fields, old clients would read them as the removed one
+func writeReserved(content *strings.Builder, removed []protolock.Field) {
+	if len(removed) == 0 {
+		return
+	}
+	var numbers, names []string
+	for _, field := range removed {
+		numbers = append(numbers, strconv.Itoa(field.Number))
+		names = append(names, strconv.Quote(field.Name))
+	}
+	content.WriteString(fmt.Sprintf("  reserved %s;\n", strings.Join(numbers, ", ")))
+	content.WriteString(fmt.Sprintf("  reserved %s;\n", strings.Join(names, ", ")))
+}
+
+// writeListResponse adds the response wrapper of a list query. Streamed queries
+// send the entity itself on every message, so they have none.
+func writeListResponse(content *strings.Builder, entity schema.Entity, query schema.Query, messageName string) {
+	if query.Stream {
+		return
+	}
+	content.WriteString("\n\n")
+	content.WriteString(fmt.Sprintf("message %sResponse {\n", messageName))
+	content.WriteString(fmt.Sprintf("  repeated %s %ss = 1;\n", entity.Name, strings.ToLower(entity.Name)))
+	content.WriteString("}")
+}
+
 func writeFieldComment(content *strings.Builder, comment string) {
 	if comment == "" {
 		return
@@ -266,7 +369,7 @@ func writeCreateFields(content *strings.Builder, entity schema.Entity) {
 			continue
 		}
 		writeFieldComment(content, field.Comment)
-		protoType := getProtoType(field.Type)
+		protoType := getProtoType(field)
 		var optional string
 		var required string
 		if field.Optional || field.DefaultValue != nil || field.DefaultFunc != nil {
@@ -276,14 +379,56 @@ func writeCreateFields(content *strings.Builder, entity schema.Entity) {
 		} else {
 			required = fmt.Sprintf(" %s", requiredStr)
 		}
-		content.WriteString(fmt.Sprintf("  %s%s %s = %d%s;\n", optional, protoType, field.Name, field.ProtoField, required))
+		optional, required = listLabels(field, optional, required)
+		content.WriteString(fmt.Sprintf("  %s%s %s = %d%s;\n", optional, protoType, field.Name, field.ProtoField, withJSONName(field, required)))
+	}
+}
+
+// withJSONName adds json_name to the options of a renamed field, so JSON
+// clients keep reading and writing it under its old name
+func withJSONName(field schema.Field, options string) string {
+	if field.RenamedFrom == "" {
+		return options
+	}
+	option := fmt.Sprintf("json_name = %q", jsonName(field.RenamedFrom))
+	if options == "" {
+		return fmt.Sprintf(" [%s]", option)
+	}
+	return fmt.Sprintf(" [%s, %s", option, strings.TrimPrefix(options, " ["))
+}
+
+// jsonName is the name protoc gives a field in JSON, lowerCamelCase of the
+// field name, e.g. display_name is displayName
+func jsonName(name string) string {
+	var b strings.Builder
+	upper := false
+	for _, r := range name {
+		if r == '_' {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// listLabels makes a Strings/Ints field repeated. It is never required,
+// an empty list is a value, and Optional() only lets the column be NULL.
+func listLabels(field schema.Field, optional, required string) (string, string) {
+	if field.IsList() {
+		return "repeated ", ""
 	}
+	return optional, required
 }
 
 func getIdFieldAsStr(fields []schema.Field) string {
 	for _, field := range fields {
 		if field.IsID() {
-			protoType := getProtoType(field.Type)
+			protoType := getProtoType(field)
 			return fmt.Sprintf("%s %s = %d", protoType, field.Name, field.ProtoField)
 		}
 	}
@@ -300,6 +445,8 @@ func generateRequests(entity schema.Entity, query schema.Query) string {
 		return fmt.Sprintf("  rpc %s(%sRequest) returns (%s);\n", rpcName, messageName, entity.Name)
 	case schema.QueryCreateBulk:
 		return fmt.Sprintf("  rpc %s(%sRequest) returns (%sResponse);\n", rpcName, messageName, messageName)
+	case schema.QueryCreateStream:
+		return fmt.Sprintf("  rpc %s(stream %sItem) returns (%sResponse);\n", rpcName, messageName, messageName)
 	case schema.QueryGetBy:
 		return fmt.Sprintf("  rpc %s(%sRequest) returns (%s);\n", rpcName, messageName, entity.Name)
 	case schema.QueryUpdate:
@@ -307,6 +454,9 @@ func generateRequests(entity schema.Entity, query schema.Query) string {
 	case schema.QueryDelete, schema.QueryDeleteAll:
 		return fmt.Sprintf("  rpc %s(%sRequest) returns (google.protobuf.Empty);\n", rpcName, messageName)
 	case schema.QueryListBy, schema.QueryListAll:
+		if query.Stream {
+			return fmt.Sprintf("  rpc %s(%sRequest) returns (stream %s);\n", rpcName, messageName, entity.Name)
+		}
 		return fmt.Sprintf("  rpc %s(%sRequest) returns (%sResponse);\n", rpcName, messageName, messageName)
 	default:
 		return ""
@@ -329,6 +479,30 @@ func needsCommonImports(entities []schema.Entity) bool {
 	return false
 }
 
+func needsFieldTypeImport(entities []schema.Entity, fieldType schema.FieldType) bool {
+	for _, entity := range entities {
+		for _, field := range entity.Fields {
+			if field.Type == fieldType {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func needsStructImport(entities []schema.Entity) bool {
+	for _, entity := range entities {
+		for _, field := range entity.Fields {
+			if field.ProtoJSON != schema.ProtoJSONText && (field.Permissions&(permissions.ApiRead|permissions.ApiWrite)) != 0 {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
 func needsEmptyImportForEntities(entities []schema.Entity) bool {
 	for _, entity := range entities {
 		for _, query := range entity.Queries {
@@ -341,8 +515,16 @@ func needsEmptyImportForEntities(entities []schema.Entity) bool {
 	return false
 }
 
-func getProtoType(fieldType schema.FieldType) string {
-	switch fieldType {
+func getProtoType(field schema.Field) string {
+	// ProtoStruct/ProtoValue json, the wrapper converts it from the stored text or Go type
+	switch field.ProtoJSON {
+	case schema.ProtoJSONStruct:
+		return "google.protobuf.Struct"
+	case schema.ProtoJSONValue:
+		return "google.protobuf.Value"
+	}
+
+	switch field.Type {
 	case schema.FieldTypeString:
 		return "string"
 	case schema.FieldTypeInt:
@@ -359,7 +541,27 @@ func getProtoType(fieldType schema.FieldType) string {
 		return "bytes"
 	case schema.FieldTypeJSON:
 		return "string" // raw json text, kept as string so it passes through unchanged
+	case schema.FieldTypeStrings:
+		return "string" // repeated, see listLabels
+	case schema.FieldTypeInts:
+		return "int32" // repeated, see listLabels
+	case schema.FieldTypeDecimal:
+		return "string" // exact decimal text, e.g. "12.50", a double would round it
+	case schema.FieldTypeDate:
+		return "google.type.Date"
+	case schema.FieldTypeDuration:
+		return "google.protobuf.Duration"
+	case schema.FieldTypeLatLng:
+		return "google.type.LatLng"
 	default:
 		return "string"
 	}
 }
+
+// FieldType is the type of a field as schema.proto declares it, repeated included
+func FieldType(field schema.Field) string {
+	if field.IsList() {
+		return "repeated " + getProtoType(field)
+	}
+	return getProtoType(field)
+}
@@ -90,7 +90,8 @@ func (i *ValidateInterceptor) WrapStreamingClient(next connect.StreamingClientFu
 	return next
 }
 
-// WrapStreamingHandler implements connect.Interceptor.
+// WrapStreamingHandler implements connect.Interceptor. Server-streaming rpcs
+// read their single request through Receive as well, so it is validated here.
 func (i *ValidateInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
 	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
 		return next(ctx, &validatingHandlerConn{StreamingHandlerConn: conn})
@@ -115,9 +116,10 @@ func generateValidateMethod(entity schema.Entity, queryType schema.QueryType) st
 	messageName := util.GenEntityQueryName(entity, queryType)
 	content.WriteString(fmt.Sprintf("func (r *%sRequest) Validate() error {\n", messageName))
 
-	// json text is checked before the request reaches the handler
+	// json text is checked before the request reaches the handler, json sent
+	// as google.protobuf.Struct/Value is valid by construction
 	for _, field := range entity.Fields {
-		if field.Type != schema.FieldTypeJSON || !inRequest(field, queryType) {
+		if field.Type != schema.FieldTypeJSON || field.ProtoJSON != schema.ProtoJSONText || !inRequest(field, queryType) {
 			continue
 		}
 
@@ -181,7 +183,7 @@ func hasQueryType(entity schema.Entity, queryType schema.QueryType) bool {
 
 func hasJSONField(entity schema.Entity) bool {
 	for _, field := range entity.Fields {
-		if field.Type == schema.FieldTypeJSON {
+		if field.Type == schema.FieldTypeJSON && field.ProtoJSON == schema.ProtoJSONText {
 			return true
 		}
 	}
@@ -3,6 +3,7 @@ package sqlc
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/guntisdev/entlite/internal/schema"
 )
@@ -102,8 +103,16 @@ func (g *Generator) getPostgresSQLType(fieldType schema.FieldType) string {
 	case schema.FieldTypeByte:
 		return "BYTEA"
 	case schema.FieldTypeJSON:
-		// TODO consider JSONB once json indexing/querying is supported, it needs sqlc overrides to stay a Go string
-		return "TEXT"
+		// sqlc.yaml overrides keep it a json.RawMessage, see util.EnsureJSONOverrides
+		return "JSONB"
+	case schema.FieldTypeStrings:
+		return "TEXT[]"
+	case schema.FieldTypeInts:
+		return "INT[]"
+	case schema.FieldTypeDate:
+		return "DATE"
+	case schema.FieldTypeDuration:
+		return "BIGINT" // nanoseconds, like time.Duration
 	default:
 		return "TEXT"
 	}
@@ -127,6 +136,12 @@ func (g *Generator) getSQLiteType(fieldType schema.FieldType) string {
 		return "BLOB"
 	case schema.FieldTypeJSON:
 		return "TEXT" // sqlite has no json type, json1 functions work on TEXT
+	case schema.FieldTypeStrings, schema.FieldTypeInts:
+		return "TEXT" // json array, the sqlcWrap layer encodes it
+	case schema.FieldTypeDate:
+		return "DATE" // the driver reads a DATE column back as a time.Time
+	case schema.FieldTypeDuration:
+		return "INTEGER" // nanoseconds, like time.Duration
 	default:
 		return "TEXT"
 	}
@@ -151,13 +166,47 @@ func (g *Generator) getMySQLType(fieldType schema.FieldType) string {
 	case schema.FieldTypeByte:
 		return "BLOB"
 	case schema.FieldTypeJSON:
-		// TODO consider native JSON once json indexing/querying is supported, it needs sqlc overrides to stay a Go string
+		// sqlc.yaml overrides keep it a json.RawMessage, see util.EnsureJSONOverrides
+		return "JSON"
+	case schema.FieldTypeStrings, schema.FieldTypeInts:
+		// a json array in TEXT, so sqlc keeps it a string the sqlcWrap layer encodes
 		return "TEXT"
+	case schema.FieldTypeDate:
+		return "DATE"
+	case schema.FieldTypeDuration:
+		return "BIGINT" // nanoseconds, like time.Duration
 	default:
 		return "TEXT"
 	}
 }
 
+// decimalSQLType sizes a decimal column, sqlite has no exact number type and
+// keeps the text the sqlcWrap layer writes
+func (g *Generator) decimalSQLType(field schema.Field) string {
+	switch g.sqlDialect {
+	case schema.PostgreSQL:
+		return fmt.Sprintf("NUMERIC(%d,%d)", field.Precision, field.Scale)
+	case schema.MySQL:
+		return fmt.Sprintf("DECIMAL(%d,%d)", field.Precision, field.Scale)
+	case schema.SQLite:
+		return "TEXT"
+	}
+
+	panic("unreachable: invalid SQL dialect")
+}
+
+// decimalCheck keeps sqlite decimal text to what NUMERIC(p,s) would hold:
+// digits only, p-s digits before the point and at most s after it
+func (g *Generator) decimalCheck(field schema.Field) string {
+	if g.sqlDialect != schema.SQLite || field.Type != schema.FieldTypeDecimal {
+		return ""
+	}
+
+	column := field.Name
+	return fmt.Sprintf("CHECK (%s NOT GLOB '*[^0-9.-]*' AND abs(CAST(%s AS REAL)) < 1e%d AND (instr(%s, '.') = 0 OR length(%s) - instr(%s, '.') <= %d))",
+		column, column, field.Precision-field.Scale, column, column, column, field.Scale)
+}
+
 func (g *Generator) formatDefaultValue(value any, fieldType schema.FieldType) string {
 	switch fieldType {
 	case schema.FieldTypeBool:
@@ -167,10 +216,15 @@ func (g *Generator) formatDefaultValue(value any, fieldType schema.FieldType) st
 			}
 			return "false"
 		}
-	case schema.FieldTypeString:
+	case schema.FieldTypeString, schema.FieldTypeDecimal:
 		// String literals must be single-quoted; escape embedded quotes.
 		s := fmt.Sprintf("%v", value)
 		return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+	case schema.FieldTypeDuration:
+		// %v would print a time.Duration as 1m30s
+		if d, ok := value.(time.Duration); ok {
+			return fmt.Sprintf("%d", int64(d))
+		}
 	}
 
 	return fmt.Sprintf("%v", value)
@@ -187,6 +241,110 @@ func (g *Generator) supportsReturning() bool {
 	panic("unreachable: invalid SQL dialect")
 }
 
+func (g *Generator) supportsPartialIndex() bool {
+	switch g.sqlDialect {
+	case schema.MySQL:
+		return false
+	case schema.PostgreSQL, schema.SQLite:
+		return true
+	}
+
+	panic("unreachable: invalid SQL dialect")
+}
+
+// lowerColumn is what a Lower() index covers for a column. mysql can't index
+// expressions inline, so it indexes a generated <column>_lower column instead.
+func (g *Generator) lowerColumn(column string) string {
+	if g.sqlDialect == schema.MySQL {
+		return column + "_lower"
+	}
+	return "LOWER(" + column + ")"
+}
+
+// jsonPathExpr extracts the value at path from a json column as text, so it
+// compares the same on every dialect. Indexes and filters must render it alike
+// for the planner to match them.
+func (g *Generator) jsonPathExpr(column string, path []string) string {
+	switch g.sqlDialect {
+	case schema.PostgreSQL:
+		return fmt.Sprintf("(%s #>> '{%s}')", column, strings.Join(path, ","))
+	case schema.MySQL:
+		return fmt.Sprintf("JSON_UNQUOTE(JSON_EXTRACT(%s, '$.%s'))", column, strings.Join(path, "."))
+	case schema.SQLite:
+		// json_extract returns numbers as numbers, cast so they compare with a text param
+		return fmt.Sprintf("CAST(json_extract(%s, '$.%s') AS TEXT)", column, strings.Join(path, "."))
+	}
+
+	panic("unreachable: invalid SQL dialect")
+}
+
+// jsonPathColumn is what a JSONPath index covers: the expression itself, or
+// on mysql a generated column named after the field and path, e.g. settings_theme.
+func (g *Generator) jsonPathColumn(column string, path []string) string {
+	if g.sqlDialect == schema.MySQL {
+		return schema.JSONPathName(column, path)
+	}
+	return g.jsonPathExpr(column, path)
+}
+
+// jsonPathCondition renders a filter.JSONPath, the param is always text. An
+// optional filter matches every row when its param is null.
+func (g *Generator) jsonPathCondition(filter schema.QueryFilter) string {
+	arg := g.namedArg(filter.ParamName())
+	if filter.Optional {
+		arg = fmt.Sprintf("sqlc.narg('%s')", filter.ParamName())
+	}
+	if g.sqlDialect == schema.PostgreSQL {
+		// without the cast sqlc types the param after the JSONB column, as json
+		arg += "::text"
+	}
+	condition := fmt.Sprintf("%s = %s", g.jsonPathExpr(filter.Field, filter.JSONPath), arg)
+	if filter.Optional {
+		return fmt.Sprintf("(%s OR %s IS NULL)", condition, arg)
+	}
+	return condition
+}
+
+// containsCondition renders a filter.Contains, the param is one element of
+// the list. Each dialect casts it so sqlc binds the element type. An optional
+// filter matches every row when its param is null.
+func (g *Generator) containsCondition(filter schema.QueryFilter, field schema.Field) string {
+	arg := g.namedArg(filter.Field)
+	if filter.Optional {
+		arg = fmt.Sprintf("sqlc.narg('%s')", filter.Field)
+	}
+	condition := g.containsExpr(filter.Field, arg, field.Type == schema.FieldTypeInts)
+	if filter.Optional {
+		return fmt.Sprintf("(%s OR %s IS NULL)", condition, arg)
+	}
+	return condition
+}
+
+// containsExpr is true when the list column holds arg
+func (g *Generator) containsExpr(column, arg string, isInts bool) string {
+	switch g.sqlDialect {
+	case schema.PostgreSQL:
+		if isInts {
+			return fmt.Sprintf("%s::integer = ANY(%s)", arg, column)
+		}
+		return fmt.Sprintf("%s::text = ANY(%s)", arg, column)
+	case schema.MySQL:
+		if isInts {
+			return fmt.Sprintf("JSON_CONTAINS(%s, JSON_ARRAY(CAST(%s AS SIGNED)))", column, arg)
+		}
+		return fmt.Sprintf("JSON_CONTAINS(%s, JSON_QUOTE(%s))", column, arg)
+	case schema.SQLite:
+		// sqlc drops a param that comes before an IN (SELECT ...), so it goes in an EXISTS
+		castType := "TEXT"
+		if isInts {
+			castType = "INTEGER"
+		}
+		return fmt.Sprintf("EXISTS (SELECT 1 FROM json_each(%s) WHERE json_each.value = CAST(%s AS %s))", column, arg, castType)
+	}
+
+	panic("unreachable: invalid SQL dialect")
+}
+
 func (g *Generator) namedArg(name string) string {
 	switch g.sqlDialect {
 	case schema.MySQL:
@@ -200,6 +358,115 @@ func (g *Generator) namedArg(name string) string {
 	panic("unreachable: invalid SQL dialect")
 }
 
+// nearCondition renders a filter.Near: a bounding box the sqlcWrap layer works
+// out from the point and radius, which an index on the columns can serve, then
+// the haversine distance in meters on an earth of radius 6371 km. The params
+// are cast so sqlc types them as floats on every dialect, and the cosine of the
+// point's latitude comes in precomputed.
+func (g *Generator) nearCondition(field schema.Field) string {
+	latColumn, lngColumn := field.LatLngColumns()
+	floatType := g.getSQLType(schema.FieldTypeFloat)
+	param := func(name string) string {
+		return fmt.Sprintf("CAST(%s AS %s)", g.namedArg(name), floatType)
+	}
+	// sqlite's two-argument min() is its LEAST
+	least := "LEAST"
+	if g.sqlDialect == schema.SQLite {
+		least = "min"
+	}
+
+	box := fmt.Sprintf("%s >= %s AND %s <= %s AND %s >= %s AND %s <= %s",
+		latColumn, param("min_"+latColumn), latColumn, param("max_"+latColumn),
+		lngColumn, param("min_"+lngColumn), lngColumn, param("max_"+lngColumn))
+	haversine := fmt.Sprintf("power(sin(radians(%s - %s) / 2), 2) + %s * cos(radians(%s)) * power(sin(radians(%s - %s) / 2), 2)",
+		latColumn, param(latColumn), param(field.Name+"_cos_lat"), latColumn, lngColumn, param(lngColumn))
+	distance := fmt.Sprintf("12742000 * asin(sqrt(%s(1, %s)))", least, haversine)
+
+	return fmt.Sprintf("%s AND %s <= %s", box, distance, param(field.Name+"_radius"))
+}
+
+// filterCondition renders an Eq or Search filter compared against arg
+func (g *Generator) filterCondition(filter schema.QueryFilter, arg string) string {
+	if filter.Type == schema.QueryFilterSearch {
+		return g.searchCondition(filter.Field, arg, filter)
+	}
+	return fmt.Sprintf("%s = %s", filter.Field, arg)
+}
+
+// rangeCondition renders a filter.Range. A closed range stays a BETWEEN, open
+// or exclusive bounds become one comparison per bound.
+func (g *Generator) rangeCondition(filter schema.QueryFilter) string {
+	minParam, maxParam := "min_"+filter.Field, "max_"+filter.Field
+	if !filter.IsOpenRange() {
+		return fmt.Sprintf("%s BETWEEN %s AND %s", filter.Field, g.namedArg(minParam), g.namedArg(maxParam))
+	}
+
+	minOperator, maxOperator := ">=", "<="
+	if filter.ExclusiveMin {
+		minOperator = ">"
+	}
+	if filter.ExclusiveMax {
+		maxOperator = "<"
+	}
+
+	return g.boundCondition(filter.Field, minOperator, minParam, filter.OptionalMin) +
+		" AND " + g.boundCondition(filter.Field, maxOperator, maxParam, filter.OptionalMax)
+}
+
+// boundCondition compares column against one range bound. An optional bound
+// matches every row when its param is null.
+func (g *Generator) boundCondition(column, operator, param string, optional bool) string {
+	if !optional {
+		return fmt.Sprintf("%s %s %s", column, operator, g.namedArg(param))
+	}
+
+	// the comparison has to come first, sqlite types the param from it
+	narg := fmt.Sprintf("sqlc.narg('%s')", param)
+	return fmt.Sprintf("(%s %s %s OR %s IS NULL)", column, operator, narg, narg)
+}
+
+// searchCondition renders a filter.Search. Contains/Prefix values reach the
+// database already escaped by the sqlcWrap layer, so they carry an ESCAPE clause.
+func (g *Generator) searchCondition(column, arg string, filter schema.QueryFilter) string {
+	if filter.Match == schema.SearchPattern && !filter.CaseInsensitive {
+		return fmt.Sprintf("%s LIKE %s", column, arg)
+	}
+
+	operator := "LIKE"
+	if filter.CaseInsensitive {
+		switch g.sqlDialect {
+		case schema.PostgreSQL:
+			operator = "ILIKE"
+		case schema.MySQL, schema.SQLite:
+			// mysql LIKE follows the column collation and sqlite LIKE ignores
+			// COLLATE NOCASE (PRAGMA case_sensitive_like decides), so lower both sides
+			column = fmt.Sprintf("LOWER(%s)", column)
+			arg = fmt.Sprintf("LOWER(%s)", arg)
+		}
+	}
+
+	condition := fmt.Sprintf("%s %s %s", column, operator, arg)
+	// postgres already escapes with a backslash, and sqlc types the param as
+	// bytea once an ESCAPE clause follows it
+	if filter.Match != schema.SearchPattern && g.sqlDialect != schema.PostgreSQL {
+		condition += " ESCAPE " + g.likeEscapeChar()
+	}
+	// sqlite only accepts ESCAPE next to OR when the LIKE is parenthesized
+	return "(" + condition + ")"
+}
+
+func (g *Generator) likeEscapeChar() string {
+	switch g.sqlDialect {
+	case schema.MySQL:
+		// backslash is an escape character inside mysql string literals too
+		return `'\\'`
+	case schema.SQLite:
+		return `'\'`
+	}
+
+	panic("unreachable: invalid SQL dialect")
+}
+
 func (g *Generator) getParameterPlaceholder(index int) string {
 	switch g.sqlDialect {
 	case schema.PostgreSQL:
@@ -0,0 +1,48 @@
+package sqlc
+
+import (
+	"strings"
+
+	"github.com/guntisdev/entlite/internal/schema"
+	"github.com/guntisdev/entlite/pkg/entlite/migrate"
+)
+
+// keyClauses are what the id column's Type adds to the column type
+var keyClauses = strings.NewReplacer(" PRIMARY KEY", "", " AUTOINCREMENT", "", " AUTO_INCREMENT", "")
+
+// SchemaTables are the tables schema.sql creates, in the shape
+// migrate.CheckSchema compares to the database catalog
+func (g *Generator) SchemaTables(entities []schema.Entity) ([]migrate.Table, error) {
+	tables, err := g.tables(entities)
+	if err != nil {
+		return nil, err
+	}
+
+	var schemaTables []migrate.Table
+	for _, table := range tables {
+		schemaTable := migrate.Table{Name: table.Name, PrimaryKey: table.PrimaryKey}
+		for _, column := range table.Columns {
+			columnType := column.Type
+			if isIDColumn(column) {
+				columnType = keyClauses.Replace(columnType)
+				schemaTable.PrimaryKey = []string{column.Name}
+			}
+			schemaTable.Columns = append(schemaTable.Columns, migrate.Column{
+				Name:    column.Name,
+				Type:    columnType,
+				NotNull: column.NotNull,
+				Unique:  column.Unique,
+			})
+		}
+		for _, idx := range table.Indexes {
+			schemaTable.Indexes = append(schemaTable.Indexes, migrate.Index{
+				Name:    idx.Name,
+				Unique:  idx.Unique,
+				Columns: idx.Columns,
+				Partial: idx.Where != "",
+			})
+		}
+		schemaTables = append(schemaTables, schemaTable)
+	}
+	return schemaTables, nil
+}
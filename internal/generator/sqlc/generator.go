@@ -40,7 +40,11 @@ func (g *Generator) generateSchema(entities []schema.Entity, dir string) error {
 	content.WriteString("-- This file contains table definitions for all entities\n\n")
 
 	for _, entity := range entities {
-		content.WriteString(g.generateTableSQL(entity))
+		tableSQL, err := g.generateTableSQL(entity)
+		if err != nil {
+			return err
+		}
+		content.WriteString(tableSQL)
 		content.WriteString("\n")
 	}
 
@@ -61,14 +65,18 @@ func writeColumnComment(content *strings.Builder, comment string) {
 	}
 }
 
-func (g *Generator) generateTableSQL(entity schema.Entity) string {
-	var content strings.Builder
-
-	tableName := strings.ToLower(entity.Name)
-	content.WriteString(fmt.Sprintf("-- %s table\n", tableName))
-	content.WriteString(fmt.Sprintf("CREATE TABLE %s(\n", g.quote(tableName)))
+func (g *Generator) generateTableSQL(entity schema.Entity) (string, error) {
+	table, err := g.table(entity)
+	if err != nil {
+		return "", err
+	}
+	return g.tableSQL(table), nil
+}
 
-	idField := entity.GetIdField()
+// table works out the columns, compound primary key and indexes entlite
+// creates for an entity, schema.sql and migrations both render from it.
+func (g *Generator) table(entity schema.Entity) (Table, error) {
+	table := Table{Name: strings.ToLower(entity.Name)}
 
 	for _, field := range entity.Fields {
 		if field.IsVirtual() {
@@ -76,102 +84,226 @@ func (g *Generator) generateTableSQL(entity schema.Entity) string {
 		}
 
 		if field.IsID() {
-			writeColumnComment(&content, idField.Comment)
-			content.WriteString(g.getIdFieldSQL(idField))
+			table.Columns = append(table.Columns, Column{
+				Name:    field.Name,
+				Type:    g.getIdFieldType(field.Type, field.Primary),
+				Comment: field.Comment,
+			})
 			continue
 		}
 
-		content.WriteString(",\n")
-		writeColumnComment(&content, field.Comment)
-		sqlType := g.getSQLType(field.Type)
+		if field.Type == schema.FieldTypeLatLng {
+			table.Columns = append(table.Columns, g.latLngColumns(field)...)
+			continue
+		}
 
-		content.WriteString(fmt.Sprintf("  %s %s", field.Name, sqlType))
+		column := Column{
+			Name:    field.Name,
+			Type:    g.getSQLType(field.Type),
+			Unique:  field.Unique,
+			NotNull: !field.Optional,
+			Check:   g.decimalCheck(field),
+			Comment: field.Comment,
 
-		if field.Unique {
-			content.WriteString(" UNIQUE")
+			Backfill:    field.Backfill,
+			BackfillSQL: field.BackfillSQL,
+		}
+		if field.Type == schema.FieldTypeDecimal {
+			column.Type = g.decimalSQLType(field)
 		}
-
 		// json defaults are applied in Go, mysql does not allow them on a JSON column
 		if field.DefaultValue != nil && field.Type != schema.FieldTypeJSON {
-			defaultVal := g.formatDefaultValue(field.DefaultValue, field.Type)
-			content.WriteString(fmt.Sprintf(" DEFAULT %s", defaultVal))
+			column.Default = g.formatDefaultValue(field.DefaultValue, field.Type)
 		}
+		// TODO write logic for DefaultFunc etc
+		table.Columns = append(table.Columns, column)
+	}
 
-		if !field.Optional {
-			content.WriteString(" NOT NULL")
+	// mysql can't index expressions inline, they go through generated columns
+	if g.sqlDialect == schema.MySQL {
+		columns, err := g.mysqlGeneratedColumns(entity)
+		if err != nil {
+			return Table{}, err
+		}
+		for _, column := range columns {
+			table.Columns = append(table.Columns, Column{Name: column.name, Type: column.sqlType, Generated: column.expr})
 		}
-
-		// TODO write logic for DefaultFunc etc
 	}
 
-	// Compound primary key declared via index.Primary(...). When present the
-	// parser clears the id field's primary flag, so this becomes the table's only PRIMARY KEY.
 	for _, idx := range entity.Indexes {
-		if idx.Type != schema.IndexPrimary {
-			continue
+		switch idx.Type {
+		case schema.IndexPrimary:
+			// Compound primary key declared via index.Primary(...). When present the
+			// parser clears the id field's primary flag, so this becomes the table's only PRIMARY KEY.
+			table.PrimaryKey = g.indexColumns(entity, idx)
+		case schema.IndexRegular:
+			name := idx.Name
+			if name == "" {
+				name = g.defaultIndexName(table.Name, idx)
+			}
+			if idx.Where != "" && !g.supportsPartialIndex() {
+				return Table{}, fmt.Errorf("entity %q index %q: %s has no partial indexes, drop Where(%q)", entity.Name, name, g.sqlDialect, idx.Where)
+			}
+			table.Indexes = append(table.Indexes, TableIndex{
+				Name:    name,
+				Unique:  idx.Unique,
+				Columns: g.indexColumns(entity, idx),
+				Where:   idx.Where,
+			})
 		}
-		content.WriteString(",\n")
-		content.WriteString(fmt.Sprintf("  PRIMARY KEY (%s)", strings.Join(g.indexColumns(idx), ", ")))
 	}
 
-	content.WriteString("\n);\n")
+	return table, nil
+}
 
-	content.WriteString(g.generateIndexSQL(entity))
+// latLngColumns are the two float columns a latlng is stored in, both null or
+// both set. The field comment goes on the first one.
+func (g *Generator) latLngColumns(field schema.Field) []Column {
+	latColumn, lngColumn := field.LatLngColumns()
+	floatType := g.getSQLType(schema.FieldTypeFloat)
+	return []Column{
+		{Name: latColumn, Type: floatType, NotNull: !field.Optional, Comment: field.Comment},
+		{Name: lngColumn, Type: floatType, NotNull: !field.Optional},
+	}
+}
 
-	return content.String()
+// fieldColumns lists the columns a field is stored in, one for every field
+// except a latlng
+func fieldColumns(field schema.Field) []string {
+	if field.Type == schema.FieldTypeLatLng {
+		latColumn, lngColumn := field.LatLngColumns()
+		return []string{latColumn, lngColumn}
+	}
+	return []string{field.Name}
 }
 
-// generateIndexSQL emits CREATE INDEX statements for secondary indexes declared
-// via index.Fields(...). Primary keys are handled inline in the CREATE TABLE.
-func (g *Generator) generateIndexSQL(entity schema.Entity) string {
+// tableSQL renders the CREATE TABLE of a table followed by its indexes
+func (g *Generator) tableSQL(table Table) string {
 	var content strings.Builder
 
-	tableName := strings.ToLower(entity.Name)
+	content.WriteString(fmt.Sprintf("-- %s table\n", table.Name))
+	content.WriteString(fmt.Sprintf("CREATE TABLE %s(\n", g.quote(table.Name)))
+	g.writeTableBody(&content, table)
+	content.WriteString("\n);\n")
 
-	for _, idx := range entity.Indexes {
-		if idx.Type != schema.IndexRegular {
-			continue
-		}
+	for _, idx := range table.Indexes {
+		content.WriteString(g.createIndexSQL(table.Name, idx))
+	}
 
-		name := idx.Name
-		if name == "" {
-			name = g.defaultIndexName(tableName, idx)
-		}
+	return content.String()
+}
 
-		unique := ""
-		if idx.Unique {
-			unique = "UNIQUE "
+// writeTableBody writes the column and primary key lines of a CREATE TABLE
+func (g *Generator) writeTableBody(content *strings.Builder, table Table) {
+	for i, column := range table.Columns {
+		if i > 0 {
+			content.WriteString(",\n")
 		}
+		writeColumnComment(content, column.Comment)
+		content.WriteString("  " + column.Definition())
+	}
 
-		content.WriteString(fmt.Sprintf("CREATE %sINDEX %s ON %s (%s);\n",
-			unique,
-			g.quote(name),
-			g.quote(tableName),
-			strings.Join(g.indexColumns(idx), ", "),
-		))
+	if len(table.PrimaryKey) > 0 {
+		content.WriteString(",\n")
+		content.WriteString(fmt.Sprintf("  PRIMARY KEY (%s)", strings.Join(table.PrimaryKey, ", ")))
 	}
+}
 
-	return content.String()
+// createIndexSQL emits the CREATE INDEX of a secondary index declared via
+// index.Fields(...). Primary keys are handled inline in the CREATE TABLE.
+func (g *Generator) createIndexSQL(tableName string, idx TableIndex) string {
+	unique := ""
+	if idx.Unique {
+		unique = "UNIQUE "
+	}
+
+	where := ""
+	if idx.Where != "" {
+		where = " WHERE " + idx.Where
+	}
+
+	return fmt.Sprintf("CREATE %sINDEX %s ON %s (%s)%s;\n",
+		unique,
+		g.quote(idx.Name),
+		g.quote(tableName),
+		strings.Join(idx.Columns, ", "),
+		where,
+	)
 }
 
 // indexColumns renders each indexed column, appending DESC for descending
-// columns (ASC is the SQL default and left implicit).
-func (g *Generator) indexColumns(idx schema.Index) []string {
-	cols := make([]string, len(idx.Columns))
-	for i, c := range idx.Columns {
-		if c.Desc {
-			cols[i] = c.Name + " DESC"
-		} else {
-			cols[i] = c.Name
+// columns (ASC is the SQL default and left implicit). A latlng field covers
+// both of its columns.
+func (g *Generator) indexColumns(entity schema.Entity, idx schema.Index) []string {
+	cols := []string{}
+	for _, c := range idx.Columns {
+		names := []string{c.Name}
+		if idx.Lower {
+			names = []string{g.lowerColumn(c.Name)}
+		}
+		if idx.JSONPath != nil {
+			names = []string{g.jsonPathColumn(c.Name, idx.JSONPath)}
+		}
+		if field, ok := entity.GetFieldByName(c.Name); ok && field.Type == schema.FieldTypeLatLng {
+			latColumn, lngColumn := field.LatLngColumns()
+			names = []string{latColumn, lngColumn}
+		}
+		for _, name := range names {
+			if c.Desc {
+				name += " DESC"
+			}
+			cols = append(cols, name)
 		}
 	}
 	return cols
 }
 
+// generatedColumn is a mysql VIRTUAL column standing in for an indexed expression
+type generatedColumn struct {
+	name    string
+	sqlType string
+	expr    string
+}
+
+// mysqlGeneratedColumns lists the generated columns Lower() and JSONPath
+// indexes need, each once and in index declaration order.
+func (g *Generator) mysqlGeneratedColumns(entity schema.Entity) ([]generatedColumn, error) {
+	var columns []generatedColumn
+	seen := make(map[string]bool)
+	for _, idx := range entity.Indexes {
+		for _, c := range idx.Columns {
+			var column generatedColumn
+			switch {
+			case idx.Lower:
+				field, _ := entity.GetFieldByName(c.Name)
+				column = generatedColumn{g.lowerColumn(c.Name), g.getSQLType(field.Type), fmt.Sprintf("LOWER(%s)", c.Name)}
+			case idx.JSONPath != nil:
+				// TEXT can't be indexed without a prefix length, extracted values are short
+				column = generatedColumn{g.jsonPathColumn(c.Name, idx.JSONPath), "VARCHAR(255)", g.jsonPathExpr(c.Name, idx.JSONPath)}
+			default:
+				continue
+			}
+			if seen[column.name] {
+				continue
+			}
+			if _, exists := entity.GetFieldByName(column.name); exists {
+				return nil, fmt.Errorf("entity %q index on %q needs generated column %q, which is already a field", entity.Name, c.Name, column.name)
+			}
+			seen[column.name] = true
+			columns = append(columns, column)
+		}
+	}
+	return columns, nil
+}
+
 // defaultIndexName builds index name from the table and its
 // column names, e.g. idx_user_env_is_active.
 func (g *Generator) defaultIndexName(tableName string, idx schema.Index) string {
 	parts := append([]string{"idx", tableName}, idx.FieldNames()...)
+	parts = append(parts, idx.JSONPath...)
+	if idx.Lower {
+		parts = append(parts, "lower")
+	}
 	return strings.Join(parts, "_")
 }
 
@@ -206,6 +338,7 @@ func (g *Generator) generateCRUDQueries(entity schema.Entity) string {
 
 	var createQuery *schema.Query
 	var createBulkQuery *schema.Query
+	var createStreamQuery *schema.Query
 	var updateQuery *schema.Query
 	var deleteQuery *schema.Query
 	var deleteAllQuery *schema.Query
@@ -218,6 +351,8 @@ func (g *Generator) generateCRUDQueries(entity schema.Entity) string {
 			createQuery = &query
 		case schema.QueryCreateBulk:
 			createBulkQuery = &query
+		case schema.QueryCreateStream:
+			createStreamQuery = &query
 		case schema.QueryUpdate:
 			updateQuery = &query
 		case schema.QueryDelete:
@@ -243,15 +378,16 @@ func (g *Generator) generateCRUDQueries(entity schema.Entity) string {
 		g.writeInsertQuery(&content, entity, util.GenQueryName(*createBulkQuery, entity.Name))
 	}
 
+	// CREATE STREAM - also a single-row insert; batching happens in the sqlcWrap layer.
+	if createStreamQuery != nil {
+		g.writeInsertQuery(&content, entity, util.GenQueryName(*createStreamQuery, entity.Name))
+	}
+
 	// READ (get by)
 	for _, query := range getQueries {
 		queryName := util.GenQueryName(query, entity.Name)
 		content.WriteString(fmt.Sprintf("\n-- name: %s :one\n", queryName))
-		var whereParts []string
-		for i, fieldName := range query.Fields {
-			whereParts = append(whereParts, fmt.Sprintf("%s = %s", fieldName, g.getParameterPlaceholder(i+1)))
-		}
-		content.WriteString(fmt.Sprintf("SELECT * FROM %s WHERE %s;\n", g.quote(tableName), strings.Join(whereParts, " AND ")))
+		content.WriteString(fmt.Sprintf("SELECT * FROM %s WHERE %s;\n", g.quote(tableName), g.getByCondition(entity, query)))
 	}
 
 	// LIST
@@ -264,16 +400,31 @@ func (g *Generator) generateCRUDQueries(entity schema.Entity) string {
 		}
 		for _, filter := range query.Filters {
 			switch filter.Type {
-			case schema.QueryFilterEq:
-				whereParts = append(whereParts, fmt.Sprintf("%s = %s", filter.Field, g.namedArg(filter.Field)))
+			case schema.QueryFilterEq, schema.QueryFilterSearch:
+				whereParts = append(whereParts, g.filterCondition(filter, g.namedArg(filter.Field)))
+
+			case schema.QueryFilterJSONPath:
+				whereParts = append(whereParts, g.jsonPathCondition(filter))
 
-			case schema.QueryFilterSearch:
-				whereParts = append(whereParts, fmt.Sprintf("%s LIKE %s", filter.Field, g.namedArg(filter.Field)))
+			case schema.QueryFilterContains:
+				field, _ := entity.GetFieldByName(filter.Field)
+				whereParts = append(whereParts, g.containsCondition(filter, field))
+
+			case schema.QueryFilterAnyOf:
+				// every filter in the group binds the same named param
+				arg := g.namedArg(filter.Field)
+				var anyParts []string
+				for _, child := range filter.Filters {
+					anyParts = append(anyParts, g.filterCondition(child, arg))
+				}
+				whereParts = append(whereParts, "("+strings.Join(anyParts, " OR ")+")")
 
 			case schema.QueryFilterRange:
-				minArg := g.namedArg("min_" + filter.Field)
-				maxArg := g.namedArg("max_" + filter.Field)
-				whereParts = append(whereParts, fmt.Sprintf("%s BETWEEN %s AND %s", filter.Field, minArg, maxArg))
+				whereParts = append(whereParts, g.rangeCondition(filter))
+
+			case schema.QueryFilterNear:
+				field, _ := entity.GetFieldByName(filter.Field)
+				whereParts = append(whereParts, g.nearCondition(field))
 			}
 		}
 		if len(whereParts) == 0 {
@@ -311,14 +462,16 @@ func (g *Generator) generateCRUDQueries(entity schema.Entity) string {
 			if canApiWrite && (field.DefaultFunc != nil || field.DefaultValue != nil) {
 				acceptOptional = true
 			}
-			var fieldUpdate string
-			if !canApiRead || acceptOptional {
-				// This makes the field optional in updates - if NULL is passed, keep existing value
-				fieldUpdate = fmt.Sprintf("  %s = COALESCE(sqlc.narg('%s'), %s)", field.Name, field.Name, field.Name)
-			} else {
-				fieldUpdate = fmt.Sprintf("  %s = %s", field.Name, g.namedArg(field.Name))
+			for _, column := range fieldColumns(field) {
+				var fieldUpdate string
+				if !canApiRead || acceptOptional {
+					// This makes the field optional in updates - if NULL is passed, keep existing value
+					fieldUpdate = fmt.Sprintf("  %s = COALESCE(sqlc.narg('%s'), %s)", column, column, column)
+				} else {
+					fieldUpdate = fmt.Sprintf("  %s = %s", column, g.namedArg(column))
+				}
+				updateFields = append(updateFields, fieldUpdate)
 			}
-			updateFields = append(updateFields, fieldUpdate)
 		}
 
 		content.WriteString(strings.Join(updateFields, ",\n"))
@@ -345,6 +498,31 @@ func (g *Generator) generateCRUDQueries(entity schema.Entity) string {
 	return content.String()
 }
 
+// getByCondition matches each GetBy field. Fields under a Lower() index
+// compare lowercased values so the lookup ignores case and can use the index;
+// those need named args, and sqlc won't mix them with positional ones.
+func (g *Generator) getByCondition(entity schema.Entity, query schema.Query) string {
+	lowered := false
+	for _, fieldName := range query.Fields {
+		if entity.IsLowerIndexed(fieldName) {
+			lowered = true
+		}
+	}
+
+	var whereParts []string
+	for i, fieldName := range query.Fields {
+		switch {
+		case !lowered:
+			whereParts = append(whereParts, fmt.Sprintf("%s = %s", fieldName, g.getParameterPlaceholder(i+1)))
+		case entity.IsLowerIndexed(fieldName):
+			whereParts = append(whereParts, fmt.Sprintf("%s = LOWER(%s)", g.lowerColumn(fieldName), g.namedArg(fieldName)))
+		default:
+			whereParts = append(whereParts, fmt.Sprintf("%s = %s", fieldName, g.namedArg(fieldName)))
+		}
+	}
+	return strings.Join(whereParts, " AND ")
+}
+
 func (g *Generator) writeInsertQuery(content *strings.Builder, entity schema.Entity, queryName string) {
 	tableName := strings.ToLower(entity.Name)
 	idField := entity.GetIdField()
@@ -367,9 +545,11 @@ func (g *Generator) writeInsertQuery(content *strings.Builder, entity schema.Ent
 		if field.IsID() && field.DefaultFunc == nil {
 			continue
 		}
-		insertFields = append(insertFields, " "+field.Name)
-		parameterPlaceholder := g.getParameterPlaceholder(len(insertPlaceholders) + 1)
-		insertPlaceholders = append(insertPlaceholders, " "+parameterPlaceholder)
+		for _, column := range fieldColumns(field) {
+			insertFields = append(insertFields, " "+column)
+			parameterPlaceholder := g.getParameterPlaceholder(len(insertPlaceholders) + 1)
+			insertPlaceholders = append(insertPlaceholders, " "+parameterPlaceholder)
+		}
 	}
 
 	content.WriteString(fmt.Sprintf(" %s\n", strings.Join(insertFields, ",\n ")))
@@ -0,0 +1,387 @@
+package sqlc
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/guntisdev/entlite/internal/schema"
+	"github.com/guntisdev/entlite/pkg/entlite/migrate"
+)
+
+// Migration is the SQL that takes a database from one version of the schema
+// to the next (Up) and back again (Down).
+type Migration struct {
+	Up   string
+	Down string
+}
+
+// Empty reports two schemas that generate the same tables
+func (m Migration) Empty() bool {
+	return m.Up == "" && m.Down == ""
+}
+
+// Migration diffs the tables of two parsed schemas. Entities without the
+// SQLC contract are left out, same as in schema.sql. Tables and columns
+// renamed with RenamedFrom are renamed in place, their rows kept.
+func (g *Generator) Migration(from, to []schema.Entity) (Migration, error) {
+	fromTables, err := g.tables(from)
+	if err != nil {
+		return Migration{}, err
+	}
+	toTables, err := g.tables(to)
+	if err != nil {
+		return Migration{}, err
+	}
+
+	up := findRenames(from, to)
+	down := up.reverse()
+	return Migration{
+		Up:   g.migrate(up.apply(fromTables), toTables, g.renameSQL(up, fromTables)),
+		Down: g.migrate(down.apply(toTables), fromTables, g.renameSQL(down, toTables)),
+	}, nil
+}
+
+func (g *Generator) tables(entities []schema.Entity) ([]Table, error) {
+	var tables []Table
+	for _, entity := range schema.FilterSQLC(entities) {
+		table, err := g.table(entity)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+// migrate creates new tables and alters changed ones in the order of the
+// target schema, then drops the tables that are gone. A table's renames run
+// before its other changes.
+func (g *Generator) migrate(from, to []Table, renamed map[string][]string) string {
+	fromByName := make(map[string]Table)
+	for _, table := range from {
+		fromByName[table.Name] = table
+	}
+
+	var blocks []string
+	for _, table := range to {
+		old, ok := fromByName[table.Name]
+		if !ok {
+			blocks = append(blocks, g.tableSQL(table))
+			continue
+		}
+		if statements := append(renamed[table.Name], g.alterTable(old, table)...); len(statements) > 0 {
+			blocks = append(blocks, fmt.Sprintf("-- %s table\n%s", table.Name, strings.Join(statements, "")))
+		}
+	}
+
+	for _, table := range from {
+		if !slices.ContainsFunc(to, func(t Table) bool { return t.Name == table.Name }) {
+			blocks = append(blocks, fmt.Sprintf("-- %s table\nDROP TABLE %s;\n", table.Name, g.quote(table.Name)))
+		}
+	}
+
+	return strings.Join(blocks, "\n")
+}
+
+// alterTable lists the statements that turn one version of a table into the
+// other. Indexes that change are dropped before their columns and created
+// after them.
+func (g *Generator) alterTable(old, new Table) []string {
+	old, statements := g.backfillColumns(old, new)
+	if g.sqlDialect == schema.SQLite && needsRebuild(old, new) {
+		return append(statements, g.rebuildTable(old, new)...)
+	}
+
+	oldIndexes := indexesByName(old.Indexes)
+	newIndexes := indexesByName(new.Indexes)
+	for _, idx := range old.Indexes {
+		if next, ok := newIndexes[idx.Name]; !ok || !sameIndex(idx, next) {
+			statements = append(statements, g.dropIndexSQL(old.Name, idx.Name))
+		}
+	}
+
+	oldColumns := columnsByName(old.Columns)
+	newColumns := columnsByName(new.Columns)
+	for _, column := range old.Columns {
+		if _, ok := newColumns[column.Name]; !ok {
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;\n", g.quote(old.Name), column.Name))
+		}
+	}
+	for _, column := range new.Columns {
+		prev, ok := oldColumns[column.Name]
+		if !ok {
+			statements = append(statements, g.addColumnSQL(new.Name, column))
+			continue
+		}
+		if !sameColumn(prev, column) {
+			statements = append(statements, g.alterColumnSQL(new.Name, prev, column)...)
+		}
+	}
+
+	if !slices.Equal(old.PrimaryKey, new.PrimaryKey) {
+		statements = append(statements, g.manualStep(new.Name, "primary key changes from (%s) to (%s)",
+			strings.Join(old.PrimaryKey, ", "), strings.Join(new.PrimaryKey, ", ")))
+	}
+
+	for _, idx := range new.Indexes {
+		if prev, ok := oldIndexes[idx.Name]; !ok || !sameIndex(prev, idx) {
+			statements = append(statements, g.createIndexSQL(new.Name, idx))
+		}
+	}
+
+	return statements
+}
+
+// backfillColumns adds the NOT NULL columns with a backfill as nullable and
+// fills them, the rest of alterTable then makes them NOT NULL. The old table
+// is returned with those columns.
+func (g *Generator) backfillColumns(old, new Table) (Table, []string) {
+	oldColumns := columnsByName(old.Columns)
+	old.Columns = slices.Clone(old.Columns)
+
+	var statements []string
+	for _, column := range new.Columns {
+		if _, ok := oldColumns[column.Name]; ok || !column.NotNull || column.Default != "" {
+			continue
+		}
+		if column.Backfill == "" && column.BackfillSQL == "" {
+			continue
+		}
+		nullable := column
+		nullable.NotNull = false
+		// sqlite can't add a UNIQUE column, the rebuild adds the constraint
+		if g.sqlDialect == schema.SQLite {
+			nullable.Unique = false
+		}
+		statements = append(statements, g.addColumnSQL(new.Name, nullable), g.backfillSQL(new, column))
+		old.Columns = append(old.Columns, nullable)
+	}
+	return old, statements
+}
+
+// backfillSQL fills the null rows of a column, in the migration with an SQL
+// expression or from migrate.Up with the Go function the directive names
+func (g *Generator) backfillSQL(table Table, column Column) string {
+	if column.BackfillSQL != "" {
+		return fmt.Sprintf("UPDATE %s SET %s = %s WHERE %s IS NULL;\n", g.quote(table.Name), column.Name, column.BackfillSQL, column.Name)
+	}
+	key := table.PrimaryKey
+	if len(key) == 0 {
+		for _, c := range table.Columns {
+			if isIDColumn(c) {
+				key = []string{c.Name}
+			}
+		}
+	}
+	return fmt.Sprintf("%s %s %s %s %s\n", migrate.BackfillDirective, table.Name, column.Name, strings.Join(key, ","), column.Backfill)
+}
+
+func (g *Generator) addColumnSQL(tableName string, column Column) string {
+	sql := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s;\n", g.quote(tableName), column.Definition())
+	if column.NotNull && column.Default == "" && column.Generated == "" {
+		return g.manualStep(tableName, "%s is NOT NULL without a default, this fails on a table with rows", column.Name) + sql
+	}
+	return sql
+}
+
+// alterColumnSQL changes a column in place on postgres and mysql
+func (g *Generator) alterColumnSQL(tableName string, old, new Column) []string {
+	if isIDColumn(old) || isIDColumn(new) {
+		return []string{g.manualStep(tableName, "id column %s changes from %s to %s", new.Name, old.Type, new.Type)}
+	}
+
+	table := g.quote(tableName)
+	var statements []string
+	// rows left null would fail the constraint, give them the default first
+	if new.NotNull && !old.NotNull && new.Default != "" {
+		statements = append(statements, fmt.Sprintf("UPDATE %s SET %s = %s WHERE %s IS NULL;\n", table, new.Name, new.Default, new.Name))
+	}
+
+	if g.sqlDialect == schema.MySQL {
+		oldDef, newDef := old, new
+		oldDef.Unique, newDef.Unique = false, false
+		if !sameColumn(oldDef, newDef) {
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s;\n", table, newDef.Definition()))
+		}
+		switch {
+		case old.Unique && !new.Unique:
+			statements = append(statements, g.uniqueKeySQL(tableName, new.Name, "DROP INDEX %s"))
+		case !old.Unique && new.Unique:
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s ADD UNIQUE INDEX %s (%s);\n", table, g.uniqueKeyName(tableName, new.Name), new.Name))
+		}
+		return statements
+	}
+
+	alter := fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s", table, new.Name)
+	if old.Type != new.Type {
+		statements = append(statements, fmt.Sprintf("%s TYPE %s USING %s::%s;\n", alter, new.Type, new.Name, new.Type))
+	}
+	if old.Default != new.Default {
+		if new.Default == "" {
+			statements = append(statements, alter+" DROP DEFAULT;\n")
+		} else {
+			statements = append(statements, fmt.Sprintf("%s SET DEFAULT %s;\n", alter, new.Default))
+		}
+	}
+	switch {
+	case new.NotNull && !old.NotNull:
+		statements = append(statements, alter+" SET NOT NULL;\n")
+	case !new.NotNull && old.NotNull:
+		statements = append(statements, alter+" DROP NOT NULL;\n")
+	}
+	switch {
+	case old.Unique && !new.Unique:
+		statements = append(statements, g.uniqueKeySQL(tableName, new.Name, "DROP CONSTRAINT %s"))
+	case !old.Unique && new.Unique:
+		statements = append(statements, fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s UNIQUE (%s);\n", table, g.uniqueKeyName(tableName, new.Name), new.Name))
+	}
+	if old.Check != new.Check {
+		statements = append(statements, g.manualStep(tableName, "check on %s changes from %q to %q", new.Name, old.Check, new.Check))
+	}
+	return statements
+}
+
+// uniqueKeyName is the name the database gives an inline UNIQUE of the tables
+// entlite creates: <table>_<column>_key on postgres, the column on mysql
+func (g *Generator) uniqueKeyName(tableName, column string) string {
+	if g.sqlDialect == schema.MySQL {
+		return g.quote(column)
+	}
+	return g.quote(fmt.Sprintf("%s_%s_key", tableName, column))
+}
+
+// uniqueKeySQL runs action, an ALTER TABLE with %s for the unique constraint
+// (postgres) or unique index (mysql) of the column, e.g. "DROP CONSTRAINT %s".
+// The name is looked up in the catalog when the migration runs, an imported
+// or hand-made table may not follow uniqueKeyName.
+func (g *Generator) uniqueKeySQL(tableName, column, action string) string {
+	table := g.quote(tableName)
+	if g.sqlDialect == schema.MySQL {
+		// a missing index fails the ALTER TABLE with the message as its name
+		before, after, _ := strings.Cut(action, "%s")
+		return fmt.Sprintf("SET @entlite_key = (SELECT INDEX_NAME FROM information_schema.STATISTICS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = '%s' AND NON_UNIQUE = 0 AND INDEX_NAME <> 'PRIMARY' GROUP BY INDEX_NAME HAVING COUNT(*) = 1 AND MAX(COLUMN_NAME) = '%s' LIMIT 1);\n", tableName, column) +
+			fmt.Sprintf("SET @entlite_sql = CONCAT('ALTER TABLE %s %s`', COALESCE(@entlite_key, 'no unique index on %s.%s'), '`%s');\n", table, before, tableName, column, after) +
+			"PREPARE entlite_stmt FROM @entlite_sql;\nEXECUTE entlite_stmt;\nDEALLOCATE PREPARE entlite_stmt;\n"
+	}
+
+	return fmt.Sprintf(`DO $$
+DECLARE key_name text;
+BEGIN
+  SELECT conname INTO key_name FROM pg_constraint WHERE conrelid = '%s'::regclass AND contype = 'u'
+    AND conkey = ARRAY[(SELECT attnum FROM pg_attribute WHERE attrelid = '%s'::regclass AND attname = '%s')];
+  IF key_name IS NULL THEN
+    RAISE EXCEPTION 'no unique constraint on %s.%s';
+  END IF;
+  EXECUTE format('ALTER TABLE %s %s', key_name);
+END $$;
+`, table, table, strings.ToLower(column), tableName, column, table, fmt.Sprintf(action, "%I"))
+}
+
+// needsRebuild reports a change sqlite's ALTER TABLE can't make. It only adds
+// columns that are nullable or have a default and aren't UNIQUE.
+func needsRebuild(old, new Table) bool {
+	if !slices.Equal(old.PrimaryKey, new.PrimaryKey) {
+		return true
+	}
+	oldColumns := columnsByName(old.Columns)
+	newColumns := columnsByName(new.Columns)
+	for _, column := range old.Columns {
+		if next, ok := newColumns[column.Name]; !ok || !sameColumn(column, next) {
+			return true
+		}
+	}
+	for _, column := range new.Columns {
+		if _, ok := oldColumns[column.Name]; ok {
+			continue
+		}
+		if column.Unique || isIDColumn(column) || (column.NotNull && column.Default == "") {
+			return true
+		}
+	}
+	return false
+}
+
+// rebuildTable is sqlite's way to change a table: copy the rows into a new
+// table of the target shape, drop the old one and take its name. Columns that
+// became NOT NULL take their default where they were null.
+func (g *Generator) rebuildTable(old, new Table) []string {
+	tmpName := new.Name + "_new"
+	var body strings.Builder
+	g.writeTableBody(&body, new)
+
+	oldColumns := columnsByName(old.Columns)
+	var statements, columns, values []string
+	for _, column := range new.Columns {
+		prev, ok := oldColumns[column.Name]
+		if !ok {
+			if column.NotNull && column.Default == "" {
+				statements = append(statements, g.manualStep(new.Name, "%s is NOT NULL without a default, copying rows fails until it gets a value", column.Name))
+			}
+			continue
+		}
+		columns = append(columns, column.Name)
+		if column.NotNull && !prev.NotNull && column.Default != "" {
+			values = append(values, fmt.Sprintf("COALESCE(%s, %s)", column.Name, column.Default))
+		} else {
+			values = append(values, column.Name)
+		}
+	}
+
+	statements = append(statements,
+		fmt.Sprintf("CREATE TABLE %s(\n%s\n);\n", g.quote(tmpName), body.String()),
+		fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s;\n", g.quote(tmpName), strings.Join(columns, ", "), strings.Join(values, ", "), g.quote(old.Name)),
+		fmt.Sprintf("DROP TABLE %s;\n", g.quote(old.Name)),
+		fmt.Sprintf("ALTER TABLE %s RENAME TO %s;\n", g.quote(tmpName), g.quote(new.Name)),
+	)
+	for _, idx := range new.Indexes {
+		statements = append(statements, g.createIndexSQL(new.Name, idx))
+	}
+	return statements
+}
+
+func (g *Generator) dropIndexSQL(tableName, indexName string) string {
+	if g.sqlDialect == schema.MySQL {
+		return fmt.Sprintf("DROP INDEX %s ON %s;\n", g.quote(indexName), g.quote(tableName))
+	}
+	return fmt.Sprintf("DROP INDEX %s;\n", g.quote(indexName))
+}
+
+// manualStep marks a change entlite can't write safely, the migration has to
+// be finished by hand before it is applied, migrate.Up refuses it until then
+func (g *Generator) manualStep(tableName, format string, args ...any) string {
+	return fmt.Sprintf("%s %s: %s, edit this migration by hand\n", migrate.ManualStep, tableName, fmt.Sprintf(format, args...))
+}
+
+// isIDColumn reports the id column, its Type carries the key clause
+func isIDColumn(column Column) bool {
+	return strings.Contains(column.Type, "PRIMARY KEY")
+}
+
+// sameColumn compares what the database stores, a comment change needs no migration
+func sameColumn(a, b Column) bool {
+	a.Comment, b.Comment = "", ""
+	a.Backfill, b.Backfill = "", ""
+	a.BackfillSQL, b.BackfillSQL = "", ""
+	return a == b
+}
+
+func sameIndex(a, b TableIndex) bool {
+	return a.Unique == b.Unique && a.Where == b.Where && slices.Equal(a.Columns, b.Columns)
+}
+
+func columnsByName(columns []Column) map[string]Column {
+	byName := make(map[string]Column, len(columns))
+	for _, column := range columns {
+		byName[column.Name] = column
+	}
+	return byName
+}
+
+func indexesByName(indexes []TableIndex) map[string]TableIndex {
+	byName := make(map[string]TableIndex, len(indexes))
+	for _, idx := range indexes {
+		byName[idx.Name] = idx
+	}
+	return byName
+}
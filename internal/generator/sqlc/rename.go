@@ -0,0 +1,177 @@
+package sqlc
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/guntisdev/entlite/internal/schema"
+)
+
+// renames are the tables and columns the target schema took over with
+// RenamedFrom. A rename already made, the old name gone from the source
+// schema, is not made again.
+type renames struct {
+	tables  map[string]string            // old table name to new
+	columns map[string]map[string]string // old table name, old column to new
+}
+
+func findRenames(from, to []schema.Entity) renames {
+	r := renames{tables: map[string]string{}, columns: map[string]map[string]string{}}
+	byName := make(map[string]schema.Entity)
+	for _, entity := range schema.FilterSQLC(from) {
+		byName[entity.Name] = entity
+	}
+
+	for _, entity := range schema.FilterSQLC(to) {
+		old, ok := byName[entity.Name]
+		if !ok && entity.RenamedFrom != "" {
+			if old, ok = byName[entity.RenamedFrom]; ok {
+				r.tables[strings.ToLower(old.Name)] = strings.ToLower(entity.Name)
+			}
+		}
+		if !ok {
+			continue
+		}
+
+		oldTable := strings.ToLower(old.Name)
+		for _, field := range entity.Fields {
+			if field.RenamedFrom == "" || field.IsVirtual() {
+				continue
+			}
+			if _, exists := old.GetFieldByName(field.Name); exists {
+				continue
+			}
+			oldField, ok := old.GetFieldByName(field.RenamedFrom)
+			if !ok || oldField.IsVirtual() {
+				continue
+			}
+			// a latlng renamed from a plain field has no column for each of its two
+			oldColumns, newColumns := fieldColumns(oldField), fieldColumns(field)
+			if len(oldColumns) != len(newColumns) {
+				continue
+			}
+			if r.columns[oldTable] == nil {
+				r.columns[oldTable] = map[string]string{}
+			}
+			for i := range newColumns {
+				r.columns[oldTable][oldColumns[i]] = newColumns[i]
+			}
+		}
+	}
+	return r
+}
+
+// reverse is the way back, for the down migration
+func (r renames) reverse() renames {
+	back := renames{tables: map[string]string{}, columns: map[string]map[string]string{}}
+	for old, new := range r.tables {
+		back.tables[new] = old
+	}
+	for table, columns := range r.columns {
+		newTable := r.table(table)
+		back.columns[newTable] = map[string]string{}
+		for old, new := range columns {
+			back.columns[newTable][new] = old
+		}
+	}
+	return back
+}
+
+func (r renames) table(name string) string {
+	if renamed, ok := r.tables[name]; ok {
+		return renamed
+	}
+	return name
+}
+
+func (r renames) column(table, name string) string {
+	if renamed, ok := r.columns[table][name]; ok {
+		return renamed
+	}
+	return name
+}
+
+// apply gives the source tables their new names, so the diff that follows
+// only sees what changed besides the names
+func (r renames) apply(tables []Table) []Table {
+	renamed := make([]Table, len(tables))
+	for i, table := range tables {
+		columns := r.columns[table.Name]
+		renamed[i] = Table{Name: r.table(table.Name), Indexes: table.Indexes}
+		for _, column := range table.Columns {
+			column.Name = r.column(table.Name, column.Name)
+			column.Check = renameIn(column.Check, columns)
+			column.Generated = renameIn(column.Generated, columns)
+			renamed[i].Columns = append(renamed[i].Columns, column)
+		}
+		for _, key := range table.PrimaryKey {
+			renamed[i].PrimaryKey = append(renamed[i].PrimaryKey, r.column(table.Name, key))
+		}
+		if len(columns) == 0 {
+			continue
+		}
+		renamed[i].Indexes = nil
+		for _, idx := range table.Indexes {
+			idxColumns := make([]string, len(idx.Columns))
+			for j, column := range idx.Columns {
+				idxColumns[j] = renameIn(column, columns)
+			}
+			idx.Columns = idxColumns
+			idx.Where = renameIn(idx.Where, columns)
+			renamed[i].Indexes = append(renamed[i].Indexes, idx)
+		}
+	}
+	return renamed
+}
+
+var identifier = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*`)
+
+// renameIn renames the columns an SQL expression mentions
+func renameIn(expr string, columns map[string]string) string {
+	if len(columns) == 0 {
+		return expr
+	}
+	return identifier.ReplaceAllStringFunc(expr, func(word string) string {
+		if renamed, ok := columns[word]; ok {
+			return renamed
+		}
+		return word
+	})
+}
+
+// renameSQL lists the renames of every source table under its new name
+func (g *Generator) renameSQL(r renames, tables []Table) map[string][]string {
+	statements := make(map[string][]string)
+	for _, table := range tables {
+		newName := r.table(table.Name)
+		var sql []string
+		if newName != table.Name {
+			sql = append(sql, fmt.Sprintf("ALTER TABLE %s RENAME TO %s;\n", g.quote(table.Name), g.quote(newName)))
+		}
+		for _, column := range table.Columns {
+			if renamed := r.column(table.Name, column.Name); renamed != column.Name {
+				sql = append(sql, fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s;\n", g.quote(newName), column.Name, renamed))
+			}
+		}
+		// unique keys take the new names, the ones a fresh table would get
+		for _, column := range table.Columns {
+			renamed := r.column(table.Name, column.Name)
+			if !column.Unique || isIDColumn(column) || (renamed == column.Name && newName == table.Name) {
+				continue
+			}
+			switch g.sqlDialect {
+			case schema.PostgreSQL:
+				sql = append(sql, g.uniqueKeySQL(newName, renamed, "RENAME CONSTRAINT %s TO "+g.uniqueKeyName(newName, renamed)))
+			case schema.MySQL:
+				if renamed != column.Name {
+					sql = append(sql, g.uniqueKeySQL(newName, renamed, "RENAME INDEX %s TO "+g.uniqueKeyName(newName, renamed)))
+				}
+			}
+		}
+		if len(sql) > 0 {
+			statements[newName] = sql
+		}
+	}
+	return statements
+}
@@ -0,0 +1,58 @@
+package sqlc
+
+import "fmt"
+
+// Table is the DDL entlite generates for an entity, split into the parts a
+// migration compares between two versions of the schema.
+type Table struct {
+	Name       string
+	Columns    []Column
+	PrimaryKey []string // index.Primary columns, empty when the id column is the key
+	Indexes    []TableIndex
+}
+
+// Column is one column of a CREATE TABLE. The id column's Type carries its
+// key clause, e.g. SERIAL PRIMARY KEY.
+type Column struct {
+	Name      string
+	Type      string
+	Unique    bool
+	Default   string // SQL literal, empty for none
+	NotNull   bool
+	Check     string
+	Generated string // mysql only: expression of a VIRTUAL column an index covers
+	Comment   string
+	// Backfill and BackfillSQL fill the rows of a table the column is added
+	// to NOT NULL, they change nothing stored
+	Backfill    string
+	BackfillSQL string
+}
+
+// Definition is the column as written in a CREATE TABLE or ADD COLUMN
+func (c Column) Definition() string {
+	def := fmt.Sprintf("%s %s", c.Name, c.Type)
+	if c.Generated != "" {
+		return fmt.Sprintf("%s AS (%s) VIRTUAL", def, c.Generated)
+	}
+	if c.Unique {
+		def += " UNIQUE"
+	}
+	if c.Default != "" {
+		def += " DEFAULT " + c.Default
+	}
+	if c.NotNull {
+		def += " NOT NULL"
+	}
+	if c.Check != "" {
+		def += " " + c.Check
+	}
+	return def
+}
+
+// TableIndex is a secondary index, Columns are rendered, e.g. "code DESC"
+type TableIndex struct {
+	Name    string
+	Unique  bool
+	Columns []string
+	Where   string
+}
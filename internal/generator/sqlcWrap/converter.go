@@ -7,6 +7,8 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/guntisdev/entlite/internal/schema"
 )
 
 // EntliteAccessFileName is the file entlite adds to sqlc's generated package to
@@ -16,15 +18,40 @@ const EntliteAccessFileName = "entlite_access.go"
 
 // GenerateAccessFile emits the sqlc-package addition described above. The
 // wrapper package needs the handle to open a transaction around multi-row
-// operations; without it the unexported field is unreachable.
-func GenerateAccessFile(packageName string) string {
-	return fmt.Sprintf(`// Code generated by entlite. DO NOT EDIT.
+// operations; without it the unexported field is unreachable. Streamed list
+// queries also get their row-by-row methods here, since sqlc only generates
+// the slice form and the query text is unexported.
+func GenerateAccessFile(inputDir, packageName string, entities []schema.Entity) (string, error) {
+	streamMethods, streamSourceImports, err := generateStreamMethods(inputDir, entities)
+	if err != nil {
+		return "", err
+	}
+
+	content := fmt.Sprintf(`// Code generated by entlite. DO NOT EDIT.
 
 package %s
 
 // DB returns the handle this Queries was constructed with.
 func (q *Queries) DB() DBTX { return q.db }
 `, packageName)
+	if streamMethods == "" {
+		return content, nil
+	}
+
+	imports := streamImports(streamMethods, streamSourceImports)
+	content = fmt.Sprintf(`// Code generated by entlite. DO NOT EDIT.
+
+package %s
+
+import (
+	%s
+)
+
+// DB returns the handle this Queries was constructed with.
+func (q *Queries) DB() DBTX { return q.db }
+%s`, packageName, strings.Join(imports, "\n\t"), streamMethods)
+
+	return formatAccessFile(content)
 }
 
 // PackageNameOf reads the package clause of the first Go file in dir. sqlc's
@@ -51,7 +78,30 @@ func PackageNameOf(dir string) (string, error) {
 	return "", fmt.Errorf("no Go files found in %s", dir)
 }
 
-func GenerateConvertFile(packageName string, hasTimeField bool) string {
+func GenerateConvertFile(packageName string, entities []schema.Entity, sqlDialect schema.SQLDialect) string {
+	hasTimeField := false
+	for _, entity := range entities {
+		for _, field := range entity.Fields {
+			// a date is a time.Time too, nullable ones go through sql.NullTime
+			if field.Type == schema.FieldTypeTime || field.Type == schema.FieldTypeDate {
+				hasTimeField = true
+				break
+			}
+		}
+		if hasTimeField {
+			break
+		}
+	}
+	searchPatterns := hasSearchPatterns(entities)
+	rawJSONFields := hasRawJSONFields(entities, sqlDialect)
+	typedJSONFields := hasTypedJSONFields(entities)
+	protoJSONFields := hasProtoJSONFields(entities)
+	jsonListFields := hasListFields(entities) && storesListAsJSON(sqlDialect)
+	decimalFields := hasDecimalFields(entities)
+	dateFields := hasFieldType(entities, schema.FieldTypeDate)
+	durationFields := hasFieldType(entities, schema.FieldTypeDuration)
+	latLngFields := hasFieldType(entities, schema.FieldTypeLatLng)
+
 	var content strings.Builder
 
 	content.WriteString("package ")
@@ -59,21 +109,62 @@ func GenerateConvertFile(packageName string, hasTimeField bool) string {
 	content.WriteString("\n\n")
 
 	content.WriteString("import (\n")
+	if latLngFields {
+		content.WriteString("\t\"cmp\"\n")
+	}
 	content.WriteString("\t\"context\"\n")
 	content.WriteString("\t\"database/sql\"\n")
+	if rawJSONFields || typedJSONFields || protoJSONFields || jsonListFields {
+		content.WriteString("\t\"encoding/json\"\n")
+	}
+	if decimalFields || latLngFields {
+		content.WriteString("\t\"fmt\"\n")
+	}
+	if latLngFields {
+		content.WriteString("\t\"math\"\n")
+	}
 	content.WriteString("\t\"reflect\"\n")
+	if latLngFields {
+		content.WriteString("\t\"slices\"\n")
+	}
+	if searchPatterns {
+		content.WriteString("\t\"strings\"\n")
+	}
+	if hasTimeField || durationFields {
+		content.WriteString("\t\"time\"\n")
+	}
+	if hasTimeField || protoJSONFields || decimalFields || durationFields || latLngFields {
+		content.WriteString("\n")
+	}
+	if decimalFields {
+		content.WriteString("\t\"github.com/shopspring/decimal\"\n")
+	}
+	if dateFields {
+		content.WriteString("\t\"google.golang.org/genproto/googleapis/type/date\"\n")
+	}
+	if latLngFields {
+		content.WriteString("\t\"google.golang.org/genproto/googleapis/type/latlng\"\n")
+	}
+	if protoJSONFields {
+		content.WriteString("\t\"google.golang.org/protobuf/encoding/protojson\"\n")
+	}
+	if durationFields {
+		content.WriteString("\t\"google.golang.org/protobuf/types/known/durationpb\"\n")
+	}
+	if protoJSONFields {
+		content.WriteString("\t\"google.golang.org/protobuf/types/known/structpb\"\n")
+	}
 	if hasTimeField {
-		content.WriteString("\t\"time\"\n\n")
 		content.WriteString("\t\"google.golang.org/protobuf/types/known/timestamppb\"\n")
 	}
 	content.WriteString(")\n")
 
-	content.WriteString(generateConverterFunctions(hasTimeField))
+	content.WriteString(generateConverterFunctions(hasTimeField, searchPatterns, rawJSONFields, typedJSONFields, protoJSONFields, jsonListFields, decimalFields, dateFields, durationFields, latLngFields))
 
 	return content.String()
 }
 
-func generateConverterFunctions(hasTimeField bool) string {
+func generateConverterFunctions(hasTimeField, searchPatterns, rawJSONFields, typedJSONFields, protoJSONFields, jsonListFields, decimalFields, dateFields, durationFields, latLngFields bool) string {
 	var content strings.Builder
 
 	if hasTimeField {
@@ -82,6 +173,15 @@ func generateConverterFunctions(hasTimeField bool) string {
 	if hasTimeField {
 		content.WriteString(nullableTime)
 	}
+	if dateFields {
+		content.WriteString(dates)
+	}
+	if durationFields {
+		content.WriteString(durations)
+	}
+	if latLngFields {
+		content.WriteString(latLngs)
+	}
 	content.WriteString(txBeginner)
 	content.WriteString(optionalWithFallback)
 	content.WriteString(nullableBytes)
@@ -89,6 +189,28 @@ func generateConverterFunctions(hasTimeField bool) string {
 	content.WriteString(sqliteBools)
 	content.WriteString(sqlLiteInts)
 	content.WriteString(mysqlBytes)
+	if searchPatterns {
+		content.WriteString(likePatterns)
+	}
+	if rawJSONFields {
+		content.WriteString(rawJSON)
+	}
+	if typedJSONFields {
+		content.WriteString(typedJSON)
+	}
+	if protoJSONFields {
+		content.WriteString(protoJSON)
+	}
+	// JSONOf fields are always sent as Struct/Value
+	if typedJSONFields {
+		content.WriteString(protoTypedJSON)
+	}
+	if jsonListFields {
+		content.WriteString(jsonLists)
+	}
+	if decimalFields {
+		content.WriteString(decimals)
+	}
 
 	return content.String()
 }
@@ -154,6 +276,212 @@ func ProtoToNullTime(t *timestamppb.Timestamp) sql.NullTime {
 		Valid: true,
 	}
 }
+
+func NullTimeToPtr(n sql.NullTime) *time.Time {
+	if !n.Valid {
+		return nil
+	}
+	return &n.Time
+}
+
+func PtrToNullTime(p *time.Time) sql.NullTime {
+	if p == nil {
+		return sql.NullTime{Valid: false}
+	}
+	return sql.NullTime{
+		Time:  *p,
+		Valid: true,
+	}
+}
+`
+
+// hasFieldType reports a stored field of fieldType, for converters only that type needs
+func hasFieldType(entities []schema.Entity, fieldType schema.FieldType) bool {
+	for _, entity := range entities {
+		for _, field := range entity.Fields {
+			if field.Type == fieldType && !field.IsVirtual() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+const dates = `
+// --- Date Converters, a date is a time.Time at midnight UTC ---
+// DateToSQL drops the time of day, keeping the calendar day t has in its location
+func DateToSQL(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func DatePtrToSQL(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	d := DateToSQL(*t)
+	return &d
+}
+
+func DateToProto(t time.Time) *date.Date {
+	return &date.Date{Year: int32(t.Year()), Month: int32(t.Month()), Day: int32(t.Day())}
+}
+
+func DatePtrToProto(t *time.Time) *date.Date {
+	if t == nil {
+		return nil
+	}
+	return DateToProto(*t)
+}
+
+// Note: If the date is nil, it returns a zero time.Time{}
+func ProtoToDate(d *date.Date) time.Time {
+	if d == nil {
+		return time.Time{}
+	}
+	return time.Date(int(d.Year), time.Month(d.Month), int(d.Day), 0, 0, 0, 0, time.UTC)
+}
+`
+
+const durations = `
+// --- Duration Converters, a duration is stored as int64 nanoseconds ---
+func DurationPtrToInt64(d *time.Duration) *int64 {
+	if d == nil {
+		return nil
+	}
+	n := int64(*d)
+	return &n
+}
+
+func Int64PtrToDuration(n *int64) *time.Duration {
+	if n == nil {
+		return nil
+	}
+	d := time.Duration(*n)
+	return &d
+}
+
+func DurationPtrToProto(d *time.Duration) *durationpb.Duration {
+	if d == nil {
+		return nil
+	}
+	return durationpb.New(*d)
+}
+`
+
+const latLngs = `
+// --- LatLng Converters, a latlng is stored as two float columns ---
+// LatLng is a point on the globe in degrees
+type LatLng struct {
+	Lat float64 ` + "`json:\"lat\"`" + `
+	Lng float64 ` + "`json:\"lng\"`" + `
+}
+
+func LatLngPtrLat(p *LatLng) *float64 {
+	if p == nil {
+		return nil
+	}
+	return &p.Lat
+}
+
+func LatLngPtrLng(p *LatLng) *float64 {
+	if p == nil {
+		return nil
+	}
+	return &p.Lng
+}
+
+// LatLngFromPtrs is nil unless both columns are set
+func LatLngFromPtrs(lat, lng *float64) *LatLng {
+	if lat == nil || lng == nil {
+		return nil
+	}
+	return &LatLng{Lat: *lat, Lng: *lng}
+}
+
+func LatLngToProto(p LatLng) *latlng.LatLng {
+	return &latlng.LatLng{Latitude: p.Lat, Longitude: p.Lng}
+}
+
+func LatLngPtrToProto(p *LatLng) *latlng.LatLng {
+	if p == nil {
+		return nil
+	}
+	return LatLngToProto(*p)
+}
+
+// Note: If the latlng is nil, it returns a zero LatLng{}
+func ProtoToLatLng(p *latlng.LatLng) LatLng {
+	if p == nil {
+		return LatLng{}
+	}
+	return LatLng{Lat: p.Latitude, Lng: p.Longitude}
+}
+
+func CheckLatLng(p LatLng) error {
+	if !(p.Lat >= -90 && p.Lat <= 90) {
+		return fmt.Errorf("latitude %v is not within -90 and 90", p.Lat)
+	}
+	if !(p.Lng >= -180 && p.Lng <= 180) {
+		return fmt.Errorf("longitude %v is not within -180 and 180", p.Lng)
+	}
+	return nil
+}
+
+func CheckLatLngPtr(p *LatLng) error {
+	if p == nil {
+		return nil
+	}
+	return CheckLatLng(*p)
+}
+
+// earthRadius is in meters, the sql of filter.Near uses its double, 12742000
+const earthRadius = 6371000.0
+
+// NearBounds is what a filter.Near query binds besides the point and radius
+type NearBounds struct {
+	MinLat, MaxLat float64
+	MinLng, MaxLng float64
+	CosLat         float64 // cosine of the point's latitude, for the haversine
+}
+
+// NearBox bounds the points within radius meters of p, so an index on the
+// columns can narrow the rows before the haversine runs. A box reaching a
+// pole or across the antimeridian spans every longitude.
+func NearBox(p LatLng, radius float64) NearBounds {
+	dLat := radius / earthRadius * 180 / math.Pi
+	box := NearBounds{
+		MinLat: math.Max(p.Lat-dLat, -90),
+		MaxLat: math.Min(p.Lat+dLat, 90),
+		MinLng: -180,
+		MaxLng: 180,
+		CosLat: math.Cos(p.Lat * math.Pi / 180),
+	}
+	// degrees of longitude shrink away from the equator, the box edge
+	// farthest from it needs the widest span
+	cosEdge := math.Cos(math.Max(math.Abs(box.MinLat), math.Abs(box.MaxLat)) * math.Pi / 180)
+	if box.MinLat > -90 && box.MaxLat < 90 && cosEdge > 0 {
+		dLng := dLat / cosEdge
+		if p.Lng-dLng >= -180 && p.Lng+dLng <= 180 {
+			box.MinLng, box.MaxLng = p.Lng-dLng, p.Lng+dLng
+		}
+	}
+	return box
+}
+
+// DistanceMeters is the haversine distance the sql of filter.Near checks
+func DistanceMeters(a, b LatLng) float64 {
+	latA, latB := a.Lat*math.Pi/180, b.Lat*math.Pi/180
+	h := math.Pow(math.Sin((latB-latA)/2), 2) +
+		math.Cos(latA)*math.Cos(latB)*math.Pow(math.Sin((b.Lng-a.Lng)*math.Pi/180/2), 2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(math.Min(1, h)))
+}
+
+// SortNear orders items nearest to p first, at reads an item's point
+func SortNear[T any](items []T, p LatLng, at func(T) LatLng) {
+	slices.SortStableFunc(items, func(a, b T) int {
+		return cmp.Compare(DistanceMeters(p, at(a)), DistanceMeters(p, at(b)))
+	})
+}
 `
 
 const nullableBytes = `
@@ -325,3 +653,348 @@ func PtrBytesToNullString(p *[]byte) sql.NullString {
         Valid:  true,
     }
 }`
+
+const likePatterns = `
+
+// --- LIKE pattern builders for filter.Search Contains()/Prefix() ---
+var likeEscaper = strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_")
+
+// LikeEscape makes % and _ in value match literally, \ is the escape character
+func LikeEscape(value string) string {
+	return likeEscaper.Replace(value)
+}
+
+// LikeContains builds a pattern matching value anywhere in the column
+func LikeContains(value string) string {
+	return "%" + LikeEscape(value) + "%"
+}
+
+// LikePrefix builds a pattern matching columns that start with value
+func LikePrefix(value string) string {
+	return LikeEscape(value) + "%"
+}
+
+// LikePatternPtr applies a pattern builder to an optional value
+func LikePatternPtr(value *string, pattern func(string) string) *string {
+	if value == nil {
+		return nil
+	}
+	p := pattern(*value)
+	return &p
+}
+`
+
+// hasRawJSONFields reports json fields that sqlc hands over as json.RawMessage,
+// postgres and mysql store them in JSONB/JSON columns
+func hasRawJSONFields(entities []schema.Entity, sqlDialect schema.SQLDialect) bool {
+	if sqlDialect != schema.PostgreSQL && sqlDialect != schema.MySQL {
+		return false
+	}
+	for _, entity := range entities {
+		for _, field := range entity.Fields {
+			if field.Type == schema.FieldTypeJSON && !field.IsVirtual() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+const rawJSON = `
+
+// --- JSON Converters, JSONB/JSON columns come back as json.RawMessage ---
+func RawJSONToPtr(raw json.RawMessage) *string {
+	if raw == nil {
+		return nil
+	}
+	s := string(raw)
+	return &s
+}
+
+func PtrToRawJSON(p *string) json.RawMessage {
+	if p == nil {
+		return nil
+	}
+	return json.RawMessage(*p)
+}
+`
+
+// hasTypedJSONFields reports JSONOf fields, which need marshalling to and from their Go type
+func hasTypedJSONFields(entities []schema.Entity) bool {
+	for _, entity := range entities {
+		for _, field := range entity.Fields {
+			if field.IsTypedJSON() && !field.IsVirtual() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+const typedJSON = `
+
+// --- Typed JSON Converters, JSONOf fields are stored as json text ---
+func MarshalJSONText[T any](v T) (string, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(raw), nil
+}
+
+func MarshalJSONTextPtr[T any](v *T) (*string, error) {
+	if v == nil {
+		return nil, nil
+	}
+	text, err := MarshalJSONText(*v)
+	if err != nil {
+		return nil, err
+	}
+	return &text, nil
+}
+
+func UnmarshalJSONText[T any](text string) (T, error) {
+	var v T
+	err := json.Unmarshal([]byte(text), &v)
+	return v, err
+}
+
+func UnmarshalJSONTextPtr[T any](text *string) (*T, error) {
+	if text == nil {
+		return nil, nil
+	}
+	v, err := UnmarshalJSONText[T](*text)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+`
+
+// hasListFields reports Strings/Ints fields, dialects without array columns
+// store them as json text
+func hasListFields(entities []schema.Entity) bool {
+	for _, entity := range entities {
+		for _, field := range entity.Fields {
+			if field.IsList() && !field.IsVirtual() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+const jsonLists = `
+
+// --- List Converters, Strings/Ints fields are stored as a json array in TEXT ---
+func ListToText[T string | int32](list []T) string {
+	if list == nil {
+		return "[]"
+	}
+	// a list of strings or ints always marshals
+	raw, _ := json.Marshal(list)
+	return string(raw)
+}
+
+// ListToTextPtr is the NULL of an Optional list for a nil one
+func ListToTextPtr[T string | int32](list []T) *string {
+	if list == nil {
+		return nil
+	}
+	text := ListToText(list)
+	return &text
+}
+
+func TextToList[T string | int32](text string) ([]T, error) {
+	list := []T{}
+	if err := json.Unmarshal([]byte(text), &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func TextPtrToList[T string | int32](text *string) ([]T, error) {
+	if text == nil {
+		return nil, nil
+	}
+	return TextToList[T](*text)
+}
+`
+
+func hasDecimalFields(entities []schema.Entity) bool {
+	for _, entity := range entities {
+		for _, field := range entity.Fields {
+			if field.Type == schema.FieldTypeDecimal && !field.IsVirtual() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+const decimals = `
+
+// --- Decimal Converters, sqlc reads and writes decimal columns as text ---
+// DecimalToText writes scale digits after the point, the way NUMERIC(p,s) keeps it
+func DecimalToText(d decimal.Decimal, scale int32) string {
+	return d.StringFixed(scale)
+}
+
+func DecimalPtrToText(d *decimal.Decimal, scale int32) *string {
+	if d == nil {
+		return nil
+	}
+	text := DecimalToText(*d, scale)
+	return &text
+}
+
+func TextToDecimal(text string) (decimal.Decimal, error) {
+	return decimal.NewFromString(text)
+}
+
+func TextPtrToDecimal(text *string) (*decimal.Decimal, error) {
+	if text == nil {
+		return nil, nil
+	}
+	d, err := TextToDecimal(*text)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// CheckDecimal reports a value that doesn't fit NUMERIC(precision, scale),
+// postgres and mysql would reject or round it and sqlite's CHECK refuse it
+func CheckDecimal(d decimal.Decimal, precision, scale int32) error {
+	if !d.Equal(d.Truncate(scale)) {
+		return fmt.Errorf("%s has more than %d decimal places", d, scale)
+	}
+	if d.Abs().GreaterThanOrEqual(decimal.New(1, precision-scale)) {
+		return fmt.Errorf("%s needs more than %d digits before the decimal point", d, precision-scale)
+	}
+	return nil
+}
+
+func CheckDecimalPtr(d *decimal.Decimal, precision, scale int32) error {
+	if d == nil {
+		return nil
+	}
+	return CheckDecimal(*d, precision, scale)
+}
+`
+
+// hasProtoJSONFields reports json fields sent as google.protobuf.Struct/Value,
+// they are converted from and to their stored text or Go type
+func hasProtoJSONFields(entities []schema.Entity) bool {
+	for _, entity := range entities {
+		for _, field := range entity.Fields {
+			if field.Type == schema.FieldTypeJSON && field.ProtoJSON != schema.ProtoJSONText && !field.IsVirtual() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+const protoJSON = `
+
+// --- Proto JSON Converters, json fields sent as google.protobuf.Struct/Value ---
+// Struct and Value keep numbers as float64, integers past 2^53 lose precision.
+
+// TextToStruct fails when the text is not a json object
+func TextToStruct(text string) (*structpb.Struct, error) {
+	s := &structpb.Struct{}
+	if err := protojson.Unmarshal([]byte(text), s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func TextPtrToStruct(text *string) (*structpb.Struct, error) {
+	if text == nil {
+		return nil, nil
+	}
+	return TextToStruct(*text)
+}
+
+// TextToValue fails when the text is not valid json
+func TextToValue(text string) (*structpb.Value, error) {
+	v := &structpb.Value{}
+	if err := protojson.Unmarshal([]byte(text), v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func TextPtrToValue(text *string) (*structpb.Value, error) {
+	if text == nil {
+		return nil, nil
+	}
+	return TextToValue(*text)
+}
+
+// StructToText turns a request Struct into the text a json field stores
+func StructToText(s *structpb.Struct) (*string, error) {
+	if s == nil {
+		return nil, nil
+	}
+	return marshalText(s.AsMap())
+}
+
+// ValueToText turns a request Value into the text a json field stores
+func ValueToText(v *structpb.Value) (*string, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return marshalText(v.AsInterface())
+}
+
+func marshalText(v any) (*string, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	text := string(raw)
+	return &text, nil
+}
+`
+
+const protoTypedJSON = `
+
+// JSONToStruct converts a JSONOf value for proto, it is nil when the value
+// is nil and fails when it doesn't encode as a json object
+func JSONToStruct(v any) (*structpb.Struct, error) {
+	raw, err := json.Marshal(v)
+	if err != nil || string(raw) == "null" {
+		return nil, err
+	}
+	return TextToStruct(string(raw))
+}
+
+// JSONToValue converts a JSONOf value for proto, it is nil when the value is nil
+func JSONToValue(v any) (*structpb.Value, error) {
+	raw, err := json.Marshal(v)
+	if err != nil || string(raw) == "null" {
+		return nil, err
+	}
+	return TextToValue(string(raw))
+}
+
+// StructToJSON converts a proto Struct from a request into a JSONOf value
+func StructToJSON[T any](s *structpb.Struct) (*T, error) {
+	text, err := StructToText(s)
+	if err != nil || text == nil {
+		return nil, err
+	}
+	return UnmarshalJSONTextPtr[T](text)
+}
+
+// ValueToJSON converts a proto Value from a request into a JSONOf value
+func ValueToJSON[T any](v *structpb.Value) (*T, error) {
+	text, err := ValueToText(v)
+	if err != nil || text == nil {
+		return nil, err
+	}
+	return UnmarshalJSONTextPtr[T](text)
+}
+`
@@ -17,6 +17,11 @@ func generateCreateStruct(structName string, structType *ast.StructType, entity
 		if len(astField.Names) > 0 {
 			fieldName := astField.Names[0].Name
 			fieldPtr := getFieldByName(entity, fieldName)
+			// a latlng takes the place of its two columns
+			latLng := latLngByLatColumn(entity, fieldName)
+			if latLng != nil {
+				fieldPtr = latLng
+			}
 			if fieldPtr == nil {
 				continue
 			}
@@ -29,6 +34,10 @@ func generateCreateStruct(structName string, structType *ast.StructType, entity
 				field.Optional = true
 			}
 
+			if latLng != nil {
+				sb.WriteString(fmt.Sprintf("\t%s %s `json:\"%s\"`\n", toDBFieldName(field), fieldToGoType(field), field.Name))
+				continue
+			}
 			sb.WriteString(fmt.Sprintf("\t%s %s", fieldName, fieldToGoType(field)))
 			if astField.Tag != nil {
 				sb.WriteString(fmt.Sprintf(" %s", astField.Tag.Value))
@@ -62,6 +71,9 @@ func generateCreateQuery(funcDecl *ast.FuncDecl, entity schema.Entity, inputPkg
 
 	sb.WriteString(" {\n")
 	sb.WriteString(addValidationChecks(entity, "create", firstReturnType, "arg", "\t"))
+	sb.WriteString(marshalTypedJSON(entity, "create", "arg", "\t", "", func(errExpr string) string {
+		return "return " + errorReturnPrefix(firstReturnType) + errExpr
+	}))
 	sb.WriteString(fmt.Sprintf("\tinternalArg := %s.%sParams{\n", inputPkg, funcDecl.Name.Name))
 	writeCreateParamsFields(&sb, entity, "arg", "\t\t", sqlDialect)
 	sb.WriteString("\t}\n")
@@ -99,13 +111,22 @@ func writeCreateParamsFields(sb *strings.Builder, entity schema.Entity, argVar,
 		if field.IsID() && field.DefaultFunc == nil && field.DefaultValue == nil {
 			continue
 		}
+		argRef := fmt.Sprintf("%s.%s", argVar, exportedName)
+		if field.Type == schema.FieldTypeLatLng {
+			writeLatLngParams(sb, field, argRef, indent, sqlDialect)
+			continue
+		}
+		// JSONOf values were marshalled into a local, see marshalTypedJSON
+		if field.IsTypedJSON() {
+			argRef = typedJSONVar(field)
+		}
 		if _, hasDefaultFunc := defaultFuncFields[exportedName]; hasDefaultFunc {
 			funcName := field.DefaultFunc().(string)
 			canApiWrite := (field.Permissions & permissions.ApiWrite) != 0
 			if canApiWrite {
 				// Resolve the optional arg against the fallback first, then apply
 				// any dialect conversion around the resulting non-pointer value.
-				fallbackRef := fmt.Sprintf("OptionalWithFallback(%s.%s, %s())", argVar, exportedName, funcName)
+				fallbackRef := fmt.Sprintf("OptionalWithFallback(%s, %s())", argRef, funcName)
 				sb.WriteString(fmt.Sprintf("%s%s: %s,\n", indent, exportedName, sqlToGo(field, fallbackRef, sqlDialect)))
 			} else {
 				sb.WriteString(fmt.Sprintf("%s%s: %s(),\n", indent, exportedName, funcName))
@@ -116,13 +137,17 @@ func writeCreateParamsFields(sb *strings.Builder, entity schema.Entity, argVar,
 			if canApiWrite {
 				// Resolve the optional arg against the fallback first, then apply
 				// any dialect conversion around the resulting non-pointer value.
-				fallbackRef := fmt.Sprintf("OptionalWithFallback(%s.%s, %s)", argVar, exportedName, valueLiteral)
+				fallbackRef := fmt.Sprintf("OptionalWithFallback(%s, %s)", argRef, valueLiteral)
 				sb.WriteString(fmt.Sprintf("%s%s: %s,\n", indent, exportedName, sqlToGo(defValField, fallbackRef, sqlDialect)))
 			} else {
+				// a decimal default is a decimal.Decimal, sqlc takes its text
+				if defValField.Type == schema.FieldTypeDecimal {
+					valueLiteral = sqlToGo(defValField, valueLiteral, sqlDialect)
+				}
 				sb.WriteString(fmt.Sprintf("%s%s: %s,\n", indent, exportedName, valueLiteral))
 			}
 		} else {
-			convertField := sqlToGo(field, fmt.Sprintf("%s.%s", argVar, exportedName), sqlDialect)
+			convertField := sqlToGo(field, argRef, sqlDialect)
 			sb.WriteString(fmt.Sprintf("%s%s: %s,\n", indent, exportedName, convertField))
 		}
 	}
@@ -166,6 +191,9 @@ func generateCreateBulkQuery(funcDecl *ast.FuncDecl, entity schema.Entity, input
 
 	indexVar := "_"
 	validation := addValidationChecksIndexed(entity, "create_bulk", "nil", "item", "\t\t", "i")
+	validation += marshalTypedJSON(entity, "create_bulk", "item", "\t\t", "i", func(errExpr string) string {
+		return "return nil, " + errExpr
+	})
 	if validation != "" {
 		indexVar = "i"
 	}
@@ -201,3 +229,141 @@ func generateCreateBulkQuery(funcDecl *ast.FuncDecl, entity schema.Entity, input
 
 	return sb.String()
 }
+
+// generateCreateStreamQuery wraps the sqlc single-row CreateStream<Entity>
+// insert. Items are read from an iterator and committed in batches, each in its
+// own transaction, so a long stream never holds one open for its whole length.
+func generateCreateStreamQuery(funcDecl *ast.FuncDecl, entity schema.Entity, inputPkg string, sqlDialect schema.SQLDialect) string {
+	var sb strings.Builder
+
+	receiverType := formatType(funcDecl.Recv.List[0].Type)
+	queryName := funcDecl.Name.Name
+	paramsType := queryName + "Params"
+	internalParamsType := fmt.Sprintf("%s.%sParams", inputPkg, queryName)
+	errorType := queryName + "Error"
+	resultType := queryName + "Result"
+	idField := entity.GetIdField()
+	idType := fieldToGoType(idField)
+	rowsFunc := toUnexportedName(queryName) + "Rows"
+	batchFunc := toUnexportedName(queryName) + "Batch"
+	validateFunc := "validate" + queryName
+
+	// Handle return value conversion for SQLite/MySQL ID (int64 -> int32)
+	idExpr := "id"
+	if (sqlDialect == schema.SQLite || sqlDialect == schema.MySQL) && idField.Type == schema.FieldTypeInt {
+		idExpr = "IntConvert[int64, int32](id)"
+	}
+
+	sb.WriteString(fmt.Sprintf("// %s is a streamed item rejected by validation, Index counts items from 0 in arrival order.\n", errorType))
+	sb.WriteString(fmt.Sprintf("type %s struct {\n", errorType))
+	sb.WriteString("\tIndex int\n")
+	sb.WriteString("\tErr error\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString(fmt.Sprintf("// %s holds the ids of inserted rows and the items that were skipped.\n", resultType))
+	sb.WriteString(fmt.Sprintf("type %s struct {\n", resultType))
+	sb.WriteString(fmt.Sprintf("\tIDs []%s\n", idType))
+	sb.WriteString(fmt.Sprintf("\tErrors []%s\n", errorType))
+	sb.WriteString("}\n\n")
+
+	sb.WriteString(fmt.Sprintf("// %s inserts every row through q, which the caller binds to a transaction.\n", rowsFunc))
+	sb.WriteString(fmt.Sprintf("func %s(ctx context.Context, q *%s.Queries, args []%s) ([]%s, error) {\n", rowsFunc, inputPkg, internalParamsType, idType))
+	sb.WriteString(fmt.Sprintf("\tresults := make([]%s, 0, len(args))\n", idType))
+	sb.WriteString("\tfor _, internalArg := range args {\n")
+	sb.WriteString(fmt.Sprintf("\t\tid, err := q.%s(ctx, internalArg)\n", queryName))
+	sb.WriteString("\t\tif err != nil {\n")
+	sb.WriteString("\t\t\treturn nil, err\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString(fmt.Sprintf("\t\tresults = append(results, %s)\n", idExpr))
+	sb.WriteString("\t}\n")
+	sb.WriteString("\treturn results, nil\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString(fmt.Sprintf("// %s commits one batch in its own transaction, or in the caller's one when q is already bound to it.\n", batchFunc))
+	sb.WriteString(fmt.Sprintf("func %s(ctx context.Context, q *%s.Queries, args []%s) ([]%s, error) {\n", batchFunc, inputPkg, internalParamsType, idType))
+	sb.WriteString("\tbeginner, ok := q.DB().(txBeginner)\n")
+	sb.WriteString("\tif !ok {\n")
+	sb.WriteString(fmt.Sprintf("\t\treturn %s(ctx, q, args)\n", rowsFunc))
+	sb.WriteString("\t}\n\n")
+	sb.WriteString("\ttx, err := beginner.BeginTx(ctx, nil)\n")
+	sb.WriteString("\tif err != nil {\n")
+	sb.WriteString("\t\treturn nil, err\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tdefer func() { _ = tx.Rollback() }()\n\n")
+	sb.WriteString(fmt.Sprintf("\tresults, err := %s(ctx, q.WithTx(tx), args)\n", rowsFunc))
+	sb.WriteString("\tif err != nil {\n")
+	sb.WriteString("\t\treturn nil, err\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tif err := tx.Commit(); err != nil {\n")
+	sb.WriteString("\t\treturn nil, err\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\treturn results, nil\n")
+	sb.WriteString("}\n\n")
+
+	validation := addValidationChecks(entity, "create_stream", "error", "item", "\t")
+	if validation != "" {
+		sb.WriteString(fmt.Sprintf("func %s(item %s) error {\n", validateFunc, paramsType))
+		sb.WriteString(validation)
+		sb.WriteString("\treturn nil\n")
+		sb.WriteString("}\n\n")
+	}
+
+	sb.WriteString(fmt.Sprintf("// %s inserts items as they arrive, batchSize rows per transaction (100 when batchSize <= 0).\n", queryName))
+	sb.WriteString("// Items failing validation are skipped and reported in the result. An error from items\n")
+	sb.WriteString("// or the database stops the stream, batches committed before it are kept.\n")
+	sb.WriteString(fmt.Sprintf("func (q %s) %s(ctx context.Context, items iter.Seq2[%s, error], batchSize int) (*%s, error) {\n", receiverType, queryName, paramsType, resultType))
+	sb.WriteString("\tif batchSize <= 0 {\n")
+	sb.WriteString("\t\tbatchSize = 100\n")
+	sb.WriteString("\t}\n\n")
+	sb.WriteString(fmt.Sprintf("\tresult := &%s{}\n", resultType))
+	sb.WriteString(fmt.Sprintf("\tinternalQueries := (*%s.Queries)(q)\n", inputPkg))
+	sb.WriteString(fmt.Sprintf("\tbatch := make([]%s, 0, batchSize)\n", internalParamsType))
+	sb.WriteString("\tflush := func() error {\n")
+	sb.WriteString("\t\tif len(batch) == 0 {\n")
+	sb.WriteString("\t\t\treturn nil\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString(fmt.Sprintf("\t\tids, err := %s(ctx, internalQueries, batch)\n", batchFunc))
+	sb.WriteString("\t\tif err != nil {\n")
+	sb.WriteString("\t\t\treturn err\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t\tresult.IDs = append(result.IDs, ids...)\n")
+	sb.WriteString("\t\tbatch = batch[:0]\n")
+	sb.WriteString("\t\treturn nil\n")
+	sb.WriteString("\t}\n\n")
+
+	// items failing to marshal are skipped like invalid ones
+	marshal := marshalTypedJSON(entity, "create_stream", "item", "\t\t", "", func(errExpr string) string {
+		return fmt.Sprintf("result.Errors = append(result.Errors, %s{Index: index, Err: %s})\n\t\t\tcontinue", errorType, errExpr)
+	})
+
+	if validation != "" || marshal != "" {
+		sb.WriteString("\tindex := -1\n")
+	}
+	sb.WriteString("\tfor item, err := range items {\n")
+	sb.WriteString("\t\tif err != nil {\n")
+	sb.WriteString("\t\t\treturn result, err\n")
+	sb.WriteString("\t\t}\n")
+	if validation != "" || marshal != "" {
+		sb.WriteString("\t\tindex++\n")
+	}
+	if validation != "" {
+		sb.WriteString(fmt.Sprintf("\t\tif err := %s(item); err != nil {\n", validateFunc))
+		sb.WriteString(fmt.Sprintf("\t\t\tresult.Errors = append(result.Errors, %s{Index: index, Err: err})\n", errorType))
+		sb.WriteString("\t\t\tcontinue\n")
+		sb.WriteString("\t\t}\n")
+	}
+	sb.WriteString(marshal)
+	sb.WriteString(fmt.Sprintf("\t\tbatch = append(batch, %s{\n", internalParamsType))
+	writeCreateParamsFields(&sb, entity, "item", "\t\t\t", sqlDialect)
+	sb.WriteString("\t\t})\n")
+	sb.WriteString("\t\tif len(batch) == batchSize {\n")
+	sb.WriteString("\t\t\tif err := flush(); err != nil {\n")
+	sb.WriteString("\t\t\t\treturn result, err\n")
+	sb.WriteString("\t\t\t}\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\treturn result, flush()\n")
+	sb.WriteString("}\n\n")
+
+	return sb.String()
+}
@@ -152,6 +152,14 @@ func (ctx *generationContext) filterParamsEntity(structName string) (schema.Enti
 		return schema.Entity{}, false
 	}
 
+	// custom named queries don't carry the List/Get prefix
+	if target, ok := ctx.dslQueries[methodName]; ok {
+		switch target.query.Type {
+		case schema.QueryListBy, schema.QueryGetBy:
+			return target.entity, true
+		}
+	}
+
 	if strings.HasPrefix(methodName, "List") {
 		return ctx.findEntityForListMethod(methodName)
 	}
@@ -174,7 +182,7 @@ func (ctx *generationContext) collectDeclarations() {
 						}
 						if target, ok := ctx.paramsQuery(typeSpec.Name.Name); ok {
 							switch target.query.Type {
-							case schema.QueryCreate, schema.QueryCreateBulk:
+							case schema.QueryCreate, schema.QueryCreateBulk, schema.QueryCreateStream:
 								ctx.createParamsStructs[typeSpec.Name.Name] = structType
 							case schema.QueryUpdate:
 								ctx.updateParamsStructs[typeSpec.Name.Name] = structType
@@ -194,6 +202,14 @@ func (ctx *generationContext) collectDeclarations() {
 	}
 }
 
+// queryFilters returns the DSL filters of the query behind a sqlc method.
+func (ctx *generationContext) queryFilters(methodName string) []schema.QueryFilter {
+	if target, ok := ctx.dslQueries[methodName]; ok {
+		return target.query.Filters
+	}
+	return nil
+}
+
 // paramsQuery resolves a sqlc "<QueryName>Params" struct back to its DSL query.
 func (ctx *generationContext) paramsQuery(structName string) (dslQuery, bool) {
 	queryName, ok := strings.CutSuffix(structName, "Params")
@@ -258,6 +274,9 @@ func (ctx *generationContext) generateImports(body string) string {
 
 	// Proto packages used by model converters.
 	add("timestamppb", "", "google.golang.org/protobuf/types/known/timestamppb")
+	add("durationpb", "", "google.golang.org/protobuf/types/known/durationpb")
+	// time.Duration fields have no time import in sqlc's int64 models
+	add("time", "", "time")
 	if ctx.pbImportPath != "" {
 		add("pb", "pb", ctx.pbImportPath)
 	}
@@ -266,6 +285,10 @@ func (ctx *generationContext) generateImports(body string) string {
 	add("fmt", "", "fmt")
 	// json is used to check json fields
 	add("json", "", "encoding/json")
+	// iter is used by streamed list queries
+	add("iter", "", "iter")
+	// decimal is the model type of decimal fields
+	add("decimal", "", "github.com/shopspring/decimal")
 
 	used := make([]importSpec, 0, len(specs))
 	for _, s := range specs {
@@ -428,7 +451,7 @@ func (ctx *generationContext) processQueryGenDecl(sb *strings.Builder, decl *ast
 
 			if target, ok := ctx.paramsQuery(s.Name.Name); ok {
 				switch target.query.Type {
-				case schema.QueryCreate, schema.QueryCreateBulk:
+				case schema.QueryCreate, schema.QueryCreateBulk, schema.QueryCreateStream:
 					sb.WriteString(generateCreateStruct(s.Name.Name, ctx.createParamsStructs[s.Name.Name], target.entity))
 					continue
 				case schema.QueryUpdate:
@@ -463,7 +486,8 @@ func (ctx *generationContext) processQueryGenDecl(sb *strings.Builder, decl *ast
 
 			if structType, ok := ctx.filterParamsStructs[s.Name.Name]; ok {
 				if entity, ok := ctx.filterParamsEntity(s.Name.Name); ok {
-					sb.WriteString(generateFilterParamsStruct(s.Name.Name, structType, entity))
+					filters := ctx.queryFilters(strings.TrimSuffix(s.Name.Name, "Params"))
+					sb.WriteString(generateFilterParamsStruct(s.Name.Name, structType, entity, filters, ctx.sqlDialect))
 					continue
 				}
 			}
@@ -576,11 +600,16 @@ func (ctx *generationContext) generateDslQuery(funcDecl *ast.FuncDecl, target ds
 		return generateCreateQuery(funcDecl, target.entity, ctx.inputPackageName, ctx.sqlDialect)
 	case schema.QueryCreateBulk:
 		return generateCreateBulkQuery(funcDecl, target.entity, ctx.inputPackageName, ctx.sqlDialect)
+	case schema.QueryCreateStream:
+		return generateCreateStreamQuery(funcDecl, target.entity, ctx.inputPackageName, ctx.sqlDialect)
 	case schema.QueryUpdate:
 		return generateUpdateQuery(funcDecl, target.entity, ctx.inputPackageName, ctx.sqlDialect)
 	case schema.QueryGetBy:
 		return ctx.generateGetQuery(funcDecl, target.entity)
 	case schema.QueryListBy, schema.QueryListAll:
+		if target.query.Stream {
+			return ctx.generateListStreamQuery(funcDecl, target.entity)
+		}
 		return ctx.generateListQuery(funcDecl, target.entity)
 	case schema.QueryDelete:
 		return generateDeleteQuery(funcDecl, target.entity, ctx.inputPackageName, ctx.sqlDialect)
@@ -782,8 +811,34 @@ func (ctx *generationContext) generateEntityModel(entity schema.Entity) string {
 func (ctx *generationContext) generateModelConverters(entity schema.Entity) string {
 	var sb strings.Builder
 
-	sb.WriteString(fmt.Sprintf("func (m *%s) %sToSQL() *%s.%s {\n", entity.Name, entity.Name, ctx.inputPackageName, entity.Name))
-	sb.WriteString("\tif m == nil {\n\t\treturn nil\n\t}\n\n")
+	// JSONOf and list fields are (un)marshalled here, so their converters can fail
+	typed := convertersFail(entity)
+	nilReturn := "nil"
+	if typed {
+		nilReturn = "nil, nil"
+	}
+
+	if typed {
+		sb.WriteString(fmt.Sprintf("func (m *%s) %sToSQL() (*%s.%s, error) {\n", entity.Name, entity.Name, ctx.inputPackageName, entity.Name))
+	} else {
+		sb.WriteString(fmt.Sprintf("func (m *%s) %sToSQL() *%s.%s {\n", entity.Name, entity.Name, ctx.inputPackageName, entity.Name))
+	}
+	sb.WriteString(fmt.Sprintf("\tif m == nil {\n\t\treturn %s\n\t}\n\n", nilReturn))
+
+	for _, field := range entity.Fields {
+		if !field.IsTypedJSON() || field.IsVirtual() {
+			continue
+		}
+		marshal := "MarshalJSONText"
+		if field.Optional {
+			marshal = "MarshalJSONTextPtr"
+		}
+		sb.WriteString(fmt.Sprintf("\t%s, jsonErr := %s(m.%s)\n", typedJSONVar(field), marshal, toDBFieldName(field)))
+		sb.WriteString("\tif jsonErr != nil {\n")
+		sb.WriteString(fmt.Sprintf("\t\treturn nil, fmt.Errorf(\"Failed converting '%s': invalid json in field '%s': %%w\", jsonErr)\n", entity.Name, field.Name))
+		sb.WriteString("\t}\n")
+	}
+
 	sb.WriteString(fmt.Sprintf("\treturn &%s.%s{\n", ctx.inputPackageName, entity.Name))
 
 	for _, field := range entity.Fields {
@@ -792,14 +847,75 @@ func (ctx *generationContext) generateModelConverters(entity schema.Entity) stri
 		}
 
 		fieldName := toDBFieldName(field)
-		convertedValue := sqlToGo(field, "m."+fieldName, ctx.sqlDialect)
+		modelRef := "m." + fieldName
+		if field.IsTypedJSON() {
+			modelRef = typedJSONVar(field)
+		}
+		if field.Type == schema.FieldTypeLatLng {
+			writeLatLngParams(&sb, field, modelRef, "\t\t", ctx.sqlDialect)
+			continue
+		}
+		convertedValue := sqlToGo(field, modelRef, ctx.sqlDialect)
 		sb.WriteString(fmt.Sprintf("\t\t%s: %s,\n", fieldName, convertedValue))
 	}
 
-	sb.WriteString("\t}\n}\n\n")
+	if typed {
+		sb.WriteString("\t}, nil\n}\n\n")
+		sb.WriteString(fmt.Sprintf("func %sFromSQL(db *%s.%s) (*%s, error) {\n", entity.Name, ctx.inputPackageName, entity.Name, entity.Name))
+	} else {
+		sb.WriteString("\t}\n}\n\n")
+		sb.WriteString(fmt.Sprintf("func %sFromSQL(db *%s.%s) *%s {\n", entity.Name, ctx.inputPackageName, entity.Name, entity.Name))
+	}
+	sb.WriteString(fmt.Sprintf("\tif db == nil {\n\t\treturn %s\n\t}\n\n", nilReturn))
+
+	for _, field := range entity.Fields {
+		if !field.IsTypedJSON() || field.IsVirtual() {
+			continue
+		}
+		unmarshal := "UnmarshalJSONText"
+		if field.Optional {
+			unmarshal = "UnmarshalJSONTextPtr"
+		}
+		textRef := goFromSQL(field, "db."+toDBFieldName(field), ctx.sqlDialect)
+		sb.WriteString(fmt.Sprintf("\t%s, jsonErr := %s[%s](%s)\n", typedJSONVar(field), unmarshal, field.GoType, textRef))
+		sb.WriteString("\tif jsonErr != nil {\n")
+		sb.WriteString(fmt.Sprintf("\t\treturn nil, fmt.Errorf(\"Failed converting '%s': invalid json in field '%s': %%w\", jsonErr)\n", entity.Name, field.Name))
+		sb.WriteString("\t}\n")
+	}
+
+	for _, field := range entity.Fields {
+		if !field.IsList() || field.IsVirtual() || !storesListAsJSON(ctx.sqlDialect) {
+			continue
+		}
+		unmarshal := "TextToList"
+		textRef := "db." + toDBFieldName(field)
+		if field.Optional {
+			unmarshal = "TextPtrToList"
+			textRef = goFromSQL(schema.Field{Type: schema.FieldTypeString, Optional: true}, textRef, ctx.sqlDialect)
+		}
+		elemType := fieldToGoType(schema.Field{Type: field.ElemType()})
+		sb.WriteString(fmt.Sprintf("\t%s, jsonErr := %s[%s](%s)\n", listVar(field), unmarshal, elemType, textRef))
+		sb.WriteString("\tif jsonErr != nil {\n")
+		sb.WriteString(fmt.Sprintf("\t\treturn nil, fmt.Errorf(\"Failed converting '%s': invalid json in field '%s': %%w\", jsonErr)\n", entity.Name, field.Name))
+		sb.WriteString("\t}\n")
+	}
+
+	for _, field := range entity.Fields {
+		if field.Type != schema.FieldTypeDecimal || field.IsVirtual() {
+			continue
+		}
+		parse := "TextToDecimal"
+		textRef := "db." + toDBFieldName(field)
+		if field.Optional {
+			parse = "TextPtrToDecimal"
+			textRef = goFromSQL(schema.Field{Type: schema.FieldTypeString, Optional: true}, textRef, ctx.sqlDialect)
+		}
+		sb.WriteString(fmt.Sprintf("\t%s, decimalErr := %s(%s)\n", decimalVar(field), parse, textRef))
+		sb.WriteString("\tif decimalErr != nil {\n")
+		sb.WriteString(fmt.Sprintf("\t\treturn nil, fmt.Errorf(\"Failed converting '%s': invalid decimal in field '%s': %%w\", decimalErr)\n", entity.Name, field.Name))
+		sb.WriteString("\t}\n")
+	}
 
-	sb.WriteString(fmt.Sprintf("func %sFromSQL(db *%s.%s) *%s {\n", entity.Name, ctx.inputPackageName, entity.Name, entity.Name))
-	sb.WriteString("\tif db == nil {\n\t\treturn nil\n\t}\n\n")
 	sb.WriteString(fmt.Sprintf("\treturn &%s{\n", entity.Name))
 
 	for _, field := range entity.Fields {
@@ -809,20 +925,57 @@ func (ctx *generationContext) generateModelConverters(entity schema.Entity) stri
 
 		fieldName := toDBFieldName(field)
 		convertedValue := goFromSQL(field, "db."+fieldName, ctx.sqlDialect)
+		if field.Type == schema.FieldTypeDecimal {
+			convertedValue = decimalVar(field)
+		}
+		if field.IsTypedJSON() {
+			convertedValue = typedJSONVar(field)
+		}
+		if field.IsList() && storesListAsJSON(ctx.sqlDialect) {
+			convertedValue = listVar(field)
+		}
 		sb.WriteString(fmt.Sprintf("\t\t%s: %s,\n", fieldName, convertedValue))
 	}
 
-	sb.WriteString("\t}\n}\n")
+	if typed {
+		sb.WriteString("\t}, nil\n}\n")
+	} else {
+		sb.WriteString("\t}\n}\n")
+	}
 
 	return sb.String()
 }
+
 func (ctx *generationContext) generateProtoConverter(entity schema.Entity) string {
 	var sb strings.Builder
 	protoPackage := "pb"
 
 	sb.WriteString(fmt.Sprintf("// ToProto converts %s to proto format\n", entity.Name))
-	sb.WriteString(fmt.Sprintf("func (m *%s) ToProto() *%s.%s {\n", entity.Name, protoPackage, entity.Name))
-	sb.WriteString("\tif m == nil {\n\t\treturn nil\n\t}\n\n")
+	fails := protoConverterFails(entity)
+	if fails {
+		sb.WriteString(fmt.Sprintf("func (m *%s) ToProto() (*%s.%s, error) {\n", entity.Name, protoPackage, entity.Name))
+		sb.WriteString("\tif m == nil {\n\t\treturn nil, nil\n\t}\n\n")
+	} else {
+		sb.WriteString(fmt.Sprintf("func (m *%s) ToProto() *%s.%s {\n", entity.Name, protoPackage, entity.Name))
+		sb.WriteString("\tif m == nil {\n\t\treturn nil\n\t}\n\n")
+	}
+
+	for _, field := range entity.Fields {
+		if !isProtoJSONField(field) {
+			continue
+		}
+		target := "google.protobuf.Struct"
+		if field.ProtoJSON == schema.ProtoJSONValue {
+			target = "google.protobuf.Value"
+		}
+		sb.WriteString(fmt.Sprintf("\t%s, protoErr := %s(m.%s)\n", protoJSONVar(field), protoJSONConverter(field), toDBFieldName(field)))
+		sb.WriteString("\tif protoErr != nil {\n")
+		sb.WriteString(fmt.Sprintf("\t\treturn nil, fmt.Errorf(\"Failed converting '%s': field '%s' is not a %s: %%w\", protoErr)\n", entity.Name, field.Name, target))
+		sb.WriteString("\t}\n")
+	}
+	if fails {
+		sb.WriteString("\n")
+	}
 	sb.WriteString(fmt.Sprintf("\treturn &%s.%s{\n", protoPackage, entity.Name))
 
 	for _, field := range entity.Fields {
@@ -837,12 +990,38 @@ func (ctx *generationContext) generateProtoConverter(entity schema.Entity) strin
 		protoName := toProtoFieldName(field)
 		modelName := toDBFieldName(field)
 
-		if field.Type == schema.FieldTypeTime {
+		if field.Type == schema.FieldTypeDate {
+			toProto := "DateToProto"
+			if field.Optional {
+				toProto = "DatePtrToProto"
+			}
+			sb.WriteString(fmt.Sprintf("\t\t%s: %s(m.%s),\n", protoName, toProto, modelName))
+		} else if field.Type == schema.FieldTypeLatLng {
+			toProto := "LatLngToProto"
+			if field.Optional {
+				toProto = "LatLngPtrToProto"
+			}
+			sb.WriteString(fmt.Sprintf("\t\t%s: %s(m.%s),\n", protoName, toProto, modelName))
+		} else if field.Type == schema.FieldTypeDuration {
+			if field.Optional {
+				sb.WriteString(fmt.Sprintf("\t\t%s: DurationPtrToProto(m.%s),\n", protoName, modelName))
+			} else {
+				sb.WriteString(fmt.Sprintf("\t\t%s: durationpb.New(m.%s),\n", protoName, modelName))
+			}
+		} else if field.Type == schema.FieldTypeTime {
 			if field.Optional {
 				sb.WriteString(fmt.Sprintf("\t\t%s: func() *timestamppb.Timestamp { if m.%s != nil { return timestamppb.New(*m.%s) }; return nil }(),\n", protoName, modelName, modelName))
 			} else {
 				sb.WriteString(fmt.Sprintf("\t\t%s: timestamppb.New(m.%s),\n", protoName, modelName))
 			}
+		} else if isProtoJSONField(field) {
+			sb.WriteString(fmt.Sprintf("\t\t%s: %s,\n", protoName, protoJSONVar(field)))
+		} else if field.Type == schema.FieldTypeDecimal {
+			toText := "DecimalToText"
+			if field.Optional {
+				toText = "DecimalPtrToText"
+			}
+			sb.WriteString(fmt.Sprintf("\t\t%s: %s(m.%s, %d),\n", protoName, toText, modelName, field.Scale))
 		} else if field.Type == schema.FieldTypeByte && field.Optional {
 			// proto uses []byte for optional bytes; unwrap the wrapper's *[]byte.
 			sb.WriteString(fmt.Sprintf("\t\t%s: PtrToNullBytes(m.%s),\n", protoName, modelName))
@@ -851,7 +1030,11 @@ func (ctx *generationContext) generateProtoConverter(entity schema.Entity) strin
 		}
 	}
 
-	sb.WriteString("\t}\n}\n")
+	if fails {
+		sb.WriteString("\t}, nil\n}\n")
+	} else {
+		sb.WriteString("\t}\n}\n")
+	}
 
 	return sb.String()
 }
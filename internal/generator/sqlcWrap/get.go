@@ -30,7 +30,7 @@ func (ctx *generationContext) generateGetQuery(funcDecl *ast.FuncDecl, entity sc
 	sb.WriteString("\t\treturn nil, err\n")
 	sb.WriteString("\t}\n")
 
-	sb.WriteString(fmt.Sprintf("\treturn %sFromSQL(&dbResult), nil\n", entity.Name))
+	sb.WriteString(fromSQLReturn(entity, "&dbResult", "\t"))
 	sb.WriteString("}\n\n")
 
 	return sb.String()
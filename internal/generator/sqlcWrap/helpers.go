@@ -5,6 +5,7 @@ import (
 	"go/ast"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/guntisdev/entlite/internal/schema"
 	"github.com/guntisdev/entlite/pkg/entlite/permissions"
@@ -16,6 +17,14 @@ func fieldToGoType(field schema.Field) string {
 		optionalStr = "*"
 	}
 
+	if field.IsTypedJSON() {
+		return optionalStr + field.GoType
+	}
+	// a nil list is the NULL of an Optional one, it needs no pointer
+	if field.IsList() {
+		return "[]" + fieldToGoType(schema.Field{Type: field.ElemType()})
+	}
+
 	switch field.Type {
 	case schema.FieldTypeString, schema.FieldTypeJSON:
 		return fmt.Sprintf("%sstring", optionalStr)
@@ -27,10 +36,16 @@ func fieldToGoType(field schema.Field) string {
 		return fmt.Sprintf("%sfloat64", optionalStr)
 	case schema.FieldTypeBool:
 		return fmt.Sprintf("%sbool", optionalStr)
-	case schema.FieldTypeTime:
+	case schema.FieldTypeTime, schema.FieldTypeDate:
 		return fmt.Sprintf("%stime.Time", optionalStr)
+	case schema.FieldTypeDuration:
+		return fmt.Sprintf("%stime.Duration", optionalStr)
 	case schema.FieldTypeByte:
 		return fmt.Sprintf("%s[]byte", optionalStr)
+	case schema.FieldTypeDecimal:
+		return fmt.Sprintf("%sdecimal.Decimal", optionalStr)
+	case schema.FieldTypeLatLng:
+		return fmt.Sprintf("%sLatLng", optionalStr)
 	default:
 		return fmt.Sprintf("%sstring", optionalStr)
 	}
@@ -45,8 +60,64 @@ func getFieldByName(entity schema.Entity, name string) *schema.Field {
 	return nil
 }
 
+// latLngByLatColumn finds the latlng field whose first column sqlc names
+// name, e.g. PositionLat. Its second column has no field of its own.
+func latLngByLatColumn(entity schema.Entity, name string) *schema.Field {
+	for _, field := range entity.Fields {
+		if field.Type == schema.FieldTypeLatLng && toDBFieldName(field)+"Lat" == name {
+			return &field
+		}
+	}
+	return nil
+}
+
+// writeLatLngParams splits a latlng value into the two columns sqlc has for
+// it, e.g. PositionLat: arg.Position.Lat
+func writeLatLngParams(sb *strings.Builder, field schema.Field, ref, indent string, sqlDialect schema.SQLDialect) {
+	latRef, lngRef := ref+".Lat", ref+".Lng"
+	if field.Optional {
+		latRef, lngRef = fmt.Sprintf("LatLngPtrLat(%s)", ref), fmt.Sprintf("LatLngPtrLng(%s)", ref)
+	}
+	column := schema.Field{Type: schema.FieldTypeFloat, Optional: field.Optional}
+	exportedName := toDBFieldName(field)
+	sb.WriteString(fmt.Sprintf("%s%sLat: %s,\n", indent, exportedName, sqlToGo(column, latRef, sqlDialect)))
+	sb.WriteString(fmt.Sprintf("%s%sLng: %s,\n", indent, exportedName, sqlToGo(column, lngRef, sqlDialect)))
+}
+
+// nearParamValue maps a param sqlc has for a filter.Near to what the wrapper
+// fills it with. The caller passes the point and the radius, the bounding box
+// and the cosine of the point's latitude come from NearBox, see nearBoxVar.
+func nearParamValue(filters []schema.QueryFilter, paramName, argVar string) (schema.QueryFilter, string, bool) {
+	for _, filter := range filters {
+		if filter.Type != schema.QueryFilterNear {
+			continue
+		}
+		base := snakeToCamelCase(filter.Field)
+		box := nearBoxVar(filter)
+		values := map[string]string{
+			"Min" + base + "Lat": box + ".MinLat",
+			"Max" + base + "Lat": box + ".MaxLat",
+			"Min" + base + "Lng": box + ".MinLng",
+			"Max" + base + "Lng": box + ".MaxLng",
+			base + "CosLat":      box + ".CosLat",
+			base + "Lat":         fmt.Sprintf("%s.%s.Lat", argVar, base),
+			base + "Lng":         fmt.Sprintf("%s.%s.Lng", argVar, base),
+			base + "Radius":      fmt.Sprintf("%s.%sRadius", argVar, base),
+		}
+		if value, ok := values[paramName]; ok {
+			return filter, value, true
+		}
+	}
+	return schema.QueryFilter{}, "", false
+}
+
+// nearBoxVar names the local that holds a filter.Near's NearBox
+func nearBoxVar(filter schema.QueryFilter) string {
+	return toUnexportedName(snakeToCamelCase(filter.Field)) + "Box"
+}
+
 // converts query sql types to go type
-func filterParamField(entity schema.Entity, paramName string) (schema.Field, bool) {
+func filterParamField(entity schema.Entity, filters []schema.QueryFilter, paramName string, sqlDialect schema.SQLDialect) (schema.Field, bool) {
 	lookup := func(name string) (schema.Field, bool) {
 		for _, field := range entity.Fields {
 			if strings.EqualFold(toDBFieldName(field), name) {
@@ -56,13 +127,45 @@ func filterParamField(entity schema.Entity, paramName string) (schema.Field, boo
 		return schema.Field{}, false
 	}
 
+	// a contains param is one element of the list, it shares the field's name
+	for _, filter := range filters {
+		if filter.Type == schema.QueryFilterContains && strings.EqualFold(snakeToCamelCase(filter.Field), paramName) {
+			field, _ := lookup(paramName)
+			return schema.Field{Name: filter.Field, Type: field.ElemType(), Optional: filter.Optional}, true
+		}
+	}
+
 	if field, ok := lookup(paramName); ok {
 		return field, true
 	}
 
+	// an AnyOf group's shared param takes the type of its fields
+	for _, filter := range filters {
+		if filter.Type == schema.QueryFilterAnyOf && strings.EqualFold(snakeToCamelCase(filter.Field), paramName) {
+			return lookup(snakeToCamelCase(filter.Filters[0].Field))
+		}
+	}
+
+	// a jsonpath param is the text at the path, nullable when the filter is optional
+	for _, filter := range filters {
+		if filter.Type == schema.QueryFilterJSONPath && strings.EqualFold(snakeToCamelCase(filter.ParamName()), paramName) {
+			paramType := schema.FieldTypeString
+			if sqlDialect == schema.MySQL {
+				// sqlc types it after the JSON column and mysql has no cast it keeps
+				// as text, so it is a json.RawMessage compared as a binary string
+				paramType = schema.FieldTypeJSON
+			}
+			return schema.Field{Name: filter.ParamName(), Type: paramType, Optional: filter.Optional}, true
+		}
+	}
+
 	for _, prefix := range []string{"Min", "Max"} {
 		if len(paramName) > len(prefix) && strings.EqualFold(paramName[:len(prefix)], prefix) {
 			if field, ok := lookup(paramName[len(prefix):]); ok {
+				// an optional bound is a nullable param even on a required field
+				if rangeBoundOptional(filters, field, prefix) {
+					field.Optional = true
+				}
 				return field, true
 			}
 		}
@@ -71,19 +174,45 @@ func filterParamField(entity schema.Entity, paramName string) (schema.Field, boo
 	return schema.Field{}, false
 }
 
+func rangeBoundOptional(filters []schema.QueryFilter, field schema.Field, prefix string) bool {
+	for _, filter := range filters {
+		if filter.Type != schema.QueryFilterRange || !strings.EqualFold(filter.Field, field.Name) {
+			continue
+		}
+		if prefix == "Min" {
+			return filter.OptionalMin
+		}
+		return filter.OptionalMax
+	}
+	return false
+}
+
 // restates sqlc "<Query>Params" struct in wrapper's own types, keeping sqlc's field names and json tags.
-func generateFilterParamsStruct(structName string, structType *ast.StructType, entity schema.Entity) string {
+func generateFilterParamsStruct(structName string, structType *ast.StructType, entity schema.Entity, filters []schema.QueryFilter, sqlDialect schema.SQLDialect) string {
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("type %s struct {\n", structName))
 
+	nearSeen := make(map[string]bool)
 	for _, astField := range structType.Fields.List {
 		if len(astField.Names) == 0 {
 			continue
 		}
 		fieldName := astField.Names[0].Name
 
+		// a filter.Near takes the point and radius, the other params are worked out
+		if filter, _, ok := nearParamValue(filters, fieldName, "arg"); ok {
+			switch base := snakeToCamelCase(filter.Field); {
+			case fieldName == base+"Radius":
+				sb.WriteString(fmt.Sprintf("\t%s float64 `json:\"%s_radius\"`\n", fieldName, filter.Field))
+			case !nearSeen[filter.Field]:
+				sb.WriteString(fmt.Sprintf("\t%s LatLng `json:\"%s\"`\n", base, filter.Field))
+				nearSeen[filter.Field] = true
+			}
+			continue
+		}
+
 		goType := formatType(astField.Type)
-		if field, ok := filterParamField(entity, fieldName); ok {
+		if field, ok := filterParamField(entity, filters, fieldName, sqlDialect); ok {
 			goType = fieldToGoType(field)
 		}
 
@@ -99,8 +228,14 @@ func generateFilterParamsStruct(structName string, structType *ast.StructType, e
 }
 
 // builds internal params literal handed to sqlc, converting each field back to its dialect type.
-func generateFilterParamsArg(structName string, structType *ast.StructType, entity schema.Entity, inputPkg, argVar string, sqlDialect schema.SQLDialect) string {
+func generateFilterParamsArg(structName string, structType *ast.StructType, entity schema.Entity, filters []schema.QueryFilter, inputPkg, argVar string, sqlDialect schema.SQLDialect) string {
 	var sb strings.Builder
+	for _, filter := range filters {
+		if filter.Type == schema.QueryFilterNear {
+			base := snakeToCamelCase(filter.Field)
+			sb.WriteString(fmt.Sprintf("\t%s := NearBox(%s.%s, %s.%sRadius)\n", nearBoxVar(filter), argVar, base, argVar, base))
+		}
+	}
 	sb.WriteString(fmt.Sprintf("\tinternalArg := %s.%s{\n", inputPkg, structName))
 
 	for _, astField := range structType.Fields.List {
@@ -110,8 +245,10 @@ func generateFilterParamsArg(structName string, structType *ast.StructType, enti
 		fieldName := astField.Names[0].Name
 
 		valueRef := fmt.Sprintf("%s.%s", argVar, fieldName)
-		if field, ok := filterParamField(entity, fieldName); ok {
-			valueRef = sqlToGo(field, valueRef, sqlDialect)
+		if _, nearRef, ok := nearParamValue(filters, fieldName, argVar); ok {
+			valueRef = nearRef
+		} else if field, ok := filterParamField(entity, filters, fieldName, sqlDialect); ok {
+			valueRef = filterParamRef(filters, field, fieldName, valueRef, sqlDialect)
 		}
 
 		sb.WriteString(fmt.Sprintf("\t\t%s: %s,\n", fieldName, valueRef))
@@ -128,6 +265,8 @@ func (ctx *generationContext) wrapFilterParams(funcDecl *ast.FuncDecl, entity sc
 		return "", "", ""
 	}
 
+	filters := ctx.queryFilters(funcDecl.Name.Name)
+
 	var paramsSb, argsSb, preludeSb strings.Builder
 
 	// Index 0 is ctx, which callers emit themselves.
@@ -139,15 +278,15 @@ func (ctx *generationContext) wrapFilterParams(funcDecl *ast.FuncDecl, entity sc
 			// A params struct the wrapper restates: take ours, convert to sqlc's.
 			if structType, ok := ctx.filterParamsStructs[typeName]; ok {
 				paramsSb.WriteString(fmt.Sprintf(", %s %s", name.Name, typeName))
-				preludeSb.WriteString(generateFilterParamsArg(typeName, structType, entity, ctx.inputPackageName, name.Name, ctx.sqlDialect))
+				preludeSb.WriteString(generateFilterParamsArg(typeName, structType, entity, filters, ctx.inputPackageName, name.Name, ctx.sqlDialect))
 				argsSb.WriteString(", internalArg")
 				continue
 			}
 
 			// A lone filter arrives as a bare scalar rather than a struct.
-			if field, ok := filterParamField(entity, name.Name); ok {
+			if field, ok := filterParamField(entity, filters, name.Name, ctx.sqlDialect); ok {
 				paramsSb.WriteString(fmt.Sprintf(", %s %s", name.Name, fieldToGoType(field)))
-				argsSb.WriteString(fmt.Sprintf(", %s", sqlToGo(field, name.Name, ctx.sqlDialect)))
+				argsSb.WriteString(fmt.Sprintf(", %s", filterParamRef(filters, field, name.Name, name.Name, ctx.sqlDialect)))
 				continue
 			}
 
@@ -159,13 +298,80 @@ func (ctx *generationContext) wrapFilterParams(funcDecl *ast.FuncDecl, entity sc
 	return paramsSb.String(), argsSb.String(), preludeSb.String()
 }
 
-func addValidationChecks(entity schema.Entity, sqlQuery string, returnType, argVar, indent string) string {
-	return addValidationChecksIndexed(entity, sqlQuery, returnType, argVar, indent, "")
+// filterParamRef converts a filter param for sqlc. mysql casts the element of
+// a filter.Contains on Ints to SIGNED, so sqlc binds it as an int64 there.
+func filterParamRef(filters []schema.QueryFilter, field schema.Field, paramName, valueRef string, sqlDialect schema.SQLDialect) string {
+	if sqlDialect == schema.MySQL && field.Type == schema.FieldTypeInt {
+		for _, filter := range filters {
+			if filter.Type != schema.QueryFilterContains || !strings.EqualFold(snakeToCamelCase(filter.Field), paramName) {
+				continue
+			}
+			if field.Optional {
+				return fmt.Sprintf("PtrToNullInt64(IntPtrConvert[int32, int64](%s))", valueRef)
+			}
+			return fmt.Sprintf("IntConvert[int32, int64](%s)", valueRef)
+		}
+	}
+
+	valueRef = searchPatternRef(filters, field, paramName, valueRef)
+	return sqlToGo(field, valueRef, sqlDialect)
 }
 
-func addValidationChecksIndexed(entity schema.Entity, sqlQuery string, returnType, argVar, indent, indexVar string) string {
-	var sb strings.Builder
+// searchPatternRef wraps the value of a Contains/Prefix search in the convert.go
+// helper that escapes it and adds the wildcards, the sql binds it to a plain LIKE.
+func searchPatternRef(filters []schema.QueryFilter, field schema.Field, paramName, valueRef string) string {
+	for _, filter := range filters {
+		if !strings.EqualFold(snakeToCamelCase(filter.Field), paramName) {
+			continue
+		}
 
+		match := filter.Match
+		if filter.Type == schema.QueryFilterAnyOf {
+			// the parser only groups filters of one kind and match
+			filter, match = filter.Filters[0], filter.Filters[0].Match
+		}
+		if filter.Type != schema.QueryFilterSearch {
+			return valueRef
+		}
+
+		var pattern string
+		switch match {
+		case schema.SearchContains:
+			pattern = "LikeContains"
+		case schema.SearchPrefix:
+			pattern = "LikePrefix"
+		default:
+			return valueRef
+		}
+
+		if field.Optional {
+			return fmt.Sprintf("LikePatternPtr(%s, %s)", valueRef, pattern)
+		}
+		return fmt.Sprintf("%s(%s)", pattern, valueRef)
+	}
+
+	return valueRef
+}
+
+// hasSearchPatterns reports whether any query needs the LIKE pattern helpers in convert.go
+func hasSearchPatterns(entities []schema.Entity) bool {
+	for _, entity := range entities {
+		for _, query := range entity.Queries {
+			for _, filter := range query.Filters {
+				if filter.Type == schema.QueryFilterAnyOf {
+					filter = filter.Filters[0]
+				}
+				if filter.Type == schema.QueryFilterSearch && filter.Match != schema.SearchPattern {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// errorReturnPrefix is what goes before the error in a return statement
+func errorReturnPrefix(returnType string) string {
 	var zeroValue string
 	switch returnType {
 	case "", "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
@@ -176,9 +382,23 @@ func addValidationChecksIndexed(entity schema.Entity, sqlQuery string, returnTyp
 		zeroValue = "false"
 	case "string":
 		zeroValue = "\"\""
+	case "error":
+		// an "error" return type has no value to go with the error
+		return ""
 	default:
 		zeroValue = "nil"
 	}
+	return zeroValue + ", "
+}
+
+func addValidationChecks(entity schema.Entity, sqlQuery string, returnType, argVar, indent string) string {
+	return addValidationChecksIndexed(entity, sqlQuery, returnType, argVar, indent, "")
+}
+
+func addValidationChecksIndexed(entity schema.Entity, sqlQuery string, returnType, argVar, indent, indexVar string) string {
+	var sb strings.Builder
+
+	returnPrefix := errorReturnPrefix(returnType)
 
 	itemPrefix, itemArgs := "", ""
 	if indexVar != "" {
@@ -186,9 +406,10 @@ func addValidationChecksIndexed(entity schema.Entity, sqlQuery string, returnTyp
 		itemArgs = ", " + indexVar
 	}
 
-	// json text is checked before it reaches the db
+	// json text is checked before it reaches the db, JSONOf fields are
+	// marshalled from their Go type instead, see marshalTypedJSON
 	for _, field := range entity.Fields {
-		if field.Type != schema.FieldTypeJSON || field.IsVirtual() {
+		if field.Type != schema.FieldTypeJSON || field.IsTypedJSON() || field.IsVirtual() {
 			continue
 		}
 		if (field.Permissions & permissions.ApiWrite) == 0 {
@@ -205,7 +426,7 @@ func addValidationChecksIndexed(entity schema.Entity, sqlQuery string, returnTyp
 			cond = fmt.Sprintf("%s != nil && !json.Valid([]byte(*%s))", ref, ref)
 		}
 		sb.WriteString(fmt.Sprintf("%sif %s {\n", indent, cond))
-		sb.WriteString(fmt.Sprintf("%s\treturn %s, fmt.Errorf(\"Failed %s: %sinvalid json for '%s' in field '%s'\"%s)\n", indent, zeroValue, sqlQuery, itemPrefix, entity.Name, field.Name, itemArgs))
+		sb.WriteString(fmt.Sprintf("%s\treturn %sfmt.Errorf(\"Failed %s: %sinvalid json for '%s' in field '%s'\"%s)\n", indent, returnPrefix, sqlQuery, itemPrefix, entity.Name, field.Name, itemArgs))
 		sb.WriteString(fmt.Sprintf("%s}\n", indent))
 	}
 
@@ -221,7 +442,172 @@ func addValidationChecksIndexed(entity schema.Entity, sqlQuery string, returnTyp
 		validateName := field.Validate().(string)
 		fieldName := toDBFieldName(field)
 		sb.WriteString(fmt.Sprintf("%sif !%s(%s.%s) {\n", indent, validateName, argVar, fieldName))
-		sb.WriteString(fmt.Sprintf("%s\treturn %s, fmt.Errorf(\"Failed %s: %sincorrect value for '%s' in field '%s', validated by '%s'\"%s)\n", indent, zeroValue, sqlQuery, itemPrefix, entity.Name, field.Name, validateName, itemArgs))
+		sb.WriteString(fmt.Sprintf("%s\treturn %sfmt.Errorf(\"Failed %s: %sincorrect value for '%s' in field '%s', validated by '%s'\"%s)\n", indent, returnPrefix, sqlQuery, itemPrefix, entity.Name, field.Name, validateName, itemArgs))
+		sb.WriteString(fmt.Sprintf("%s}\n", indent))
+	}
+
+	// a latlng outside the globe would never match a filter.Near
+	for _, field := range entity.Fields {
+		if field.Type != schema.FieldTypeLatLng || field.IsVirtual() {
+			continue
+		}
+		if (field.Permissions & permissions.ApiWrite) == 0 {
+			continue
+		}
+		// update skips immutable fields, so they are not in the params struct
+		if sqlQuery == "update" && field.Immutable {
+			continue
+		}
+
+		check := "CheckLatLng"
+		if isPointerParam(field, sqlQuery) {
+			check = "CheckLatLngPtr"
+		}
+		sb.WriteString(fmt.Sprintf("%sif err := %s(%s.%s); err != nil {\n", indent, check, argVar, toDBFieldName(field)))
+		sb.WriteString(fmt.Sprintf("%s\treturn %sfmt.Errorf(\"Failed %s: %sout of range for '%s' in field '%s': %%w\"%s, err)\n", indent, returnPrefix, sqlQuery, itemPrefix, entity.Name, field.Name, itemArgs))
+		sb.WriteString(fmt.Sprintf("%s}\n", indent))
+	}
+
+	// decimals must fit their column, postgres and mysql would round the extra scale
+	for _, field := range entity.Fields {
+		if field.Type != schema.FieldTypeDecimal || field.IsVirtual() {
+			continue
+		}
+		if (field.Permissions & permissions.ApiWrite) == 0 {
+			continue
+		}
+		// update skips immutable fields, so they are not in the params struct
+		if sqlQuery == "update" && field.Immutable {
+			continue
+		}
+
+		check := "CheckDecimal"
+		if isPointerParam(field, sqlQuery) {
+			check = "CheckDecimalPtr"
+		}
+		sb.WriteString(fmt.Sprintf("%sif err := %s(%s.%s, %d, %d); err != nil {\n", indent, check, argVar, toDBFieldName(field), field.Precision, field.Scale))
+		sb.WriteString(fmt.Sprintf("%s\treturn %sfmt.Errorf(\"Failed %s: %sout of range for '%s' in field '%s': %%w\"%s, err)\n", indent, returnPrefix, sqlQuery, itemPrefix, entity.Name, field.Name, itemArgs))
+		sb.WriteString(fmt.Sprintf("%s}\n", indent))
+	}
+	return sb.String()
+}
+
+// convertersFail reports an entity whose model converters parse text and
+// return an error: JSONOf columns, Strings/Ints stored as json text and
+// decimals. Lists count on postgres too, so the signatures don't change with
+// the dialect.
+func convertersFail(entity schema.Entity) bool {
+	for _, field := range entity.Fields {
+		if (field.IsTypedJSON() || field.IsList() || field.Type == schema.FieldTypeDecimal) && !field.IsVirtual() {
+			return true
+		}
+	}
+	return false
+}
+
+// fromSQLReturn returns the converted row, XFromSQL has its own error when
+// the entity has JSONOf or list fields
+func fromSQLReturn(entity schema.Entity, dbRef, indent string) string {
+	if convertersFail(entity) {
+		return fmt.Sprintf("%sreturn %sFromSQL(%s)\n", indent, entity.Name, dbRef)
+	}
+	return fmt.Sprintf("%sreturn %sFromSQL(%s), nil\n", indent, entity.Name, dbRef)
+}
+
+// protoConverterFails reports an entity whose ToProto converts json fields
+// into google.protobuf.Struct/Value, which fails for text or a JSONOf value
+// that doesn't fit, and so returns an error
+func protoConverterFails(entity schema.Entity) bool {
+	for _, field := range entity.Fields {
+		if isProtoJSONField(field) {
+			return true
+		}
+	}
+	return false
+}
+
+// isProtoJSONField reports a json field ToProto converts into a
+// google.protobuf.Struct/Value
+func isProtoJSONField(field schema.Field) bool {
+	canRead := (field.Permissions & permissions.ApiRead) != 0
+	return canRead && field.Type == schema.FieldTypeJSON && field.ProtoJSON != schema.ProtoJSONText && !field.IsVirtual()
+}
+
+// protoJSONVar names the local that holds the converted Struct/Value of a
+// json field
+func protoJSONVar(field schema.Field) string {
+	return toUnexportedName(toDBFieldName(field)) + "Proto"
+}
+
+// protoJSONConverter names the convert.go func that turns a json field into
+// its google.protobuf.Struct/Value
+func protoJSONConverter(field schema.Field) string {
+	target := "Struct"
+	if field.ProtoJSON == schema.ProtoJSONValue {
+		target = "Value"
+	}
+	switch {
+	case field.IsTypedJSON():
+		return "JSONTo" + target
+	case field.Optional:
+		return "TextPtrTo" + target
+	default:
+		return "TextTo" + target
+	}
+}
+
+// storesListAsJSON reports a dialect without array columns, Strings/Ints are
+// json text there and the wrapper encodes them
+func storesListAsJSON(sqlDialect schema.SQLDialect) bool {
+	return sqlDialect != schema.PostgreSQL
+}
+
+// listVar names the local that holds a decoded Strings/Ints field
+func listVar(field schema.Field) string {
+	return toUnexportedName(toDBFieldName(field)) + "List"
+}
+
+// decimalVar names the local that holds a parsed decimal field
+func decimalVar(field schema.Field) string {
+	return toUnexportedName(toDBFieldName(field)) + "Decimal"
+}
+
+// typedJSONVar names the local that holds the json text of a JSONOf field
+func typedJSONVar(field schema.Field) string {
+	return toUnexportedName(toDBFieldName(field)) + "JSON"
+}
+
+// marshalTypedJSON encodes the JSONOf fields of argVar into typedJSONVar
+// locals. fail turns the error expression into the statement that bails out.
+func marshalTypedJSON(entity schema.Entity, sqlQuery, argVar, indent, indexVar string, fail func(errExpr string) string) string {
+	var sb strings.Builder
+
+	itemPrefix, itemArgs := "", ""
+	if indexVar != "" {
+		itemPrefix = "item %d: "
+		itemArgs = ", " + indexVar
+	}
+
+	for _, field := range entity.Fields {
+		if !field.IsTypedJSON() || field.IsVirtual() {
+			continue
+		}
+		if (field.Permissions & permissions.ApiWrite) == 0 {
+			continue
+		}
+		// update skips immutable fields, so they are not in the params struct
+		if sqlQuery == "update" && field.Immutable {
+			continue
+		}
+
+		marshal := "MarshalJSONText"
+		if isPointerParam(field, sqlQuery) {
+			marshal = "MarshalJSONTextPtr"
+		}
+		errExpr := fmt.Sprintf("fmt.Errorf(\"Failed %s: %sinvalid json for '%s' in field '%s': %%w\"%s, jsonErr)", sqlQuery, itemPrefix, entity.Name, field.Name, itemArgs)
+		sb.WriteString(fmt.Sprintf("%s%s, jsonErr := %s(%s.%s)\n", indent, typedJSONVar(field), marshal, argVar, toDBFieldName(field)))
+		sb.WriteString(fmt.Sprintf("%sif jsonErr != nil {\n", indent))
+		sb.WriteString(fmt.Sprintf("%s\t%s\n", indent, fail(errExpr)))
 		sb.WriteString(fmt.Sprintf("%s}\n", indent))
 	}
 	return sb.String()
@@ -401,6 +787,48 @@ func isIdentByte(c byte) bool {
 }
 
 func sqlToGo(field schema.Field, pbFieldRef string, sqlDialect schema.SQLDialect) string {
+	if field.IsList() && storesListAsJSON(sqlDialect) {
+		if !field.Optional {
+			return fmt.Sprintf("ListToText(%s)", pbFieldRef)
+		}
+		if sqlDialect == schema.MySQL {
+			return fmt.Sprintf("PtrToNullString(ListToTextPtr(%s))", pbFieldRef)
+		}
+		return fmt.Sprintf("ListToTextPtr(%s)", pbFieldRef)
+	}
+
+	// sqlc keeps NUMERIC/DECIMAL as a string, and sqlite stores the same text
+	if field.Type == schema.FieldTypeDecimal {
+		if !field.Optional {
+			return fmt.Sprintf("DecimalToText(%s, %d)", pbFieldRef, field.Scale)
+		}
+		if sqlDialect == schema.SQLite {
+			return fmt.Sprintf("DecimalPtrToText(%s, %d)", pbFieldRef, field.Scale)
+		}
+		return fmt.Sprintf("PtrToNullString(DecimalPtrToText(%s, %d))", pbFieldRef, field.Scale)
+	}
+
+	if field.Type == schema.FieldTypeDate {
+		if !field.Optional {
+			return fmt.Sprintf("DateToSQL(%s)", pbFieldRef)
+		}
+		if sqlDialect == schema.SQLite {
+			return fmt.Sprintf("DatePtrToSQL(%s)", pbFieldRef)
+		}
+		return fmt.Sprintf("PtrToNullTime(DatePtrToSQL(%s))", pbFieldRef)
+	}
+
+	// a duration is a BIGINT of nanoseconds, sqlc binds it as an int64
+	if field.Type == schema.FieldTypeDuration {
+		if !field.Optional {
+			return fmt.Sprintf("int64(%s)", pbFieldRef)
+		}
+		if sqlDialect == schema.SQLite {
+			return fmt.Sprintf("DurationPtrToInt64(%s)", pbFieldRef)
+		}
+		return fmt.Sprintf("PtrToNullInt64(DurationPtrToInt64(%s))", pbFieldRef)
+	}
+
 	if sqlDialect == schema.SQLite {
 		if field.Type == schema.FieldTypeBool {
 			if field.Optional {
@@ -433,9 +861,17 @@ func sqlToGo(field schema.Field, pbFieldRef string, sqlDialect schema.SQLDialect
 		return fmt.Sprintf("PtrToNullBytes(%s)", pbFieldRef)
 	}
 
+	// JSONB/JSON columns are json.RawMessage in sqlc, see util.EnsureJSONOverrides
+	if field.Type == schema.FieldTypeJSON && (sqlDialect == schema.PostgreSQL || sqlDialect == schema.MySQL) {
+		if field.Optional {
+			return fmt.Sprintf("PtrToRawJSON(%s)", pbFieldRef)
+		}
+		return fmt.Sprintf("json.RawMessage(%s)", pbFieldRef)
+	}
+
 	if field.Optional && (sqlDialect == schema.PostgreSQL || sqlDialect == schema.MySQL) {
 		switch field.Type {
-		case schema.FieldTypeString, schema.FieldTypeJSON:
+		case schema.FieldTypeString:
 			return fmt.Sprintf("PtrToNullString(%s)", pbFieldRef)
 		case schema.FieldTypeInt:
 			return fmt.Sprintf("PtrToNullInt32(%s)", pbFieldRef)
@@ -445,6 +881,8 @@ func sqlToGo(field schema.Field, pbFieldRef string, sqlDialect schema.SQLDialect
 			return fmt.Sprintf("PtrToNullFloat64(%s)", pbFieldRef)
 		case schema.FieldTypeBool:
 			return fmt.Sprintf("PtrToNullBool(%s)", pbFieldRef)
+		case schema.FieldTypeTime:
+			return fmt.Sprintf("PtrToNullTime(%s)", pbFieldRef)
 		}
 	}
 
@@ -453,6 +891,25 @@ func sqlToGo(field schema.Field, pbFieldRef string, sqlDialect schema.SQLDialect
 
 // goFromSQL converts from SQL types to Go types (inverse of sqlToGo)
 func goFromSQL(field schema.Field, dbFieldRef string, sqlDialect schema.SQLDialect) string {
+	// a latlng joins its two columns, dbFieldRef+Lat and dbFieldRef+Lng
+	if field.Type == schema.FieldTypeLatLng {
+		if !field.Optional {
+			return fmt.Sprintf("LatLng{Lat: %sLat, Lng: %sLng}", dbFieldRef, dbFieldRef)
+		}
+		column := schema.Field{Type: schema.FieldTypeFloat, Optional: true}
+		return fmt.Sprintf("LatLngFromPtrs(%s, %s)", goFromSQL(column, dbFieldRef+"Lat", sqlDialect), goFromSQL(column, dbFieldRef+"Lng", sqlDialect))
+	}
+
+	if field.Type == schema.FieldTypeDuration {
+		if !field.Optional {
+			return fmt.Sprintf("time.Duration(%s)", dbFieldRef)
+		}
+		if sqlDialect == schema.SQLite {
+			return fmt.Sprintf("Int64PtrToDuration(%s)", dbFieldRef)
+		}
+		return fmt.Sprintf("Int64PtrToDuration(NullInt64ToPtr(%s))", dbFieldRef)
+	}
+
 	if sqlDialect == schema.SQLite {
 		if field.Type == schema.FieldTypeBool {
 			if field.Optional {
@@ -475,9 +932,16 @@ func goFromSQL(field schema.Field, dbFieldRef string, sqlDialect schema.SQLDiale
 		return fmt.Sprintf("NullBytesToPtr(%s)", dbFieldRef)
 	}
 
+	if field.Type == schema.FieldTypeJSON && (sqlDialect == schema.PostgreSQL || sqlDialect == schema.MySQL) {
+		if field.Optional {
+			return fmt.Sprintf("RawJSONToPtr(%s)", dbFieldRef)
+		}
+		return fmt.Sprintf("string(%s)", dbFieldRef)
+	}
+
 	if field.Optional && (sqlDialect == schema.PostgreSQL || sqlDialect == schema.MySQL) {
 		switch field.Type {
-		case schema.FieldTypeString, schema.FieldTypeJSON:
+		case schema.FieldTypeString:
 			return fmt.Sprintf("NullStringToPtr(%s)", dbFieldRef)
 		case schema.FieldTypeInt:
 			return fmt.Sprintf("NullInt32ToPtr(%s)", dbFieldRef)
@@ -487,14 +951,41 @@ func goFromSQL(field schema.Field, dbFieldRef string, sqlDialect schema.SQLDiale
 			return fmt.Sprintf("NullFloat64ToPtr(%s)", dbFieldRef)
 		case schema.FieldTypeBool:
 			return fmt.Sprintf("NullBoolToPtr(%s)", dbFieldRef)
+		case schema.FieldTypeTime, schema.FieldTypeDate:
+			return fmt.Sprintf("NullTimeToPtr(%s)", dbFieldRef)
 		}
 	}
 
 	return dbFieldRef
 }
 
+// durationLiteral writes d the way it would be typed, e.g. 90 * time.Second
+func durationLiteral(d time.Duration) string {
+	units := []struct {
+		name string
+		unit time.Duration
+	}{
+		{"time.Hour", time.Hour},
+		{"time.Minute", time.Minute},
+		{"time.Second", time.Second},
+		{"time.Millisecond", time.Millisecond},
+		{"time.Microsecond", time.Microsecond},
+	}
+	for _, u := range units {
+		if d != 0 && d%u.unit == 0 {
+			if d == u.unit {
+				return u.name
+			}
+			return fmt.Sprintf("%d * %s", d/u.unit, u.name)
+		}
+	}
+	return fmt.Sprintf("time.Duration(%d)", int64(d))
+}
+
 func formatDefaultValue(field schema.Field) string {
 	switch v := field.DefaultValue.(type) {
+	case time.Duration:
+		return durationLiteral(v)
 	case float64:
 		return strconv.FormatFloat(v, 'f', -1, 64)
 	case float32:
@@ -508,6 +999,10 @@ func formatDefaultValue(field schema.Field) string {
 	case bool:
 		return fmt.Sprintf("%v", v)
 	case string:
+		if field.Type == schema.FieldTypeDecimal {
+			// the parser checked it parses and fits, see validateDecimalFields
+			return fmt.Sprintf("decimal.RequireFromString(%q)", v)
+		}
 		return fmt.Sprintf("%q", v)
 	default:
 		return fmt.Sprintf("%v", v)
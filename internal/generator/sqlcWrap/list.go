@@ -31,10 +31,80 @@ func (ctx *generationContext) generateListQuery(funcDecl *ast.FuncDecl, entity s
 
 	sb.WriteString(fmt.Sprintf("\tresult := make([]*%s, len(dbResults))\n", entity.Name))
 	sb.WriteString("\tfor i := range dbResults {\n")
-	sb.WriteString(fmt.Sprintf("\t\tresult[i] = %sFromSQL(&dbResults[i])\n", entity.Name))
+	if convertersFail(entity) {
+		sb.WriteString(fmt.Sprintf("\t\tresult[i], err = %sFromSQL(&dbResults[i])\n", entity.Name))
+		sb.WriteString("\t\tif err != nil {\n")
+		sb.WriteString("\t\t\treturn nil, err\n")
+		sb.WriteString("\t\t}\n")
+	} else {
+		sb.WriteString(fmt.Sprintf("\t\tresult[i] = %sFromSQL(&dbResults[i])\n", entity.Name))
+	}
 	sb.WriteString("\t}\n")
+	sb.WriteString(ctx.sortNear(funcDecl, entity))
 	sb.WriteString("\treturn result, nil\n")
 	sb.WriteString("}\n\n")
 
 	return sb.String()
 }
+
+// sortNear orders the rows of a filter.Near query nearest first, the sql
+// only filters them by distance
+func (ctx *generationContext) sortNear(funcDecl *ast.FuncDecl, entity schema.Entity) string {
+	argVar := ""
+	for _, param := range funcDecl.Type.Params.List {
+		if _, ok := ctx.filterParamsStructs[formatType(param.Type)]; ok && len(param.Names) > 0 {
+			argVar = param.Names[0].Name
+		}
+	}
+
+	for _, filter := range ctx.queryFilters(funcDecl.Name.Name) {
+		if filter.Type != schema.QueryFilterNear || argVar == "" {
+			continue
+		}
+		field, _ := entity.GetFieldByName(filter.Field)
+		modelRef := "m." + toDBFieldName(field)
+		// only rows with a point match the filter
+		if field.Optional {
+			modelRef = "*" + modelRef
+		}
+		return fmt.Sprintf("\tSortNear(result, %s.%s, func(m *%s) LatLng { return %s })\n", argVar, snakeToCamelCase(filter.Field), entity.Name, modelRef)
+	}
+	return ""
+}
+
+// generateListStreamQuery wraps a streamed list query: rows come from the
+// access file's Seq method and are converted one at a time.
+func (ctx *generationContext) generateListStreamQuery(funcDecl *ast.FuncDecl, entity schema.Entity) string {
+	var sb strings.Builder
+	inputPkg := ctx.inputPackageName
+
+	params, args, prelude := ctx.wrapFilterParams(funcDecl, entity)
+
+	receiverType := formatType(funcDecl.Recv.List[0].Type)
+	sb.WriteString(fmt.Sprintf("func (q %s) %s(ctx context.Context%s) iter.Seq2[*%s, error] {\n", receiverType, funcDecl.Name.Name, params, entity.Name))
+	sb.WriteString(prelude)
+
+	sb.WriteString(fmt.Sprintf("\treturn func(yield func(*%s, error) bool) {\n", entity.Name))
+	sb.WriteString(fmt.Sprintf("\t\tfor dbResult, err := range (*%s.Queries)(q).%s%s(ctx%s) {\n", inputPkg, funcDecl.Name.Name, streamMethodSuffix, args))
+	sb.WriteString("\t\t\tif err != nil {\n")
+	sb.WriteString("\t\t\t\tyield(nil, err)\n")
+	sb.WriteString("\t\t\t\treturn\n")
+	sb.WriteString("\t\t\t}\n")
+	if convertersFail(entity) {
+		sb.WriteString(fmt.Sprintf("\t\t\titem, err := %sFromSQL(&dbResult)\n", entity.Name))
+		sb.WriteString("\t\t\tif err != nil {\n")
+		sb.WriteString("\t\t\t\tyield(nil, err)\n")
+		sb.WriteString("\t\t\t\treturn\n")
+		sb.WriteString("\t\t\t}\n")
+		sb.WriteString("\t\t\tif !yield(item, nil) {\n")
+	} else {
+		sb.WriteString(fmt.Sprintf("\t\t\tif !yield(%sFromSQL(&dbResult), nil) {\n", entity.Name))
+	}
+	sb.WriteString("\t\t\t\treturn\n")
+	sb.WriteString("\t\t\t}\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("}\n\n")
+
+	return sb.String()
+}
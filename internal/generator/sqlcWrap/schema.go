@@ -0,0 +1,88 @@
+package sqlcwrap
+
+import (
+	"fmt"
+	"go/format"
+	"strings"
+
+	"github.com/guntisdev/entlite/internal/schema"
+	"github.com/guntisdev/entlite/pkg/entlite/migrate"
+)
+
+// SchemaFileName holds the tables the wrapper package expects and CheckSchema
+const SchemaFileName = "check_schema.go"
+
+var dialectConstants = map[schema.SQLDialect]string{
+	schema.PostgreSQL: "migrate.PostgreSQL",
+	schema.MySQL:      "migrate.MySQL",
+	schema.SQLite:     "migrate.SQLite",
+}
+
+// GenerateSchemaFile emits the tables schema.sql creates and a CheckSchema
+// that compares them to a live database, so a service can refuse to start
+// on a schema someone changed by hand.
+func GenerateSchemaFile(packageName string, tables []migrate.Table, sqlDialect schema.SQLDialect) (string, error) {
+	var content strings.Builder
+
+	fmt.Fprintf(&content, "package %s\n\n", packageName)
+	content.WriteString("import (\n")
+	content.WriteString("\t\"context\"\n\n")
+	content.WriteString("\t\"github.com/guntisdev/entlite/pkg/entlite/migrate\"\n")
+	content.WriteString(")\n\n")
+
+	content.WriteString("// Schema is the tables schema.sql creates\n")
+	content.WriteString("var Schema = []migrate.Table{\n")
+	for _, table := range tables {
+		fmt.Fprintf(&content, "{\nName: %q,\nColumns: []migrate.Column{\n", table.Name)
+		for _, column := range table.Columns {
+			fmt.Fprintf(&content, "{Name: %q, Type: %q", column.Name, column.Type)
+			if column.NotNull {
+				content.WriteString(", NotNull: true")
+			}
+			if column.Unique {
+				content.WriteString(", Unique: true")
+			}
+			content.WriteString("},\n")
+		}
+		content.WriteString("},\n")
+		fmt.Fprintf(&content, "PrimaryKey: %s,\n", stringSlice(table.PrimaryKey))
+		if len(table.Indexes) > 0 {
+			content.WriteString("Indexes: []migrate.Index{\n")
+			for _, idx := range table.Indexes {
+				fmt.Fprintf(&content, "{Name: %q", idx.Name)
+				if idx.Unique {
+					content.WriteString(", Unique: true")
+				}
+				fmt.Fprintf(&content, ", Columns: %s", stringSlice(idx.Columns))
+				if idx.Partial {
+					content.WriteString(", Partial: true")
+				}
+				content.WriteString("},\n")
+			}
+			content.WriteString("},\n")
+		}
+		content.WriteString("},\n")
+	}
+	content.WriteString("}\n\n")
+
+	content.WriteString("// CheckSchema reports tables, columns and indexes of the database that differ\n")
+	content.WriteString("// from Schema, the error is a *migrate.DriftError listing them\n")
+	content.WriteString("func CheckSchema(ctx context.Context, db DBTX) error {\n")
+	fmt.Fprintf(&content, "\treturn migrate.CheckSchema(ctx, db, %s, Schema)\n", dialectConstants[sqlDialect])
+	content.WriteString("}\n")
+
+	formatted, err := format.Source([]byte(content.String()))
+	if err != nil {
+		return "", fmt.Errorf("failed to format %s: %w", SchemaFileName, err)
+	}
+	return string(formatted), nil
+}
+
+// stringSlice is a []string literal
+func stringSlice(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = fmt.Sprintf("%q", value)
+	}
+	return "[]string{" + strings.Join(quoted, ", ") + "}"
+}
@@ -0,0 +1,218 @@
+package sqlcwrap
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/guntisdev/entlite/internal/schema"
+	"github.com/guntisdev/entlite/internal/util"
+)
+
+// streamMethodSuffix names the row-by-row twin entlite adds next to a sqlc
+// :many method, e.g. ListReadingBySensorIdSeq.
+const streamMethodSuffix = "Seq"
+
+// streamQueryNames lists the sqlc method names of list queries marked Stream().
+func streamQueryNames(entities []schema.Entity) map[string]bool {
+	names := make(map[string]bool)
+	for _, entity := range entities {
+		for _, query := range entity.Queries {
+			if query.Stream {
+				names[util.GenQueryName(query, entity.Name)] = true
+			}
+		}
+	}
+	return names
+}
+
+// generateStreamMethods reads sqlc's generated query files in inputDir and, for
+// every streamed query, emits a method that yields rows as they are scanned
+// instead of collecting them into a slice. The query text and scan targets are
+// copied from sqlc's own method, so both stay in step with the schema.
+func generateStreamMethods(inputDir string, entities []schema.Entity) (string, []*ast.ImportSpec, error) {
+	names := streamQueryNames(entities)
+	if len(names) == 0 {
+		return "", nil, nil
+	}
+
+	files, err := os.ReadDir(inputDir)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var sb strings.Builder
+	var imports []*ast.ImportSpec
+	found := make(map[string]bool)
+	for _, file := range files {
+		if file.IsDir() || detectFileType(file.Name()) != FileTypeQuery {
+			continue
+		}
+
+		fset := token.NewFileSet()
+		node, err := parser.ParseFile(fset, filepath.Join(inputDir, file.Name()), nil, 0)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to parse %s: %w", file.Name(), err)
+		}
+		imports = append(imports, node.Imports...)
+
+		for _, decl := range node.Decls {
+			funcDecl, ok := decl.(*ast.FuncDecl)
+			if !ok || funcDecl.Recv == nil || !names[funcDecl.Name.Name] {
+				continue
+			}
+			method, err := generateStreamMethod(fset, funcDecl)
+			if err != nil {
+				return "", nil, err
+			}
+			sb.WriteString(method)
+			found[funcDecl.Name.Name] = true
+		}
+	}
+
+	missing := []string{}
+	for name := range names {
+		if !found[name] {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return "", nil, fmt.Errorf("streamed queries not found in sqlc output: %s", strings.Join(missing, ", "))
+	}
+
+	return sb.String(), imports, nil
+}
+
+func generateStreamMethod(fset *token.FileSet, funcDecl *ast.FuncDecl) (string, error) {
+	name := funcDecl.Name.Name
+
+	var rowType ast.Expr
+	if results := funcDecl.Type.Results; results != nil && len(results.List) == 2 {
+		if arrayType, ok := results.List[0].Type.(*ast.ArrayType); ok {
+			rowType = arrayType.Elt
+		}
+	}
+
+	var queryCall, scanCall *ast.CallExpr
+	ast.Inspect(funcDecl.Body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		if sel, ok := call.Fun.(*ast.SelectorExpr); ok {
+			switch sel.Sel.Name {
+			case "QueryContext":
+				queryCall = call
+			case "Scan":
+				scanCall = call
+			}
+		}
+		return true
+	})
+
+	if rowType == nil || queryCall == nil || scanCall == nil {
+		return "", fmt.Errorf("stream query %s: expected a sqlc :many method using database/sql", name)
+	}
+
+	print := func(node any) (string, error) {
+		var buf bytes.Buffer
+		if err := printer.Fprint(&buf, fset, node); err != nil {
+			return "", fmt.Errorf("stream query %s: %w", name, err)
+		}
+		return buf.String(), nil
+	}
+
+	var paramList []string
+	for _, param := range funcDecl.Type.Params.List {
+		typ, err := print(param.Type)
+		if err != nil {
+			return "", err
+		}
+		for _, paramName := range param.Names {
+			paramList = append(paramList, paramName.Name+" "+typ)
+		}
+	}
+	params := "(" + strings.Join(paramList, ", ") + ")"
+	row, err := print(rowType)
+	if err != nil {
+		return "", err
+	}
+	query, err := print(queryCall)
+	if err != nil {
+		return "", err
+	}
+	scan, err := print(scanCall)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("\n// %s%s yields the rows of %s one by one as they are scanned.\n", name, streamMethodSuffix, name))
+	sb.WriteString(fmt.Sprintf("func (q *Queries) %s%s%s iter.Seq2[%s, error] {\n", name, streamMethodSuffix, params, row))
+	sb.WriteString(fmt.Sprintf("\treturn func(yield func(%s, error) bool) {\n", row))
+	sb.WriteString(fmt.Sprintf("\t\trows, err := %s\n", query))
+	sb.WriteString("\t\tif err != nil {\n")
+	sb.WriteString(fmt.Sprintf("\t\t\tyield(%s{}, err)\n", row))
+	sb.WriteString("\t\t\treturn\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t\tdefer rows.Close()\n")
+	sb.WriteString("\t\tfor rows.Next() {\n")
+	sb.WriteString(fmt.Sprintf("\t\t\tvar i %s\n", row))
+	sb.WriteString(fmt.Sprintf("\t\t\tif err := %s; err != nil {\n", scan))
+	sb.WriteString(fmt.Sprintf("\t\t\t\tyield(%s{}, err)\n", row))
+	sb.WriteString("\t\t\t\treturn\n")
+	sb.WriteString("\t\t\t}\n")
+	sb.WriteString("\t\t\tif !yield(i, nil) {\n")
+	sb.WriteString("\t\t\t\treturn\n")
+	sb.WriteString("\t\t\t}\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t\tif err := rows.Err(); err != nil {\n")
+	sb.WriteString(fmt.Sprintf("\t\t\tyield(%s{}, err)\n", row))
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("}\n")
+
+	return sb.String(), nil
+}
+
+// streamImports picks the imports the stream methods reference, out of the
+// ones sqlc used for the methods they were copied from.
+func streamImports(body string, imports []*ast.ImportSpec) []string {
+	seen := map[string]bool{}
+	lines := []string{`"context"`, `"iter"`}
+	for _, imp := range imports {
+		path := strings.Trim(imp.Path.Value, `"`)
+		name := filepath.Base(path)
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		if path == "context" || seen[path] || !usesPackage(body, name) {
+			continue
+		}
+		seen[path] = true
+		if imp.Name != nil {
+			lines = append(lines, fmt.Sprintf("%s %q", name, path))
+		} else {
+			lines = append(lines, fmt.Sprintf("%q", path))
+		}
+	}
+	return lines
+}
+
+// formatAccessFile gofmts the access file, it is read next to sqlc's own output.
+func formatAccessFile(content string) (string, error) {
+	formatted, err := format.Source([]byte(content))
+	if err != nil {
+		return "", fmt.Errorf("failed to format %s: %w", EntliteAccessFileName, err)
+	}
+	return string(formatted), nil
+}
@@ -18,6 +18,11 @@ func generateUpdateStruct(structName string, structType *ast.StructType, entity
 		if len(astField.Names) > 0 {
 			fieldName := astField.Names[0].Name
 			fieldPtr := getFieldByName(entity, fieldName)
+			// a latlng takes the place of its two columns
+			latLng := latLngByLatColumn(entity, fieldName)
+			if latLng != nil {
+				fieldPtr = latLng
+			}
 			if fieldPtr == nil {
 				continue
 			}
@@ -34,6 +39,10 @@ func generateUpdateStruct(structName string, structType *ast.StructType, entity
 				field.Optional = true
 			}
 
+			if latLng != nil {
+				sb.WriteString(fmt.Sprintf("\t%s %s `json:\"%s\"`\n", toDBFieldName(field), fieldToGoType(field), field.Name))
+				continue
+			}
 			sb.WriteString(fmt.Sprintf("\t%s %s", fieldName, fieldToGoType(field)))
 			if astField.Tag != nil {
 				sb.WriteString(fmt.Sprintf(" %s", astField.Tag.Value))
@@ -56,6 +65,9 @@ func generateUpdateQuery(funcDecl *ast.FuncDecl, entity schema.Entity, inputPkg
 
 	sb.WriteString(" {\n")
 	sb.WriteString(addValidationChecks(entity, "update", "nil", "arg", "\t"))
+	sb.WriteString(marshalTypedJSON(entity, "update", "arg", "\t", "", func(errExpr string) string {
+		return "return nil, " + errExpr
+	}))
 	sb.WriteString(fmt.Sprintf("\tinternalArg := %s.%sParams{\n", inputPkg, funcDecl.Name.Name))
 
 	defaultFuncFields := make(map[string]schema.Field)
@@ -85,6 +97,15 @@ func generateUpdateQuery(funcDecl *ast.FuncDecl, entity schema.Entity, inputPkg
 		if !canApiRead {
 			field.Optional = true
 		}
+		argRef := "arg." + exportedName
+		// JSONOf values were marshalled into a local, see marshalTypedJSON
+		if field.IsTypedJSON() {
+			argRef = typedJSONVar(field)
+		}
+		if field.Type == schema.FieldTypeLatLng {
+			writeLatLngParams(&sb, field, argRef, "\t\t", sqlDialect)
+			continue
+		}
 		pointerStr := ""
 		// Only MySQL's PtrBytesToNullString consumes a pointer ref (it strips the
 		// leading '*' back off); SQLite/Postgres take the *[]byte arg as-is.
@@ -95,7 +116,7 @@ func generateUpdateQuery(funcDecl *ast.FuncDecl, entity schema.Entity, inputPkg
 			funcName := field.DefaultFunc().(string)
 			if canApiWrite {
 				field.Optional = true
-				convertField := sqlToGo(field, fmt.Sprintf("%sarg.%s", pointerStr, exportedName), sqlDialect)
+				convertField := sqlToGo(field, pointerStr+argRef, sqlDialect)
 				sb.WriteString(fmt.Sprintf("\t\t%s: %s,\n", exportedName, convertField))
 			} else {
 				sb.WriteString(fmt.Sprintf("\t\t%s: %s(),\n", exportedName, funcName))
@@ -103,13 +124,13 @@ func generateUpdateQuery(funcDecl *ast.FuncDecl, entity schema.Entity, inputPkg
 		} else if _, hasDefaultVal := defaultValueFields[exportedName]; hasDefaultVal {
 			if canApiWrite {
 				field.Optional = true
-				convertField := sqlToGo(field, fmt.Sprintf("%sarg.%s", pointerStr, exportedName), sqlDialect)
+				convertField := sqlToGo(field, pointerStr+argRef, sqlDialect)
 				sb.WriteString(fmt.Sprintf("\t\t%s: %s,\n", exportedName, convertField))
 			} else {
 				continue
 			}
 		} else {
-			convertField := sqlToGo(field, fmt.Sprintf("arg.%s", exportedName), sqlDialect)
+			convertField := sqlToGo(field, argRef, sqlDialect)
 			sb.WriteString(fmt.Sprintf("\t\t%s: %s,\n", exportedName, convertField))
 		}
 	}
@@ -132,7 +153,7 @@ func generateUpdateQuery(funcDecl *ast.FuncDecl, entity schema.Entity, inputPkg
 		sb.WriteString("\t}\n")
 	}
 
-	sb.WriteString(fmt.Sprintf("\treturn %sFromSQL(&db%s), nil\n", entity.Name, entity.Name))
+	sb.WriteString(fromSQLReturn(entity, fmt.Sprintf("&db%s", entity.Name), "\t"))
 	sb.WriteString("}\n\n")
 
 	return sb.String()
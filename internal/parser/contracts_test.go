@@ -38,7 +38,7 @@ func parseContractEntity(t *testing.T, contractsMethod string) error {
 		t.Fatalf("failed to write entity file: %v", err)
 	}
 
-	_, err := ParseEntities([]DiscoveredEntity{{Name: "User", Path: path}})
+	_, err := ParseEntities([]DiscoveredEntity{{Name: "User", Path: path}}, nil)
 	return err
 }
 
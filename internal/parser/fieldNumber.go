@@ -9,9 +9,18 @@ import (
 	"github.com/guntisdev/entlite/pkg/entlite/permissions"
 )
 
-func addFieldNumbers(fields []schema.Field) []schema.Field {
+// addFieldNumbers gives every field without a ProtoField a number, the one
+// in the lock when it has one, else the smallest free. A renamed field takes
+// the number locked to its old name. Numbers of fields removed since they
+// were locked stay taken.
+func addFieldNumbers(fields []schema.Field, locked map[string]int) ([]schema.Field, error) {
 	var usedNumbers []int
 	hasIdField := false
+	lockedTo := make(map[int]string)
+	for name, number := range locked {
+		usedNumbers = append(usedNumbers, number)
+		lockedTo[number] = name
+	}
 
 	// checks if there is id field with protoField number
 	for i := range fields {
@@ -22,13 +31,19 @@ func addFieldNumbers(fields []schema.Field) []schema.Field {
 		}
 
 		if fields[i].ProtoField != 0 {
+			if err := checkLockedNumber(fields, fields[i], locked, lockedTo); err != nil {
+				return nil, err
+			}
 			usedNumbers = append(usedNumbers, fields[i].ProtoField)
 		}
 	}
 
 	if !hasIdField {
-		idNumber := getNextAvailable(usedNumbers)
-		usedNumbers = append(usedNumbers, idNumber)
+		idNumber, ok := locked["ID"]
+		if !ok {
+			idNumber = getNextAvailable(usedNumbers)
+			usedNumbers = append(usedNumbers, idNumber)
+		}
 
 		idField := schema.Field{
 			Name:        "ID",
@@ -44,14 +59,49 @@ func addFieldNumbers(fields []schema.Field) []schema.Field {
 
 	// add proto field numbers if they are missing
 	for i := range fields {
-		if fields[i].ProtoField == 0 {
-			num := getNextAvailable(usedNumbers)
-			usedNumbers = append(usedNumbers, num)
+		if fields[i].ProtoField != 0 {
+			continue
+		}
+		if num, ok := lockedNumber(locked, fields[i]); ok {
 			fields[i].ProtoField = num
+			continue
 		}
+		num := getNextAvailable(usedNumbers)
+		usedNumbers = append(usedNumbers, num)
+		fields[i].ProtoField = num
 	}
 
-	return fields
+	return fields, nil
+}
+
+// checkLockedNumber fails a ProtoField(n) that moves a locked field to another
+// number or takes the number locked to another field, deployed clients would
+// read the wrong field. The number of a removed field is reserved in proto.
+func checkLockedNumber(fields []schema.Field, field schema.Field, locked map[string]int, lockedTo map[int]string) error {
+	if number, ok := lockedNumber(locked, field); ok && number != field.ProtoField {
+		return fmt.Errorf("field %q is number %d in entlite.lock, ProtoField(%d) would change it", field.Name, number, field.ProtoField)
+	}
+	owner, ok := lockedTo[field.ProtoField]
+	if !ok || owner == field.Name || owner == field.RenamedFrom {
+		return nil
+	}
+	if slices.ContainsFunc(fields, func(f schema.Field) bool { return f.Name == owner }) {
+		return fmt.Errorf("field %q ProtoField(%d) is the number of field %q in entlite.lock", field.Name, field.ProtoField, owner)
+	}
+	return fmt.Errorf("field %q ProtoField(%d) is reserved, it was the number of removed field %q", field.Name, field.ProtoField, owner)
+}
+
+// lockedNumber is the number locked to a field, or to its old name until the
+// lock follows the rename
+func lockedNumber(locked map[string]int, field schema.Field) (int, bool) {
+	if number, ok := locked[field.Name]; ok {
+		return number, true
+	}
+	if field.RenamedFrom == "" {
+		return 0, false
+	}
+	number, ok := locked[field.RenamedFrom]
+	return number, ok
 }
 
 // if {1, 2, 4, 6] - it will find 3 as smallest available number
@@ -6,6 +6,7 @@ import (
 	"go/token"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/guntisdev/entlite/internal/schema"
 	"github.com/guntisdev/entlite/pkg/entlite/permissions"
@@ -54,7 +55,13 @@ func parseFieldExpression(expr ast.Expr) (schema.Field, error) {
 	for currentExpr != nil {
 		switch e := currentExpr.(type) {
 		case *ast.CallExpr:
-			if selExpr, ok := e.Fun.(*ast.SelectorExpr); ok {
+			if indexExpr, ok := e.Fun.(*ast.IndexExpr); ok {
+				// Generic constructor like field.JSONOf[logic.Settings]("settings")
+				if err := parseGenericFieldConstructor(&field, indexExpr, e.Args); err != nil {
+					return field, err
+				}
+				currentExpr = nil
+			} else if selExpr, ok := e.Fun.(*ast.SelectorExpr); ok {
 				methodName := selExpr.Sel.Name
 
 				switch methodName {
@@ -114,6 +121,56 @@ func parseFieldExpression(expr ast.Expr) (schema.Field, error) {
 							field.Name = unquote(lit.Value)
 						}
 					}
+				case "Strings":
+					field.Type = schema.FieldTypeStrings
+					if len(e.Args) > 0 {
+						if lit, ok := e.Args[0].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+							field.Name = unquote(lit.Value)
+						}
+					}
+				case "Ints":
+					field.Type = schema.FieldTypeInts
+					if len(e.Args) > 0 {
+						if lit, ok := e.Args[0].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+							field.Name = unquote(lit.Value)
+						}
+					}
+				case "Decimal":
+					field.Type = schema.FieldTypeDecimal
+					if len(e.Args) > 0 {
+						if lit, ok := e.Args[0].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+							field.Name = unquote(lit.Value)
+						}
+					}
+				case "Date":
+					field.Type = schema.FieldTypeDate
+					if len(e.Args) > 0 {
+						if lit, ok := e.Args[0].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+							field.Name = unquote(lit.Value)
+						}
+					}
+				case "Duration":
+					field.Type = schema.FieldTypeDuration
+					if len(e.Args) > 0 {
+						if lit, ok := e.Args[0].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+							field.Name = unquote(lit.Value)
+						}
+					}
+				case "LatLng":
+					field.Type = schema.FieldTypeLatLng
+					if len(e.Args) > 0 {
+						if lit, ok := e.Args[0].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+							field.Name = unquote(lit.Value)
+						}
+					}
+				case "Precision":
+					// left unset unless both are literals, validateDecimalFields reports it
+					if len(e.Args) == 2 {
+						precision, scale := parseIntArg(e.Args[0]), parseIntArg(e.Args[1])
+						if precision != nil && scale != nil {
+							field.Precision, field.Scale = *precision, *scale
+						}
+					}
 				case "ProtoField":
 					if len(e.Args) > 0 {
 						if lit, ok := e.Args[0].(*ast.BasicLit); ok && lit.Kind == token.INT {
@@ -128,10 +185,34 @@ func parseFieldExpression(expr ast.Expr) (schema.Field, error) {
 							field.Comment = unquote(lit.Value)
 						}
 					}
+				case "RenamedFrom":
+					if len(e.Args) > 0 {
+						if lit, ok := e.Args[0].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+							field.RenamedFrom = unquote(lit.Value)
+						}
+					}
+				case "Backfill":
+					if len(e.Args) > 0 {
+						fn, err := parseBackfillFunc(e.Args[0])
+						if err != nil {
+							return field, fmt.Errorf("field %q: %w", field.Name, err)
+						}
+						field.Backfill = fn
+					}
+				case "BackfillSQL":
+					if len(e.Args) > 0 {
+						if lit, ok := e.Args[0].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+							field.BackfillSQL = unquote(lit.Value)
+						}
+					}
 				case "Permissions":
 					if len(e.Args) > 0 {
 						field.Permissions = parsePermissionsExpression(e.Args[0])
 					}
+				case "ProtoStruct":
+					field.ProtoJSON = schema.ProtoJSONStruct
+				case "ProtoValue":
+					field.ProtoJSON = schema.ProtoJSONValue
 				case "Unique":
 					field.Unique = true
 				case "Immutable":
@@ -179,6 +260,37 @@ func parseFieldExpression(expr ast.Expr) (schema.Field, error) {
 	return field, nil
 }
 
+func parseGenericFieldConstructor(field *schema.Field, indexExpr *ast.IndexExpr, args []ast.Expr) error {
+	selExpr, ok := indexExpr.X.(*ast.SelectorExpr)
+	if !ok || selExpr.Sel.Name != "JSONOf" {
+		return nil
+	}
+
+	field.Type = schema.FieldTypeJSON
+	if len(args) > 0 {
+		if lit, ok := args[0].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+			field.Name = unquote(lit.Value)
+		}
+	}
+
+	// Only package types, so the wrapper can import them like validators
+	typeSel, ok := indexExpr.Index.(*ast.SelectorExpr)
+	if !ok {
+		return fmt.Errorf("field %q: JSONOf needs a type from another package, e.g. JSONOf[logic.Settings]", field.Name)
+	}
+	pkg, ok := typeSel.X.(*ast.Ident)
+	if !ok {
+		return fmt.Errorf("field %q: JSONOf needs a type from another package, e.g. JSONOf[logic.Settings]", field.Name)
+	}
+	field.GoType = fmt.Sprintf("%s.%s", pkg.Name, typeSel.Sel.Name)
+	// The chain is walked from the outside in, ProtoValue() is already set
+	if field.ProtoJSON == schema.ProtoJSONText {
+		field.ProtoJSON = schema.ProtoJSONStruct
+	}
+
+	return nil
+}
+
 func unquote(raw string) string {
 	if val, err := strconv.Unquote(raw); err == nil {
 		return val
@@ -208,10 +320,50 @@ func parseDefaultValue(expr ast.Expr) any {
 		if e.Name == "false" {
 			return false
 		}
+	case *ast.SelectorExpr, *ast.BinaryExpr:
+		// duration defaults like time.Minute or 90 * time.Second
+		if d, ok := parseDurationExpr(expr); ok {
+			return d
+		}
 	}
 	return nil
 }
 
+var durationUnits = map[string]time.Duration{
+	"Nanosecond":  time.Nanosecond,
+	"Microsecond": time.Microsecond,
+	"Millisecond": time.Millisecond,
+	"Second":      time.Second,
+	"Minute":      time.Minute,
+	"Hour":        time.Hour,
+}
+
+func parseDurationExpr(expr ast.Expr) (time.Duration, bool) {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		if e.Kind == token.INT {
+			if val := parseInt(e.Value); val != nil {
+				return time.Duration(*val), true
+			}
+		}
+	case *ast.SelectorExpr:
+		if ident, ok := e.X.(*ast.Ident); ok && ident.Name == "time" {
+			unit, ok := durationUnits[e.Sel.Name]
+			return unit, ok
+		}
+	case *ast.BinaryExpr:
+		if e.Op != token.MUL {
+			return 0, false
+		}
+		x, okX := parseDurationExpr(e.X)
+		y, okY := parseDurationExpr(e.Y)
+		return x * y, okX && okY
+	case *ast.ParenExpr:
+		return parseDurationExpr(e.X)
+	}
+	return 0, false
+}
+
 func parseInt(s string) *int {
 	var i int
 	if _, err := fmt.Sscanf(s, "%d", &i); err == nil {
@@ -220,6 +372,13 @@ func parseInt(s string) *int {
 	return nil
 }
 
+func parseIntArg(expr ast.Expr) *int {
+	if lit, ok := expr.(*ast.BasicLit); ok && lit.Kind == token.INT {
+		return parseInt(lit.Value)
+	}
+	return nil
+}
+
 func parseDefaultFuncValue(expr ast.Expr) (func() any, error) {
 	switch e := expr.(type) {
 	case *ast.SelectorExpr:
@@ -268,6 +427,17 @@ func parseValidateFuncValue(expr ast.Expr) (func() any, error) {
 	return nil, fmt.Errorf("validate must be a function reference")
 }
 
+// parseBackfillFunc reads the pkg.Function a Backfill names, the migrations
+// package imports it so it can't be local to the schema package
+func parseBackfillFunc(expr ast.Expr) (string, error) {
+	if e, ok := expr.(*ast.SelectorExpr); ok {
+		if ident, ok := e.X.(*ast.Ident); ok {
+			return fmt.Sprintf("%s.%s", ident.Name, e.Sel.Name), nil
+		}
+	}
+	return "", fmt.Errorf("backfill must be a function of another package, e.g. logic.ComputeSlug")
+}
+
 func parsePermissionsExpression(expr ast.Expr) permissions.Permission {
 	var perm permissions.Permission
 
@@ -0,0 +1,494 @@
+package parser
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/guntisdev/entlite/internal/protolock"
+	"github.com/guntisdev/entlite/internal/schema"
+)
+
+const fieldEntityTemplate = `package schema
+
+import (
+	"github.com/guntisdev/entlite/pkg/entlite"
+	"github.com/guntisdev/entlite/pkg/entlite/field"
+
+	"example.com/app/ent/logic"
+)
+
+type Device struct {
+	entlite.Schema
+}
+
+func (Device) Contracts() []entlite.Contract {
+	return []entlite.Contract{
+		entlite.SQLC(),
+		entlite.PROTO(),
+	}
+}
+
+func (Device) Fields() []entlite.Field {
+	return []entlite.Field{
+		field.String("name"),
+		%s
+	}
+}
+`
+
+func parseFieldEntity(t *testing.T, fields string) (schema.Entity, error) {
+	t.Helper()
+	return parseLockedFieldEntity(t, fields, nil)
+}
+
+func parseLockedFieldEntity(t *testing.T, fields string, lock *protolock.Lock) (schema.Entity, error) {
+	t.Helper()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "device.go")
+	source := strings.Replace(fieldEntityTemplate, "%s", fields, 1)
+
+	if err := os.WriteFile(path, []byte(source), 0644); err != nil {
+		t.Fatalf("failed to write entity file: %v", err)
+	}
+
+	entities, err := ParseEntities([]DiscoveredEntity{{Name: "Device", Path: path}}, lock)
+	if err != nil {
+		return schema.Entity{}, err
+	}
+	return entities[0], nil
+}
+
+func TestJSONOfField(t *testing.T) {
+	tests := []struct {
+		name         string
+		fields       string
+		wantGoType   string
+		wantOptional bool
+		wantErr      string
+	}{
+		{
+			name:       "typed json",
+			fields:     `field.JSONOf[logic.Settings]("settings"),`,
+			wantGoType: "logic.Settings",
+		},
+		{
+			name:         "typed json with chained options",
+			fields:       "field.JSONOf[logic.Settings](\"settings\").Optional().Default(`{}`),",
+			wantGoType:   "logic.Settings",
+			wantOptional: true,
+		},
+		{
+			name:       "plain json has no go type",
+			fields:     `field.JSON("settings"),`,
+			wantGoType: "",
+		},
+		{
+			name:    "type from the schema package",
+			fields:  `field.JSONOf[Settings]("settings"),`,
+			wantErr: `field "settings": JSONOf needs a type from another package, e.g. JSONOf[logic.Settings]`,
+		},
+		{
+			name:    "validate gets json text",
+			fields:  `field.JSONOf[logic.Settings]("settings").Validate(logic.CheckSettings),`,
+			wantErr: `entity "Device" field "settings": JSONOf fields can't take Validate, it would get json text instead of logic.Settings`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entity, err := parseFieldEntity(t, tt.fields)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			field, ok := entity.GetFieldByName("settings")
+			if !ok {
+				t.Fatalf("settings field not parsed, got %+v", entity.Fields)
+			}
+			if field.Type != schema.FieldTypeJSON {
+				t.Errorf("Type = %q, want %q", field.Type, schema.FieldTypeJSON)
+			}
+			if field.GoType != tt.wantGoType {
+				t.Errorf("GoType = %q, want %q", field.GoType, tt.wantGoType)
+			}
+			if field.Optional != tt.wantOptional {
+				t.Errorf("Optional = %v, want %v", field.Optional, tt.wantOptional)
+			}
+		})
+	}
+}
+
+func TestProtoJSONField(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  string
+		want    schema.ProtoJSONType
+		wantErr string
+	}{
+		{
+			name:   "plain json stays text",
+			fields: `field.JSON("settings"),`,
+			want:   schema.ProtoJSONText,
+		},
+		{
+			name:   "json as struct",
+			fields: "field.JSON(\"settings\").ProtoStruct().Default(`{}`),",
+			want:   schema.ProtoJSONStruct,
+		},
+		{
+			name:   "json as value",
+			fields: `field.JSON("settings").Optional().ProtoValue(),`,
+			want:   schema.ProtoJSONValue,
+		},
+		{
+			name:   "typed json defaults to struct",
+			fields: `field.JSONOf[logic.Settings]("settings"),`,
+			want:   schema.ProtoJSONStruct,
+		},
+		{
+			name:   "typed json as value",
+			fields: `field.JSONOf[logic.Settings]("settings").ProtoValue(),`,
+			want:   schema.ProtoJSONValue,
+		},
+		{
+			name:    "struct default must be an object",
+			fields:  "field.JSON(\"settings\").Default(`[]`).ProtoStruct(),",
+			wantErr: `entity "Device" field "settings" json default must be an object to go to proto as a Struct: []`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entity, err := parseFieldEntity(t, tt.fields)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			field, ok := entity.GetFieldByName("settings")
+			if !ok {
+				t.Fatalf("settings field not parsed, got %+v", entity.Fields)
+			}
+			if field.ProtoJSON != tt.want {
+				t.Errorf("ProtoJSON = %q, want %q", field.ProtoJSON, tt.want)
+			}
+		})
+	}
+}
+
+func TestListFields(t *testing.T) {
+	tests := []struct {
+		name         string
+		fields       string
+		wantType     schema.FieldType
+		wantOptional bool
+	}{
+		{
+			name:     "strings",
+			fields:   `field.Strings("allowed"),`,
+			wantType: schema.FieldTypeStrings,
+		},
+		{
+			name:         "optional ints",
+			fields:       `field.Ints("allowed").Optional(),`,
+			wantType:     schema.FieldTypeInts,
+			wantOptional: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entity, err := parseFieldEntity(t, tt.fields)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			field, ok := entity.GetFieldByName("allowed")
+			if !ok {
+				t.Fatalf("allowed field not parsed, got %+v", entity.Fields)
+			}
+			if field.Type != tt.wantType || !field.IsList() {
+				t.Errorf("Type = %q, want list type %q", field.Type, tt.wantType)
+			}
+			if field.Optional != tt.wantOptional {
+				t.Errorf("Optional = %v, want %v", field.Optional, tt.wantOptional)
+			}
+		})
+	}
+}
+
+func TestDecimalField(t *testing.T) {
+	tests := []struct {
+		name          string
+		fields        string
+		wantPrecision int
+		wantScale     int
+		wantErr       string
+	}{
+		{
+			name:          "precision and scale",
+			fields:        `field.Decimal("amount").Precision(12, 2),`,
+			wantPrecision: 12,
+			wantScale:     2,
+		},
+		{
+			name:          "default that fits",
+			fields:        `field.Decimal("amount").Precision(5, 2).Default("-999.990"),`,
+			wantPrecision: 5,
+			wantScale:     2,
+		},
+		{
+			name:    "missing precision",
+			fields:  `field.Decimal("amount"),`,
+			wantErr: `entity "Device" field "amount": Decimal needs Precision(precision, scale)`,
+		},
+		{
+			name:    "scale over precision",
+			fields:  `field.Decimal("amount").Precision(2, 4),`,
+			wantErr: `Decimal needs Precision(precision, scale)`,
+		},
+		{
+			name:    "default not a number",
+			fields:  `field.Decimal("amount").Precision(12, 2).Default("1e3"),`,
+			wantErr: `entity "Device" field "amount" decimal default is not a decimal number: 1e3`,
+		},
+		{
+			name:    "default too large",
+			fields:  `field.Decimal("amount").Precision(4, 2).Default("100.00"),`,
+			wantErr: `decimal default 100.00 needs more than 2 digits before the decimal point`,
+		},
+		{
+			name:    "default too fine",
+			fields:  `field.Decimal("amount").Precision(4, 2).Default("0.125"),`,
+			wantErr: `decimal default 0.125 has more than 2 decimal places`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entity, err := parseFieldEntity(t, tt.fields)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			field, ok := entity.GetFieldByName("amount")
+			if !ok {
+				t.Fatalf("amount field not parsed, got %+v", entity.Fields)
+			}
+			if field.Type != schema.FieldTypeDecimal {
+				t.Errorf("Type = %q, want %q", field.Type, schema.FieldTypeDecimal)
+			}
+			if field.Precision != tt.wantPrecision || field.Scale != tt.wantScale {
+				t.Errorf("Precision = (%d, %d), want (%d, %d)", field.Precision, field.Scale, tt.wantPrecision, tt.wantScale)
+			}
+		})
+	}
+}
+
+func TestDateAndDurationFields(t *testing.T) {
+	tests := []struct {
+		name        string
+		fields      string
+		wantType    schema.FieldType
+		wantDefault any
+	}{
+		{
+			name:     "date",
+			fields:   `field.Date("span").Optional(),`,
+			wantType: schema.FieldTypeDate,
+		},
+		{
+			name:        "duration default unit",
+			fields:      `field.Duration("span").Default(time.Minute),`,
+			wantType:    schema.FieldTypeDuration,
+			wantDefault: time.Minute,
+		},
+		{
+			name:        "duration default expression",
+			fields:      `field.Duration("span").Default(90 * time.Second),`,
+			wantType:    schema.FieldTypeDuration,
+			wantDefault: 90 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entity, err := parseFieldEntity(t, tt.fields)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			field, ok := entity.GetFieldByName("span")
+			if !ok {
+				t.Fatalf("span field not parsed, got %+v", entity.Fields)
+			}
+			if field.Type != tt.wantType {
+				t.Errorf("Type = %q, want %q", field.Type, tt.wantType)
+			}
+			if field.DefaultValue != tt.wantDefault {
+				t.Errorf("DefaultValue = %v, want %v", field.DefaultValue, tt.wantDefault)
+			}
+		})
+	}
+}
+
+func TestLatLngField(t *testing.T) {
+	entity, err := parseFieldEntity(t, `field.LatLng("position").Optional(),`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	field, ok := entity.GetFieldByName("position")
+	if !ok {
+		t.Fatalf("position field not parsed, got %+v", entity.Fields)
+	}
+	if field.Type != schema.FieldTypeLatLng || !field.Optional {
+		t.Errorf("field = %+v, want an optional latlng", field)
+	}
+	if lat, lng := field.LatLngColumns(); lat != "position_lat" || lng != "position_lng" {
+		t.Errorf("LatLngColumns() = %q, %q", lat, lng)
+	}
+
+	_, err = parseFieldEntity(t, "field.LatLng(\"position\"),\n\t\tfield.Float(\"position_lng\"),")
+	want := `entity "Device" field "position" is stored in column "position_lng", which is already a field`
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Fatalf("expected error containing %q, got %v", want, err)
+	}
+}
+
+func TestProtoFieldLock(t *testing.T) {
+	lock := &protolock.Lock{Messages: map[string]protolock.Message{
+		"Device": {Fields: map[string]int{"ID": 1, "name": 2, "serial": 3, "removed": 4}},
+	}}
+
+	tests := []struct {
+		name    string
+		fields  string
+		want    map[string]int
+		wantErr string
+	}{
+		{
+			name:   "inserted field keeps later numbers",
+			fields: "field.String(\"added\"),\n\t\tfield.String(\"serial\"),",
+			want:   map[string]int{"ID": 1, "name": 2, "added": 5, "serial": 3},
+		},
+		{
+			name:   "same explicit number",
+			fields: `field.String("serial").ProtoField(3),`,
+			want:   map[string]int{"ID": 1, "name": 2, "serial": 3},
+		},
+		{
+			name:    "explicit number moves a locked field",
+			fields:  `field.String("serial").ProtoField(7),`,
+			wantErr: `field "serial" is number 3 in entlite.lock, ProtoField(7) would change it`,
+		},
+		{
+			name:    "explicit number of a removed field",
+			fields:  `field.String("added").ProtoField(4),`,
+			wantErr: `field "added" ProtoField(4) is reserved, it was the number of removed field "removed"`,
+		},
+		{
+			name:    "explicit number of another field",
+			fields:  "field.String(\"serial\"),\n\t\tfield.String(\"added\").ProtoField(3),",
+			wantErr: `field "added" ProtoField(3) is the number of field "serial" in entlite.lock`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entity, err := parseLockedFieldEntity(t, tt.fields, lock)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got := map[string]int{}
+			for _, field := range entity.Fields {
+				got[field.Name] = field.ProtoField
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("numbers = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackfillFields(t *testing.T) {
+	tests := []struct {
+		name            string
+		fields          string
+		wantBackfill    string
+		wantBackfillSQL string
+		wantErr         string
+	}{
+		{
+			name:         "go function",
+			fields:       `field.String("slug").Backfill(logic.ComputeSlug),`,
+			wantBackfill: "logic.ComputeSlug",
+		},
+		{
+			name:            "sql expression",
+			fields:          `field.String("slug").BackfillSQL("LOWER(name)"),`,
+			wantBackfillSQL: "LOWER(name)",
+		},
+		{
+			name:    "optional field",
+			fields:  `field.String("slug").Optional().Backfill(logic.ComputeSlug),`,
+			wantErr: `entity "Device" field "slug": a backfill is for a NOT NULL field without Default(), drop it`,
+		},
+		{
+			name:    "field with a default",
+			fields:  `field.String("slug").Default("none").BackfillSQL("name"),`,
+			wantErr: `entity "Device" field "slug": a backfill is for a NOT NULL field without Default(), drop it`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entity, err := parseFieldEntity(t, tt.fields)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			field, ok := entity.GetFieldByName("slug")
+			if !ok {
+				t.Fatalf("slug field not parsed, got %+v", entity.Fields)
+			}
+			if field.Backfill != tt.wantBackfill || field.BackfillSQL != tt.wantBackfillSQL {
+				t.Errorf("Backfill = %q, BackfillSQL = %q, want %q, %q", field.Backfill, field.BackfillSQL, tt.wantBackfill, tt.wantBackfillSQL)
+			}
+		})
+	}
+}
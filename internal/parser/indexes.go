@@ -3,6 +3,8 @@ package parser
 import (
 	"fmt"
 	"go/ast"
+	"go/token"
+	"strings"
 
 	"github.com/guntisdev/entlite/internal/schema"
 )
@@ -74,6 +76,12 @@ func parseIndexCall(callExpr *ast.CallExpr) (schema.Index, bool, error) {
 				return schema.Index{}, true, fmt.Errorf("index.Fields requires at least one field")
 			}
 			return schema.Index{Type: schema.IndexRegular, Columns: columnsFromFields(fields)}, true, nil
+		case "JSONPath":
+			field, path, err := parseJSONPathArgs(callExpr.Args, "index.JSONPath")
+			if err != nil {
+				return schema.Index{}, true, err
+			}
+			return schema.Index{Type: schema.IndexRegular, Columns: columnsFromFields([]string{field}), JSONPath: path}, true, nil
 		default:
 			return schema.Index{}, false, nil
 		}
@@ -121,6 +129,25 @@ func parseIndexCall(callExpr *ast.CallExpr) (schema.Index, bool, error) {
 			return schema.Index{}, true, err
 		}
 		index.Columns = append(index.Columns, schema.IndexColumn{Name: field, Desc: true})
+	case "Lower":
+		if len(callExpr.Args) != 0 {
+			return schema.Index{}, true, fmt.Errorf("Lower does not accept arguments")
+		}
+		index.Lower = true
+	case "Where":
+		if len(callExpr.Args) != 1 {
+			return schema.Index{}, true, fmt.Errorf("Where expects exactly one string predicate")
+		}
+		// unquote rather than trim, predicates may be `raw` strings or carry escapes
+		lit, ok := callExpr.Args[0].(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			return schema.Index{}, true, fmt.Errorf("Where expects exactly one string predicate")
+		}
+		predicate := unquote(lit.Value)
+		if strings.TrimSpace(predicate) == "" {
+			return schema.Index{}, true, fmt.Errorf("Where predicate must not be empty")
+		}
+		index.Where = predicate
 	default:
 		return schema.Index{}, true, fmt.Errorf("unsupported index operation %q", selExpr.Sel.Name)
 	}
@@ -3,8 +3,11 @@ package parser
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/guntisdev/entlite/internal/schema"
 )
 
 const indexEntityTemplate = `package schema
@@ -33,6 +36,7 @@ func (User) Fields() []entlite.Field {
 		field.String("name"),
 		field.Bool("is_active").Default(true),
 		field.String("captcha").Permissions(permissions.Virtual),
+		field.JSON("settings"),
 	}
 }
 
@@ -46,6 +50,13 @@ func (User) Indexes() []entlite.Index {
 func parseIndexEntity(t *testing.T, indexes string) error {
 	t.Helper()
 
+	_, err := parseEntityIndexes(t, indexes)
+	return err
+}
+
+func parseEntityIndexes(t *testing.T, indexes string) ([]schema.Index, error) {
+	t.Helper()
+
 	dir := t.TempDir()
 	path := filepath.Join(dir, "user.go")
 	source := strings.Replace(indexEntityTemplate, "%s", indexes, 1)
@@ -54,8 +65,11 @@ func parseIndexEntity(t *testing.T, indexes string) error {
 		t.Fatalf("failed to write entity file: %v", err)
 	}
 
-	_, err := ParseEntities([]DiscoveredEntity{{Name: "User", Path: path}})
-	return err
+	entities, err := ParseEntities([]DiscoveredEntity{{Name: "User", Path: path}}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return entities[0].Indexes, nil
 }
 
 func TestIndexFieldValidation(t *testing.T) {
@@ -110,3 +124,178 @@ func TestIndexFieldValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestPartialIndex(t *testing.T) {
+	tests := []struct {
+		name      string
+		indexes   string
+		wantWhere string
+		wantErr   string
+	}{
+		{
+			name:      "unique partial index",
+			indexes:   `index.Fields("email").Unique().Where("is_active = true"),`,
+			wantWhere: "is_active = true",
+		},
+		{
+			name:      "raw string predicate",
+			indexes:   "index.Fields(\"name\").Where(`name <> ''`),",
+			wantWhere: "name <> ''",
+		},
+		{
+			name:    "empty predicate",
+			indexes: `index.Fields("email").Where(" "),`,
+			wantErr: "Where predicate must not be empty",
+		},
+		{
+			name:    "predicate not a string",
+			indexes: `index.Fields("email").Where(true),`,
+			wantErr: "Where expects exactly one string predicate",
+		},
+		{
+			name:    "where on primary",
+			indexes: `index.Primary("email").Where("is_active = true"),`,
+			wantErr: "Where cannot be chained on index.Primary",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			indexes, err := parseEntityIndexes(t, tt.indexes)
+
+			if tt.wantErr != "" {
+				if err == nil {
+					t.Fatalf("expected error containing %q, got nil", tt.wantErr)
+				}
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got: %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+
+			if len(indexes) != 1 {
+				t.Fatalf("expected 1 index, got %d", len(indexes))
+			}
+			if indexes[0].Where != tt.wantWhere {
+				t.Errorf("Where = %q, want %q", indexes[0].Where, tt.wantWhere)
+			}
+		})
+	}
+}
+
+func TestLowerIndex(t *testing.T) {
+	tests := []struct {
+		name    string
+		indexes string
+		wantErr string
+	}{
+		{
+			name:    "unique lower index",
+			indexes: `index.Fields("email").Unique().Lower(),`,
+		},
+		{
+			name:    "lower on non string field",
+			indexes: `index.Fields("email", "is_active").Lower(),`,
+			wantErr: `only string fields can be lowercased`,
+		},
+		{
+			name:    "lower with arguments",
+			indexes: `index.Fields("email").Lower("email"),`,
+			wantErr: "Lower does not accept arguments",
+		},
+		{
+			name:    "lower on primary",
+			indexes: `index.Primary("email").Lower(),`,
+			wantErr: "Lower cannot be chained on index.Primary",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			indexes, err := parseEntityIndexes(t, tt.indexes)
+
+			if tt.wantErr != "" {
+				if err == nil {
+					t.Fatalf("expected error containing %q, got nil", tt.wantErr)
+				}
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got: %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+
+			if len(indexes) != 1 || !indexes[0].Lower {
+				t.Fatalf("expected 1 Lower index, got %+v", indexes)
+			}
+		})
+	}
+}
+
+func TestJSONPathIndex(t *testing.T) {
+	tests := []struct {
+		name     string
+		indexes  string
+		wantPath []string
+		wantErr  string
+	}{
+		{
+			name:     "json path index",
+			indexes:  `index.JSONPath("settings", "$.theme"),`,
+			wantPath: []string{"theme"},
+		},
+		{
+			name:     "unique nested path",
+			indexes:  `index.JSONPath("settings", "$.ui.theme").Unique(),`,
+			wantPath: []string{"ui", "theme"},
+		},
+		{
+			name:    "whole json field",
+			indexes: `index.Fields("settings"),`,
+			wantErr: `index references json field "settings", index a path in it with index.JSONPath`,
+		},
+		{
+			name:    "path on non json field",
+			indexes: `index.JSONPath("email", "$.theme"),`,
+			wantErr: `index.JSONPath needs a json field, "email" is not one`,
+		},
+		{
+			name:    "extra column",
+			indexes: `index.JSONPath("settings", "$.theme").Asc("email"),`,
+			wantErr: "covers one path only",
+		},
+		{
+			name:    "bad path",
+			indexes: `index.JSONPath("settings", "$.ui-theme"),`,
+			wantErr: "must be letters, digits and underscores",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			indexes, err := parseEntityIndexes(t, tt.indexes)
+
+			if tt.wantErr != "" {
+				if err == nil {
+					t.Fatalf("expected error containing %q, got nil", tt.wantErr)
+				}
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got: %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+
+			if len(indexes) != 1 || !reflect.DeepEqual(indexes[0].JSONPath, tt.wantPath) {
+				t.Fatalf("expected 1 index on path %v, got %+v", tt.wantPath, indexes)
+			}
+		})
+	}
+}
@@ -6,15 +6,20 @@ import (
 	"go/ast"
 	"go/parser"
 	"go/token"
+	"regexp"
+	"strings"
 
+	"github.com/guntisdev/entlite/internal/protolock"
 	"github.com/guntisdev/entlite/internal/schema"
 )
 
-func ParseEntities(discoveredEntities []DiscoveredEntity) ([]schema.Entity, error) {
+// ParseEntities parses the discovered entities. Fields without a ProtoField
+// take their number from lock when it has one, lock may be nil.
+func ParseEntities(discoveredEntities []DiscoveredEntity, lock *protolock.Lock) ([]schema.Entity, error) {
 	var entities []schema.Entity
 
 	for _, discovered := range discoveredEntities {
-		parsed, err := parseEntityFromFile(discovered)
+		parsed, err := parseEntityFromFile(discovered, lock)
 		if err != nil {
 			return nil, fmt.Errorf("entity %q in %s: %w", discovered.Name, discovered.Path, err)
 		}
@@ -24,7 +29,7 @@ func ParseEntities(discoveredEntities []DiscoveredEntity) ([]schema.Entity, erro
 	return entities, nil
 }
 
-func parseEntityFromFile(discovered DiscoveredEntity) (schema.Entity, error) {
+func parseEntityFromFile(discovered DiscoveredEntity, lock *protolock.Lock) (schema.Entity, error) {
 	entity := schema.Entity{
 		Name: discovered.Name,
 	}
@@ -36,6 +41,7 @@ func parseEntityFromFile(discovered DiscoveredEntity) (schema.Entity, error) {
 	}
 
 	hasContractsMethod := false
+	hasFieldsMethod := false
 
 	for _, decl := range file.Decls {
 		funcDecl, ok := decl.(*ast.FuncDecl)
@@ -76,6 +82,8 @@ func parseEntityFromFile(discovered DiscoveredEntity) (schema.Entity, error) {
 
 		// Parse Fields
 		if funcDecl.Name.Name == "Fields" {
+			hasFieldsMethod = true
+
 			fields, err := parseFieldsMethod(funcDecl)
 			if err != nil {
 				return entity, fmt.Errorf("failed to parse fields: %w", err)
@@ -84,12 +92,18 @@ func parseEntityFromFile(discovered DiscoveredEntity) (schema.Entity, error) {
 			if err := checkProtoFieldCollision(fields); err != nil {
 				return entity, err
 			}
-
-			// add protoField, add id if not there
-			fields = addFieldNumbers(fields)
 			entity.Fields = fields
 		}
 
+		// Parse RenamedFrom
+		if funcDecl.Name.Name == "RenamedFrom" {
+			renamedFrom, err := parseRenamedFromMethod(funcDecl)
+			if err != nil {
+				return entity, err
+			}
+			entity.RenamedFrom = renamedFrom
+		}
+
 		// Parse Queries
 		if funcDecl.Name.Name == "Queries" {
 			queries, err := parseQueriesMethod(funcDecl)
@@ -113,6 +127,24 @@ func parseEntityFromFile(discovered DiscoveredEntity) (schema.Entity, error) {
 		return entity, err
 	}
 
+	if err := validateRenames(entity); err != nil {
+		return entity, err
+	}
+
+	// add protoField, add id if not there. A renamed entity keeps the numbers
+	// locked to its old name until gen moves them.
+	if hasFieldsMethod {
+		lockedNumbers := lock.Fields(entity.Name)
+		if lockedNumbers == nil && entity.RenamedFrom != "" {
+			lockedNumbers = lock.Fields(entity.RenamedFrom)
+		}
+		fields, err := addFieldNumbers(entity.Fields, lockedNumbers)
+		if err != nil {
+			return entity, err
+		}
+		entity.Fields = fields
+	}
+
 	// An explicit index.Primary overrides the auto-assigned primary key on the
 	// id field: the compound key declared in Indexes() becomes the table's only
 	// PRIMARY KEY.
@@ -134,6 +166,22 @@ func parseEntityFromFile(discovered DiscoveredEntity) (schema.Entity, error) {
 		return entity, err
 	}
 
+	if err := validateTypedJSONFields(entity); err != nil {
+		return entity, err
+	}
+
+	if err := validateDecimalFields(entity); err != nil {
+		return entity, err
+	}
+
+	if err := validateLatLngFields(entity); err != nil {
+		return entity, err
+	}
+
+	if err := validateBackfills(entity); err != nil {
+		return entity, err
+	}
+
 	return entity, nil
 }
 
@@ -150,6 +198,59 @@ func validateContracts(entity schema.Entity, hasMethod bool) error {
 	return nil
 }
 
+// parseRenamedFromMethod reads the old name an entity returns from
+// RenamedFrom(), it has to be a string literal
+func parseRenamedFromMethod(funcDecl *ast.FuncDecl) (string, error) {
+	if funcDecl.Body != nil {
+		for _, stmt := range funcDecl.Body.List {
+			retStmt, ok := stmt.(*ast.ReturnStmt)
+			if !ok || len(retStmt.Results) != 1 {
+				continue
+			}
+			if lit, ok := retStmt.Results[0].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+				return unquote(lit.Value), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("RenamedFrom() must return a string literal, the old entity name")
+}
+
+// validateRenames keeps a rename from pointing at a name still in use, a
+// migration would have two columns to take one name
+func validateRenames(entity schema.Entity) error {
+	if entity.RenamedFrom == entity.Name {
+		return fmt.Errorf("entity %q RenamedFrom() returns its own name", entity.Name)
+	}
+	for _, field := range entity.Fields {
+		if field.RenamedFrom == "" {
+			continue
+		}
+		if field.IsID() || strings.EqualFold(field.RenamedFrom, "id") {
+			return fmt.Errorf("entity %q field %q: the id field can't be renamed", entity.Name, field.Name)
+		}
+		if entityHasField(entity, field.RenamedFrom) {
+			return fmt.Errorf("entity %q field %q RenamedFrom(%q), which is still a field", entity.Name, field.Name, field.RenamedFrom)
+		}
+	}
+
+	return nil
+}
+
+// validateBackfills keeps a backfill to the fields a migration can't add
+// without one, anything else fills its rows with null or the default
+func validateBackfills(entity schema.Entity) error {
+	for _, field := range entity.Fields {
+		if field.Backfill == "" && field.BackfillSQL == "" {
+			continue
+		}
+		if field.Optional || field.DefaultValue != nil {
+			return fmt.Errorf("entity %q field %q: a backfill is for a NOT NULL field without Default(), drop it", entity.Name, field.Name)
+		}
+	}
+
+	return nil
+}
+
 // catch malformed text at generation time
 func validateJSONDefaults(entity schema.Entity) error {
 	for _, field := range entity.Fields {
@@ -163,6 +264,81 @@ func validateJSONDefaults(entity schema.Entity) error {
 		if !json.Valid([]byte(text)) {
 			return fmt.Errorf("entity %q field %q json default is not valid json: %s", entity.Name, field.Name, text)
 		}
+		if field.ProtoJSON == schema.ProtoJSONStruct && !strings.HasPrefix(strings.TrimSpace(text), "{") {
+			return fmt.Errorf("entity %q field %q json default must be an object to go to proto as a Struct: %s", entity.Name, field.Name, text)
+		}
+	}
+
+	return nil
+}
+
+// Validate funcs take the stored text, a JSONOf field only has its Go type
+func validateTypedJSONFields(entity schema.Entity) error {
+	for _, field := range entity.Fields {
+		if field.IsTypedJSON() && field.Validate != nil {
+			return fmt.Errorf("entity %q field %q: JSONOf fields can't take Validate, it would get json text instead of %s", entity.Name, field.Name, field.GoType)
+		}
+	}
+
+	return nil
+}
+
+// 38 digits is the most postgres, mysql and shopspring/decimal text all agree on
+const maxDecimalPrecision = 38
+
+var decimalText = regexp.MustCompile(`^-?([0-9]+)(\.([0-9]+))?$`)
+
+func validateDecimalFields(entity schema.Entity) error {
+	for _, field := range entity.Fields {
+		if field.Type != schema.FieldTypeDecimal {
+			continue
+		}
+		if field.Precision < 1 || field.Precision > maxDecimalPrecision || field.Scale < 0 || field.Scale > field.Precision {
+			return fmt.Errorf("entity %q field %q: Decimal needs Precision(precision, scale) with int literals, 1 <= precision <= %d and 0 <= scale <= precision", entity.Name, field.Name, maxDecimalPrecision)
+		}
+		if field.DefaultValue == nil {
+			continue
+		}
+		text, ok := field.DefaultValue.(string)
+		if !ok {
+			return fmt.Errorf("entity %q field %q decimal default must be a string, e.g. \"0.00\"", entity.Name, field.Name)
+		}
+		if err := checkDecimalText(text, field.Precision, field.Scale); err != nil {
+			return fmt.Errorf("entity %q field %q decimal default %w", entity.Name, field.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// checkDecimalText mirrors the range check the sqlcWrap layer runs on writes
+func checkDecimalText(text string, precision, scale int) error {
+	match := decimalText.FindStringSubmatch(text)
+	if match == nil {
+		return fmt.Errorf("is not a decimal number: %s", text)
+	}
+	if digits := len(strings.TrimLeft(match[1], "0")); digits > precision-scale {
+		return fmt.Errorf("%s needs more than %d digits before the decimal point", text, precision-scale)
+	}
+	if len(strings.TrimRight(match[3], "0")) > scale {
+		return fmt.Errorf("%s has more than %d decimal places", text, scale)
+	}
+	return nil
+}
+
+// validateLatLngFields keeps the two columns a latlng is stored in from
+// clashing with another field's column
+func validateLatLngFields(entity schema.Entity) error {
+	for _, field := range entity.Fields {
+		if field.Type != schema.FieldTypeLatLng {
+			continue
+		}
+		latColumn, lngColumn := field.LatLngColumns()
+		for _, column := range []string{latColumn, lngColumn} {
+			if entityHasField(entity, column) {
+				return fmt.Errorf("entity %q field %q is stored in column %q, which is already a field", entity.Name, field.Name, column)
+			}
+		}
 	}
 
 	return nil
@@ -180,6 +356,9 @@ func validateVirtualFields(entity schema.Entity) error {
 
 func validateIndexFields(entity schema.Entity) error {
 	for _, idx := range entity.Indexes {
+		if idx.JSONPath != nil && (len(idx.Columns) != 1 || idx.Lower) {
+			return fmt.Errorf("entity %q index.JSONPath on %q covers one path only, it can't take Asc/Desc/Lower", entity.Name, idx.Columns[0].Name)
+		}
 		for _, column := range idx.Columns {
 			if !entityHasField(entity, column.Name) {
 				return fmt.Errorf("entity %q index references nonexisting field %q", entity.Name, column.Name)
@@ -187,9 +366,20 @@ func validateIndexFields(entity schema.Entity) error {
 			if entityFieldIsVirtual(entity, column.Name) {
 				return fmt.Errorf("entity %q index references virtual field %q, which has no database column", entity.Name, column.Name)
 			}
-			// TODO support indexing json fields (postgres needs GIN, mysql needs a generated column)
-			if entityFieldHasType(entity, column.Name, schema.FieldTypeJSON) {
-				return fmt.Errorf("entity %q index references json field %q, indexing json fields is not supported", entity.Name, column.Name)
+			// mysql can't index a TEXT column and the json text sqlite holds isn't worth it
+			if entityFieldIsList(entity, column.Name) {
+				return fmt.Errorf("entity %q index references list field %q, lists can't be indexed", entity.Name, column.Name)
+			}
+			// a json column can't be indexed whole, only a path inside it
+			isJSON := entityFieldHasType(entity, column.Name, schema.FieldTypeJSON)
+			if isJSON && idx.JSONPath == nil {
+				return fmt.Errorf("entity %q index references json field %q, index a path in it with index.JSONPath", entity.Name, column.Name)
+			}
+			if !isJSON && idx.JSONPath != nil {
+				return fmt.Errorf("entity %q index.JSONPath needs a json field, %q is not one", entity.Name, column.Name)
+			}
+			if idx.Lower && !entityFieldHasType(entity, column.Name, schema.FieldTypeString) {
+				return fmt.Errorf("entity %q Lower index references %q, only string fields can be lowercased", entity.Name, column.Name)
 			}
 		}
 	}
@@ -78,6 +78,11 @@ func parseQueryCall(callExpr *ast.CallExpr) ([]schema.Query, bool, error) {
 				return nil, true, fmt.Errorf("CreateBulk does not accept arguments")
 			}
 			return []schema.Query{{Type: schema.QueryCreateBulk}}, true, nil
+		case "CreateStream":
+			if len(callExpr.Args) != 0 {
+				return nil, true, fmt.Errorf("CreateStream does not accept arguments")
+			}
+			return []schema.Query{{Type: schema.QueryCreateStream}}, true, nil
 		case "Get":
 			return []schema.Query{{Type: schema.QueryGetBy, Fields: []string{"ID"}}}, true, nil
 		case "Update":
@@ -125,8 +130,16 @@ func parseQueryCall(callExpr *ast.CallExpr) ([]schema.Query, bool, error) {
 	}
 
 	query := queries[0]
-	if query.Type != schema.QueryListBy && selExpr.Sel.Name != "Name" {
-		return nil, true, fmt.Errorf("%s is only supported for ListBy queries", selExpr.Sel.Name)
+	switch selExpr.Sel.Name {
+	case "Name":
+	case "Stream":
+		if query.Type != schema.QueryListBy && query.Type != schema.QueryListAll {
+			return nil, true, fmt.Errorf("Stream is only supported for ListBy and ListAll queries")
+		}
+	default:
+		if query.Type != schema.QueryListBy {
+			return nil, true, fmt.Errorf("%s is only supported for ListBy queries", selExpr.Sel.Name)
+		}
 	}
 
 	switch selExpr.Sel.Name {
@@ -144,6 +157,11 @@ func parseQueryCall(callExpr *ast.CallExpr) ([]schema.Query, bool, error) {
 			return nil, true, fmt.Errorf("OrderBy expects exactly one string field: %w", err)
 		}
 		query.OrderBy = orderField
+	case "Stream":
+		if len(callExpr.Args) != 0 {
+			return nil, true, fmt.Errorf("Stream does not accept arguments")
+		}
+		query.Stream = true
 	case "Name":
 		if len(callExpr.Args) != 1 {
 			return nil, true, fmt.Errorf("Name expects exactly one string argument")
@@ -185,6 +203,47 @@ func parseSingleStringArg(arg ast.Expr) (string, error) {
 	return strings.Trim(lit.Value, "\""), nil
 }
 
+// parseJSONPathArgs reads the (field, path) args of filter.JSONPath and index.JSONPath
+func parseJSONPathArgs(args []ast.Expr, method string) (string, []string, error) {
+	strs, err := parseStringArgs(args)
+	if err != nil || len(strs) != 2 {
+		return "", nil, fmt.Errorf("%s expects a field and a path string", method)
+	}
+	path, err := parseJSONPath(strs[1])
+	if err != nil {
+		return "", nil, fmt.Errorf("%s on %q: %w", method, strs[0], err)
+	}
+	return strs[0], path, nil
+}
+
+// parseJSONPath splits "$.ui.theme" or "ui.theme" into its keys. Keys end up
+// inside SQL literals and param names, so only identifier-like keys are allowed.
+func parseJSONPath(path string) ([]string, error) {
+	trimmed := strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if trimmed == "" {
+		return nil, fmt.Errorf("json path %q has no keys", path)
+	}
+	keys := strings.Split(trimmed, ".")
+	for _, key := range keys {
+		if !isJSONPathKey(key) {
+			return nil, fmt.Errorf("json path %q: key %q must be letters, digits and underscores", path, key)
+		}
+	}
+	return keys, nil
+}
+
+func isJSONPathKey(key string) bool {
+	if key == "" || (key[0] >= '0' && key[0] <= '9') {
+		return false
+	}
+	for _, r := range key {
+		if !(r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')) {
+			return false
+		}
+	}
+	return true
+}
+
 func parseListByArgs(args []ast.Expr) ([]string, []schema.QueryFilter, error) {
 	fields := []string{}
 	filters := []schema.QueryFilter{}
@@ -225,24 +284,11 @@ func parseFilterExpression(expr ast.Expr) (schema.QueryFilter, bool, error) {
 		return schema.QueryFilter{}, false, nil
 	}
 
+	if innerCall, ok := selExpr.X.(*ast.CallExpr); ok {
+		return parseFilterModifier(selExpr.Sel.Name, callExpr, innerCall)
+	}
 	if selExpr.Sel.Name == "Optional" {
-		innerCall, ok := selExpr.X.(*ast.CallExpr)
-		if !ok {
-			return schema.QueryFilter{}, true, fmt.Errorf("Optional must be chained from a filter call")
-		}
-		parsedFilter, handled, err := parseFilterExpression(innerCall)
-		if err != nil {
-			return schema.QueryFilter{}, true, err
-		}
-		if !handled {
-			return schema.QueryFilter{}, true, fmt.Errorf("Optional must be chained from filter.Range/filter.Search/filter.Eq")
-		}
-		if len(callExpr.Args) != 0 {
-			return schema.QueryFilter{}, true, fmt.Errorf("Optional does not accept arguments")
-		}
-
-		parsedFilter.Optional = true
-		return parsedFilter, true, nil
+		return schema.QueryFilter{}, true, fmt.Errorf("Optional must be chained from a filter call")
 	}
 
 	ident, ok := selExpr.X.(*ast.Ident)
@@ -250,6 +296,17 @@ func parseFilterExpression(expr ast.Expr) (schema.QueryFilter, bool, error) {
 		return schema.QueryFilter{}, false, nil
 	}
 
+	if selExpr.Sel.Name == "AnyOf" {
+		return parseAnyOfFilter(callExpr.Args)
+	}
+	if selExpr.Sel.Name == "JSONPath" {
+		field, path, err := parseJSONPathArgs(callExpr.Args, "filter.JSONPath")
+		if err != nil {
+			return schema.QueryFilter{}, true, err
+		}
+		return schema.QueryFilter{Type: schema.QueryFilterJSONPath, Field: field, JSONPath: path}, true, nil
+	}
+
 	if len(callExpr.Args) != 1 {
 		return schema.QueryFilter{}, true, fmt.Errorf("filter.%s expects exactly one string field", selExpr.Sel.Name)
 	}
@@ -267,6 +324,10 @@ func parseFilterExpression(expr ast.Expr) (schema.QueryFilter, bool, error) {
 		parsedFilter.Type = schema.QueryFilterSearch
 	case "Eq":
 		parsedFilter.Type = schema.QueryFilterEq
+	case "Contains":
+		parsedFilter.Type = schema.QueryFilterContains
+	case "Near":
+		parsedFilter.Type = schema.QueryFilterNear
 	default:
 		return schema.QueryFilter{}, true, fmt.Errorf("unsupported filter function filter.%s", selExpr.Sel.Name)
 	}
@@ -274,6 +335,110 @@ func parseFilterExpression(expr ast.Expr) (schema.QueryFilter, bool, error) {
 	return parsedFilter, true, nil
 }
 
+// parseAnyOfFilter parses filter.AnyOf(...). Its filters are ORed and bound to
+// one shared param, so they have to agree on how that param is used.
+func parseAnyOfFilter(args []ast.Expr) (schema.QueryFilter, bool, error) {
+	if len(args) < 2 {
+		return schema.QueryFilter{}, true, fmt.Errorf("filter.AnyOf expects at least two filters")
+	}
+
+	children := []schema.QueryFilter{}
+	for _, arg := range args {
+		child, ok, err := parseFilterExpression(arg)
+		if err != nil {
+			return schema.QueryFilter{}, true, err
+		}
+		if !ok {
+			return schema.QueryFilter{}, true, fmt.Errorf("filter.AnyOf arguments must be filter.Search or filter.Eq calls")
+		}
+
+		switch child.Type {
+		case schema.QueryFilterSearch, schema.QueryFilterEq:
+		default:
+			return schema.QueryFilter{}, true, fmt.Errorf("filter.AnyOf supports only filter.Search and filter.Eq, got %s filter on %q", child.Type, child.Field)
+		}
+		if child.Optional {
+			return schema.QueryFilter{}, true, fmt.Errorf("filter.AnyOf: make the group Optional instead of filter on %q", child.Field)
+		}
+		if len(children) > 0 {
+			first := children[0]
+			if child.Type != first.Type || child.Match != first.Match {
+				return schema.QueryFilter{}, true, fmt.Errorf("filter.AnyOf: filters on %q and %q share one param and must be of the same kind", first.Field, child.Field)
+			}
+		}
+		children = append(children, child)
+	}
+
+	return schema.QueryFilter{
+		Type:    schema.QueryFilterAnyOf,
+		Field:   schema.AnyOfParam(children),
+		Filters: children,
+	}, true, nil
+}
+
+// parseFilterModifier applies a method chained onto a filter call,
+// e.g. filter.Search("label").Contains().Optional().
+func parseFilterModifier(name string, callExpr, innerCall *ast.CallExpr) (schema.QueryFilter, bool, error) {
+	parsedFilter, handled, err := parseFilterExpression(innerCall)
+	if err != nil {
+		return schema.QueryFilter{}, true, err
+	}
+	if !handled {
+		if name == "Optional" {
+			return schema.QueryFilter{}, true, fmt.Errorf("Optional must be chained from filter.Range/filter.Search/filter.Eq/filter.JSONPath/filter.Contains")
+		}
+		return schema.QueryFilter{}, false, nil
+	}
+	if len(callExpr.Args) != 0 {
+		return schema.QueryFilter{}, true, fmt.Errorf("%s does not accept arguments", name)
+	}
+
+	switch name {
+	case "Optional":
+		if parsedFilter.Type == schema.QueryFilterNear {
+			return schema.QueryFilter{}, true, fmt.Errorf("Optional is not supported on filter.Near")
+		}
+		parsedFilter.Optional = true
+		if parsedFilter.Type == schema.QueryFilterRange {
+			parsedFilter.OptionalMin = true
+			parsedFilter.OptionalMax = true
+		}
+	case "OptionalMin", "OptionalMax", "ExclusiveMin", "ExclusiveMax":
+		if parsedFilter.Type != schema.QueryFilterRange {
+			return schema.QueryFilter{}, true, fmt.Errorf("%s is only supported on filter.Range", name)
+		}
+		switch name {
+		case "OptionalMin":
+			parsedFilter.OptionalMin = true
+		case "OptionalMax":
+			parsedFilter.OptionalMax = true
+		case "ExclusiveMin":
+			parsedFilter.ExclusiveMin = true
+		case "ExclusiveMax":
+			parsedFilter.ExclusiveMax = true
+		}
+	case "Contains", "Prefix", "CaseInsensitive":
+		if parsedFilter.Type != schema.QueryFilterSearch {
+			return schema.QueryFilter{}, true, fmt.Errorf("%s is only supported on filter.Search", name)
+		}
+		if name == "CaseInsensitive" {
+			parsedFilter.CaseInsensitive = true
+			break
+		}
+		if parsedFilter.Match != schema.SearchPattern {
+			return schema.QueryFilter{}, true, fmt.Errorf("filter.Search on %q: Contains and Prefix cannot be combined", parsedFilter.Field)
+		}
+		parsedFilter.Match = schema.SearchContains
+		if name == "Prefix" {
+			parsedFilter.Match = schema.SearchPrefix
+		}
+	default:
+		return schema.QueryFilter{}, true, fmt.Errorf("unsupported filter modifier %s", name)
+	}
+
+	return parsedFilter, true, nil
+}
+
 func validateQueryFields(entity schema.Entity) error {
 	if len(entity.Queries) == 0 {
 		return nil
@@ -293,6 +458,12 @@ func validateQueryFields(entity schema.Entity) error {
 				if entityFieldIsVirtual(entity, fieldName) {
 					return fmt.Errorf("entity %q query %q references virtual field %q, which has no database column", entity.Name, query.Type, fieldName)
 				}
+				if entityFieldIsList(entity, fieldName) {
+					return fmt.Errorf("entity %q query %q can't look up by list field %q", entity.Name, query.Type, fieldName)
+				}
+				if entityFieldHasType(entity, fieldName, schema.FieldTypeLatLng) {
+					return fmt.Errorf("entity %q query %q can't look up by latlng field %q, use filter.Near", entity.Name, query.Type, fieldName)
+				}
 			}
 		case schema.QueryListBy:
 			if len(query.Fields) > 0 && len(query.Filters) > 0 {
@@ -310,15 +481,78 @@ func validateQueryFields(entity schema.Entity) error {
 				if entityFieldIsVirtual(entity, fieldName) {
 					return fmt.Errorf("entity %q query %q references virtual field %q, which has no database column", entity.Name, query.Type, fieldName)
 				}
+				if entityFieldIsList(entity, fieldName) {
+					return fmt.Errorf("entity %q query %q can't look up by list field %q", entity.Name, query.Type, fieldName)
+				}
+				if entityFieldHasType(entity, fieldName, schema.FieldTypeLatLng) {
+					return fmt.Errorf("entity %q query %q can't look up by latlng field %q, use filter.Near", entity.Name, query.Type, fieldName)
+				}
 			}
 
-			for _, queryFilter := range query.Filters {
+			for _, queryFilter := range flattenFilters(query.Filters) {
 				if !entityHasField(entity, queryFilter.Field) {
 					return fmt.Errorf("entity %q query %q filter references nonexisting field %q", entity.Name, query.Type, queryFilter.Field)
 				}
 				if entityFieldIsVirtual(entity, queryFilter.Field) {
 					return fmt.Errorf("entity %q query %q filter references virtual field %q, which has no database column", entity.Name, query.Type, queryFilter.Field)
 				}
+				isList := entityFieldIsList(entity, queryFilter.Field)
+				if queryFilter.Type == schema.QueryFilterContains && !isList {
+					return fmt.Errorf("entity %q query %q filter.Contains needs a field.Strings or field.Ints, %q is not one", entity.Name, query.Type, queryFilter.Field)
+				}
+				if queryFilter.Type != schema.QueryFilterContains && isList {
+					return fmt.Errorf("entity %q query %q list field %q can only be filtered with filter.Contains", entity.Name, query.Type, queryFilter.Field)
+				}
+				isLatLng := entityFieldHasType(entity, queryFilter.Field, schema.FieldTypeLatLng)
+				if queryFilter.Type == schema.QueryFilterNear && !isLatLng {
+					return fmt.Errorf("entity %q query %q filter.Near needs a field.LatLng, %q is not one", entity.Name, query.Type, queryFilter.Field)
+				}
+				if queryFilter.Type != schema.QueryFilterNear && isLatLng {
+					return fmt.Errorf("entity %q query %q latlng field %q can only be filtered with filter.Near", entity.Name, query.Type, queryFilter.Field)
+				}
+				isJSON := entityFieldHasType(entity, queryFilter.Field, schema.FieldTypeJSON)
+				if queryFilter.Type == schema.QueryFilterJSONPath && !isJSON {
+					return fmt.Errorf("entity %q query %q filter.JSONPath needs a json field, %q is not one", entity.Name, query.Type, queryFilter.Field)
+				}
+				// sqlite keeps decimals in TEXT, where a range would compare them as strings
+				isDecimal := entityFieldHasType(entity, queryFilter.Field, schema.FieldTypeDecimal)
+				if queryFilter.Type == schema.QueryFilterRange && isDecimal {
+					return fmt.Errorf("entity %q query %q can't take filter.Range on decimal field %q", entity.Name, query.Type, queryFilter.Field)
+				}
+				searchMode := queryFilter.Match != schema.SearchPattern || queryFilter.CaseInsensitive
+				if searchMode && !entityFieldHasType(entity, queryFilter.Field, schema.FieldTypeString) {
+					return fmt.Errorf("entity %q query %q search modes need a string field, %q is not one", entity.Name, query.Type, queryFilter.Field)
+				}
+			}
+
+			// results come back sorted by distance, which only fits one point
+			nearFilters := 0
+			for _, queryFilter := range query.Filters {
+				if queryFilter.Type == schema.QueryFilterNear {
+					nearFilters++
+				}
+			}
+			if nearFilters > 1 {
+				return fmt.Errorf("entity %q query %q can take only one filter.Near", entity.Name, query.Type)
+			}
+			if nearFilters > 0 && query.Stream {
+				return fmt.Errorf("entity %q query %q can't Stream with filter.Near, rows are sorted by distance after they are read", entity.Name, query.Type)
+			}
+			if nearFilters > 0 && query.OrderBy != "" {
+				return fmt.Errorf("entity %q query %q can't take order_by with filter.Near, rows are sorted by distance", entity.Name, query.Type)
+			}
+
+			for _, queryFilter := range query.Filters {
+				if queryFilter.Type != schema.QueryFilterAnyOf {
+					continue
+				}
+				first, _ := entity.GetFieldByName(queryFilter.Filters[0].Field)
+				for _, child := range queryFilter.Filters[1:] {
+					field, _ := entity.GetFieldByName(child.Field)
+					if field.Type != first.Type || field.Optional != first.Optional {
+						return fmt.Errorf("entity %q query %q filter.AnyOf fields %q and %q share one param and need the same type", entity.Name, query.Type, first.Name, field.Name)
+					}
+				}
 			}
 
 			if query.OrderBy != "" && !entityHasField(entity, query.OrderBy) {
@@ -334,6 +568,19 @@ func validateQueryFields(entity schema.Entity) error {
 	return nil
 }
 
+// flattenFilters replaces AnyOf groups with the filters inside them
+func flattenFilters(filters []schema.QueryFilter) []schema.QueryFilter {
+	flat := []schema.QueryFilter{}
+	for _, filter := range filters {
+		if filter.Type == schema.QueryFilterAnyOf {
+			flat = append(flat, filter.Filters...)
+			continue
+		}
+		flat = append(flat, filter)
+	}
+	return flat
+}
+
 func entityHasField(entity schema.Entity, fieldName string) bool {
 	for _, field := range entity.Fields {
 		if strings.EqualFold(field.Name, fieldName) {
@@ -354,6 +601,16 @@ func entityFieldIsVirtual(entity schema.Entity, fieldName string) bool {
 	return false
 }
 
+func entityFieldIsList(entity schema.Entity, fieldName string) bool {
+	for _, field := range entity.Fields {
+		if strings.EqualFold(field.Name, fieldName) {
+			return field.IsList()
+		}
+	}
+
+	return false
+}
+
 func entityFieldHasType(entity schema.Entity, fieldName string, fieldType schema.FieldType) bool {
 	for _, field := range entity.Fields {
 		if strings.EqualFold(field.Name, fieldName) {
@@ -0,0 +1,576 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/guntisdev/entlite/internal/schema"
+)
+
+const queryEntityTemplate = `package schema
+
+import (
+	"github.com/guntisdev/entlite/pkg/entlite"
+	"github.com/guntisdev/entlite/pkg/entlite/field"
+	"github.com/guntisdev/entlite/pkg/entlite/filter"
+	"github.com/guntisdev/entlite/pkg/entlite/query"
+)
+
+type Reading struct {
+	entlite.Schema
+}
+
+func (Reading) Contracts() []entlite.Contract {
+	return []entlite.Contract{
+		entlite.SQLC(),
+		entlite.PROTO(),
+	}
+}
+
+func (Reading) Fields() []entlite.Field {
+	return []entlite.Field{
+		field.Int("sensor_id"),
+		field.String("code"),
+		field.String("label"),
+		field.Time("recorded_at"),
+		field.JSON("meta"),
+		field.Strings("tags"),
+		field.LatLng("origin"),
+	}
+}
+
+func (Reading) Queries() []entlite.Query {
+	return []entlite.Query{
+		%s
+	}
+}
+`
+
+func parseQueryEntity(t *testing.T, queries string) (schema.Entity, error) {
+	t.Helper()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "reading.go")
+	source := strings.Replace(queryEntityTemplate, "%s", queries, 1)
+
+	if err := os.WriteFile(path, []byte(source), 0644); err != nil {
+		t.Fatalf("failed to write entity file: %v", err)
+	}
+
+	entities, err := ParseEntities([]DiscoveredEntity{{Name: "Reading", Path: path}}, nil)
+	if err != nil {
+		return schema.Entity{}, err
+	}
+	return entities[0], nil
+}
+
+func TestQueryStream(t *testing.T) {
+	tests := []struct {
+		name       string
+		queries    string
+		wantStream bool
+		wantName   string
+		wantErr    string
+	}{
+		{
+			name:       "streamed ListBy",
+			queries:    `query.ListBy(filter.Eq("sensor_id"), filter.Range("recorded_at")).Stream(),`,
+			wantStream: true,
+		},
+		{
+			name:       "streamed ListAll",
+			queries:    `query.ListAll().Stream(),`,
+			wantStream: true,
+		},
+		{
+			name:       "streamed and named",
+			queries:    `query.ListBy("sensor_id").Stream().Name("StreamReadings"),`,
+			wantStream: true,
+			wantName:   "StreamReadings",
+		},
+		{
+			name:    "not streamed",
+			queries: `query.ListBy("sensor_id"),`,
+		},
+		{
+			name:    "stream on Get",
+			queries: `query.Get().Stream(),`,
+			wantErr: "Stream is only supported for ListBy and ListAll queries",
+		},
+		{
+			name:    "stream with arguments",
+			queries: `query.ListAll().Stream(10),`,
+			wantErr: "Stream does not accept arguments",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entity, err := parseQueryEntity(t, tt.queries)
+
+			if tt.wantErr != "" {
+				if err == nil {
+					t.Fatalf("expected error containing %q, got nil", tt.wantErr)
+				}
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got: %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+
+			if len(entity.Queries) != 1 {
+				t.Fatalf("expected 1 query, got %d", len(entity.Queries))
+			}
+			if got := entity.Queries[0].Stream; got != tt.wantStream {
+				t.Errorf("Stream = %v, want %v", got, tt.wantStream)
+			}
+			if got := entity.Queries[0].Name; got != tt.wantName {
+				t.Errorf("Name = %q, want %q", got, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestCreateStreamQuery(t *testing.T) {
+	entity, err := parseQueryEntity(t, `query.CreateStream(),`)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(entity.Queries) != 1 || entity.Queries[0].Type != schema.QueryCreateStream {
+		t.Fatalf("expected a single create_stream query, got %+v", entity.Queries)
+	}
+
+	_, err = parseQueryEntity(t, `query.CreateStream(100),`)
+	if err == nil || !strings.Contains(err.Error(), "CreateStream does not accept arguments") {
+		t.Fatalf("expected argument error, got: %v", err)
+	}
+}
+
+func TestSearchFilterModes(t *testing.T) {
+	tests := []struct {
+		name                string
+		queries             string
+		wantMatch           schema.SearchMatch
+		wantCaseInsensitive bool
+		wantOptional        bool
+		wantErr             string
+	}{
+		{
+			name:    "plain search",
+			queries: `query.ListBy(filter.Search("label")),`,
+		},
+		{
+			name:      "contains",
+			queries:   `query.ListBy(filter.Search("label").Contains()),`,
+			wantMatch: schema.SearchContains,
+		},
+		{
+			name:                "prefix case insensitive optional",
+			queries:             `query.ListBy(filter.Search("label").Prefix().CaseInsensitive().Optional()),`,
+			wantMatch:           schema.SearchPrefix,
+			wantCaseInsensitive: true,
+			wantOptional:        true,
+		},
+		{
+			name:    "contains and prefix",
+			queries: `query.ListBy(filter.Search("label").Contains().Prefix()),`,
+			wantErr: "Contains and Prefix cannot be combined",
+		},
+		{
+			name:    "contains on eq",
+			queries: `query.ListBy(filter.Eq("label").Contains()),`,
+			wantErr: "Contains is only supported on filter.Search",
+		},
+		{
+			name:    "case insensitive with arguments",
+			queries: `query.ListBy(filter.Search("label").CaseInsensitive(true)),`,
+			wantErr: "CaseInsensitive does not accept arguments",
+		},
+		{
+			name:    "search mode on non-string field",
+			queries: `query.ListBy(filter.Search("sensor_id").Prefix()),`,
+			wantErr: "search modes need a string field",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entity, err := parseQueryEntity(t, tt.queries)
+
+			if tt.wantErr != "" {
+				if err == nil {
+					t.Fatalf("expected error containing %q, got nil", tt.wantErr)
+				}
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got: %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+
+			if len(entity.Queries) != 1 || len(entity.Queries[0].Filters) != 1 {
+				t.Fatalf("expected 1 query with 1 filter, got %+v", entity.Queries)
+			}
+			filter := entity.Queries[0].Filters[0]
+			if filter.Type != schema.QueryFilterSearch {
+				t.Errorf("Type = %q, want %q", filter.Type, schema.QueryFilterSearch)
+			}
+			if filter.Match != tt.wantMatch {
+				t.Errorf("Match = %q, want %q", filter.Match, tt.wantMatch)
+			}
+			if filter.CaseInsensitive != tt.wantCaseInsensitive {
+				t.Errorf("CaseInsensitive = %v, want %v", filter.CaseInsensitive, tt.wantCaseInsensitive)
+			}
+			if filter.Optional != tt.wantOptional {
+				t.Errorf("Optional = %v, want %v", filter.Optional, tt.wantOptional)
+			}
+		})
+	}
+}
+
+func TestAnyOfFilter(t *testing.T) {
+	entity, err := parseQueryEntity(t, `query.ListBy(filter.AnyOf(filter.Search("code").Contains(), filter.Search("label").Contains()).Optional(), filter.Eq("sensor_id")),`)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	filters := entity.Queries[0].Filters
+	if len(filters) != 2 {
+		t.Fatalf("expected 2 filters, got %+v", filters)
+	}
+	group := filters[0]
+	if group.Type != schema.QueryFilterAnyOf || group.Field != "code_or_label" || !group.Optional {
+		t.Errorf("unexpected group %+v", group)
+	}
+	if len(group.Filters) != 2 || group.Filters[0].Field != "code" || group.Filters[1].Field != "label" {
+		t.Errorf("unexpected group filters %+v", group.Filters)
+	}
+	for _, child := range group.Filters {
+		if child.Type != schema.QueryFilterSearch || child.Match != schema.SearchContains {
+			t.Errorf("unexpected child filter %+v", child)
+		}
+	}
+}
+
+func TestAnyOfFilterErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		queries string
+		wantErr string
+	}{
+		{
+			name:    "single filter",
+			queries: `query.ListBy(filter.AnyOf(filter.Search("code"))),`,
+			wantErr: "filter.AnyOf expects at least two filters",
+		},
+		{
+			name:    "range filter",
+			queries: `query.ListBy(filter.AnyOf(filter.Search("code"), filter.Range("recorded_at"))),`,
+			wantErr: "filter.AnyOf supports only filter.Search and filter.Eq",
+		},
+		{
+			name:    "mixed kinds",
+			queries: `query.ListBy(filter.AnyOf(filter.Search("code"), filter.Eq("label"))),`,
+			wantErr: "must be of the same kind",
+		},
+		{
+			name:    "mixed search modes",
+			queries: `query.ListBy(filter.AnyOf(filter.Search("code").Prefix(), filter.Search("label").Contains())),`,
+			wantErr: "must be of the same kind",
+		},
+		{
+			name:    "optional child",
+			queries: `query.ListBy(filter.AnyOf(filter.Search("code").Optional(), filter.Search("label"))),`,
+			wantErr: "make the group Optional",
+		},
+		{
+			name:    "different field types",
+			queries: `query.ListBy(filter.AnyOf(filter.Eq("code"), filter.Eq("sensor_id"))),`,
+			wantErr: "need the same type",
+		},
+		{
+			name:    "unknown field",
+			queries: `query.ListBy(filter.AnyOf(filter.Eq("code"), filter.Eq("missing"))),`,
+			wantErr: `filter references nonexisting field "missing"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseQueryEntity(t, tt.queries)
+			if err == nil {
+				t.Fatalf("expected error containing %q, got nil", tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestRangeFilterBounds(t *testing.T) {
+	tests := []struct {
+		name    string
+		queries string
+		want    schema.QueryFilter
+		wantErr string
+	}{
+		{
+			name:    "closed range",
+			queries: `query.ListBy(filter.Range("recorded_at")),`,
+			want:    schema.QueryFilter{Type: schema.QueryFilterRange, Field: "recorded_at"},
+		},
+		{
+			name:    "optional covers both bounds",
+			queries: `query.ListBy(filter.Range("recorded_at").Optional()),`,
+			want:    schema.QueryFilter{Type: schema.QueryFilterRange, Field: "recorded_at", Optional: true, OptionalMin: true, OptionalMax: true},
+		},
+		{
+			name:    "open above, exclusive max",
+			queries: `query.ListBy(filter.Range("recorded_at").OptionalMax().ExclusiveMax()),`,
+			want:    schema.QueryFilter{Type: schema.QueryFilterRange, Field: "recorded_at", OptionalMax: true, ExclusiveMax: true},
+		},
+		{
+			name:    "exclusive min",
+			queries: `query.ListBy(filter.Range("sensor_id").ExclusiveMin().OptionalMin()),`,
+			want:    schema.QueryFilter{Type: schema.QueryFilterRange, Field: "sensor_id", OptionalMin: true, ExclusiveMin: true},
+		},
+		{
+			name:    "bound modifier on eq",
+			queries: `query.ListBy(filter.Eq("sensor_id").OptionalMin()),`,
+			wantErr: "OptionalMin is only supported on filter.Range",
+		},
+		{
+			name:    "bound modifier with arguments",
+			queries: `query.ListBy(filter.Range("sensor_id").ExclusiveMax(true)),`,
+			wantErr: "ExclusiveMax does not accept arguments",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entity, err := parseQueryEntity(t, tt.queries)
+
+			if tt.wantErr != "" {
+				if err == nil {
+					t.Fatalf("expected error containing %q, got nil", tt.wantErr)
+				}
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got: %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+
+			if len(entity.Queries) != 1 || len(entity.Queries[0].Filters) != 1 {
+				t.Fatalf("expected 1 query with 1 filter, got %+v", entity.Queries)
+			}
+			if got := entity.Queries[0].Filters[0]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filter = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONPathFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		queries string
+		want    schema.QueryFilter
+		wantErr string
+	}{
+		{
+			name:    "single key",
+			queries: `query.ListBy(filter.JSONPath("meta", "theme")),`,
+			want:    schema.QueryFilter{Type: schema.QueryFilterJSONPath, Field: "meta", JSONPath: []string{"theme"}},
+		},
+		{
+			name:    "nested path with root, optional",
+			queries: `query.ListBy(filter.JSONPath("meta", "$.ui.theme").Optional()),`,
+			want:    schema.QueryFilter{Type: schema.QueryFilterJSONPath, Field: "meta", JSONPath: []string{"ui", "theme"}, Optional: true},
+		},
+		{
+			name:    "not a json field",
+			queries: `query.ListBy(filter.JSONPath("code", "theme")),`,
+			wantErr: `filter.JSONPath needs a json field, "code" is not one`,
+		},
+		{
+			name:    "missing path",
+			queries: `query.ListBy(filter.JSONPath("meta")),`,
+			wantErr: "filter.JSONPath expects a field and a path string",
+		},
+		{
+			name:    "empty path",
+			queries: `query.ListBy(filter.JSONPath("meta", "$")),`,
+			wantErr: `json path "$" has no keys`,
+		},
+		{
+			name:    "quote in key",
+			queries: `query.ListBy(filter.JSONPath("meta", "ui.the'me")),`,
+			wantErr: `key "the'me" must be letters, digits and underscores`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entity, err := parseQueryEntity(t, tt.queries)
+
+			if tt.wantErr != "" {
+				if err == nil {
+					t.Fatalf("expected error containing %q, got nil", tt.wantErr)
+				}
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got: %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+
+			if len(entity.Queries) != 1 || len(entity.Queries[0].Filters) != 1 {
+				t.Fatalf("expected 1 query with 1 filter, got %+v", entity.Queries)
+			}
+			if got := entity.Queries[0].Filters[0]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filter = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContainsFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		queries string
+		want    schema.QueryFilter
+		wantErr string
+	}{
+		{
+			name:    "list field",
+			queries: `query.ListBy(filter.Contains("tags")),`,
+			want:    schema.QueryFilter{Type: schema.QueryFilterContains, Field: "tags"},
+		},
+		{
+			name:    "optional",
+			queries: `query.ListBy(filter.Contains("tags").Optional()),`,
+			want:    schema.QueryFilter{Type: schema.QueryFilterContains, Field: "tags", Optional: true},
+		},
+		{
+			name:    "not a list field",
+			queries: `query.ListBy(filter.Contains("code")),`,
+			wantErr: `filter.Contains needs a field.Strings or field.Ints, "code" is not one`,
+		},
+		{
+			name:    "other filter on a list field",
+			queries: `query.ListBy(filter.Eq("tags")),`,
+			wantErr: `list field "tags" can only be filtered with filter.Contains`,
+		},
+		{
+			name:    "lookup by a list field",
+			queries: `query.GetBy("tags"),`,
+			wantErr: `can't look up by list field "tags"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entity, err := parseQueryEntity(t, tt.queries)
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+
+			if len(entity.Queries) != 1 || len(entity.Queries[0].Filters) != 1 {
+				t.Fatalf("expected 1 query with 1 filter, got %+v", entity.Queries)
+			}
+			if got := entity.Queries[0].Filters[0]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filter = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNearFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		queries string
+		wantErr string
+	}{
+		{
+			name:    "latlng field",
+			queries: `query.ListBy(filter.Near("origin"), filter.Eq("code")),`,
+		},
+		{
+			name:    "not a latlng field",
+			queries: `query.ListBy(filter.Near("code")),`,
+			wantErr: `filter.Near needs a field.LatLng, "code" is not one`,
+		},
+		{
+			name:    "other filter on a latlng field",
+			queries: `query.ListBy(filter.Eq("origin")),`,
+			wantErr: `latlng field "origin" can only be filtered with filter.Near`,
+		},
+		{
+			name:    "lookup by a latlng field",
+			queries: `query.GetBy("origin"),`,
+			wantErr: `can't look up by latlng field "origin", use filter.Near`,
+		},
+		{
+			name:    "optional",
+			queries: `query.ListBy(filter.Near("origin").Optional()),`,
+			wantErr: `Optional is not supported on filter.Near`,
+		},
+		{
+			name:    "twice",
+			queries: `query.ListBy(filter.Near("origin"), filter.Near("origin")),`,
+			wantErr: `can take only one filter.Near`,
+		},
+		{
+			name:    "streamed",
+			queries: `query.ListBy(filter.Near("origin")).Stream(),`,
+			wantErr: `can't Stream with filter.Near`,
+		},
+		{
+			name:    "ordered",
+			queries: `query.ListBy(filter.Near("origin")).OrderBy("recorded_at"),`,
+			wantErr: `can't take order_by with filter.Near`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entity, err := parseQueryEntity(t, tt.queries)
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+
+			want := schema.QueryFilter{Type: schema.QueryFilterNear, Field: "origin"}
+			if got := entity.Queries[0].Filters[0]; !reflect.DeepEqual(got, want) {
+				t.Errorf("filter = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
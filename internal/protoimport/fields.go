@@ -0,0 +1,226 @@
+package protoimport
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/guntisdev/entlite/internal/schemafile"
+)
+
+// scalarTypes are the proto scalars a field type generates as written
+var scalarTypes = map[descriptorpb.FieldDescriptorProto_Type]string{
+	descriptorpb.FieldDescriptorProto_TYPE_STRING: "String",
+	descriptorpb.FieldDescriptorProto_TYPE_BYTES:  "Byte",
+	descriptorpb.FieldDescriptorProto_TYPE_BOOL:   "Bool",
+	descriptorpb.FieldDescriptorProto_TYPE_INT32:  "Int",
+	descriptorpb.FieldDescriptorProto_TYPE_SINT32: "Int",
+	descriptorpb.FieldDescriptorProto_TYPE_INT64:  "Int64",
+	descriptorpb.FieldDescriptorProto_TYPE_SINT64: "Int64",
+	descriptorpb.FieldDescriptorProto_TYPE_DOUBLE: "Float",
+}
+
+// widenedTypes hold their values in a field type whose proto type differs
+var widenedTypes = map[descriptorpb.FieldDescriptorProto_Type]struct{ constructor, proto string }{
+	descriptorpb.FieldDescriptorProto_TYPE_UINT32:   {"Int64", "int64"},
+	descriptorpb.FieldDescriptorProto_TYPE_UINT64:   {"Int64", "int64"},
+	descriptorpb.FieldDescriptorProto_TYPE_FIXED32:  {"Int64", "int64"},
+	descriptorpb.FieldDescriptorProto_TYPE_FIXED64:  {"Int64", "int64"},
+	descriptorpb.FieldDescriptorProto_TYPE_SFIXED32: {"Int", "int32"},
+	descriptorpb.FieldDescriptorProto_TYPE_SFIXED64: {"Int64", "int64"},
+	descriptorpb.FieldDescriptorProto_TYPE_FLOAT:    {"Float", "double"},
+}
+
+// messageTypes are the well-known messages a field type generates, with the
+// modifier that picks it
+var messageTypes = map[string][]string{
+	"google.protobuf.Timestamp": {"Time"},
+	"google.protobuf.Duration":  {"Duration"},
+	"google.type.Date":          {"Date"},
+	"google.type.LatLng":        {"LatLng"},
+	"google.protobuf.Struct":    {"JSON", "ProtoStruct()"},
+	"google.protobuf.Value":     {"JSON", "ProtoValue()"},
+}
+
+// immutableTypes have Immutable()
+var immutableTypes = []string{"String", "Time", "Byte", "JSON", "Strings", "Ints", "Decimal", "Date", "Duration", "LatLng"}
+
+// addFields maps the fields of the message. The request messages of its
+// rpcs tell which fields the api only reads or only writes.
+func (b *entityBuilder) addFields(index int, reqs requests) {
+	msg := b.message
+	if !slices.ContainsFunc(msg.GetField(), func(fd *descriptorpb.FieldDescriptorProto) bool { return isID(fd.GetName()) }) {
+		b.report("no id field, entlite adds ID with the smallest free number")
+	}
+	if len(msg.GetReservedRange()) > 0 || len(msg.GetReservedName()) > 0 {
+		b.report("reserved numbers and names are not imported, entlite reserves the fields it removes once entlite.lock has them")
+	}
+
+	oneofs := map[int32]bool{}
+	for i, fd := range msg.GetField() {
+		if fd.OneofIndex != nil && !fd.GetProto3Optional() && !oneofs[fd.GetOneofIndex()] {
+			oneofs[fd.GetOneofIndex()] = true
+			b.report("oneof %s is imported as separate fields", msg.GetOneofDecl()[fd.GetOneofIndex()].GetName())
+		}
+		f, ok := b.field(fd, b.file.comment(index, i))
+		if !ok {
+			continue
+		}
+		name := fd.GetName()
+		if isID(name) {
+			if name != "ID" {
+				b.report("field %s is ID in entlite's proto, its json name changes", name)
+			}
+			b.fields = append(b.fields, f)
+			continue
+		}
+
+		if reqs.create != nil {
+			created, inCreate := findField(reqs.create, name)
+			_, inUpdate := findField(reqs.update, name)
+			switch {
+			case !inCreate && !inUpdate:
+				f.Modifiers = append(f.Modifiers, "Permissions(permissions.ReadOnly)")
+				if !fd.GetProto3Optional() {
+					b.report("field %s is never written by the api, give it a Default() or DefaultFunc()", name)
+				}
+			case inCreate && reqs.update != nil && !inUpdate:
+				if slices.Contains(immutableTypes, f.Constructor) {
+					f.Modifiers = append(f.Modifiers, "Immutable()")
+				} else {
+					b.report("field %s can't be updated, field.%s has no Immutable()", name, f.Constructor)
+				}
+			}
+			if inCreate && created.GetProto3Optional() && !fd.GetProto3Optional() {
+				b.report("field %s is optional in %s, give it the default it has with Default()", name, reqs.create.GetName())
+			}
+		}
+		b.fields = append(b.fields, f)
+	}
+
+	// a field the api writes but never returns, e.g. a password
+	if reqs.create == nil {
+		return
+	}
+	createIndex := b.file.messageIndex(reqs.create.GetName())
+	for i, fd := range reqs.create.GetField() {
+		if _, ok := findField(msg, fd.GetName()); ok || isID(fd.GetName()) {
+			continue
+		}
+		comment := ""
+		if createIndex >= 0 {
+			comment = b.file.comment(createIndex, i)
+		}
+		f, ok := b.field(fd, comment)
+		if !ok {
+			continue
+		}
+		f.Modifiers = append(f.Modifiers, "Permissions(permissions.WriteOnly)")
+		b.fields = append(b.fields, f)
+	}
+}
+
+// field is the field.* call of a proto field, false when no field type fits
+func (b *entityBuilder) field(fd *descriptorpb.FieldDescriptorProto, comment string) (schemafile.Field, bool) {
+	name := fd.GetName()
+	if isID(name) {
+		name = "id"
+	}
+	f := schemafile.Field{Name: name}
+
+	switch {
+	case fd.GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_REPEATED:
+		switch {
+		case b.isMapEntry(fd.GetTypeName()):
+			b.report("map field %s is not imported, a map has no field type", name)
+			return f, false
+		case fd.GetType() == descriptorpb.FieldDescriptorProto_TYPE_STRING:
+			f.Constructor = "Strings"
+		case fd.GetType() == descriptorpb.FieldDescriptorProto_TYPE_INT32:
+			f.Constructor = "Ints"
+		default:
+			b.report("repeated field %s of %s is not imported, only repeated string and int32 have a field type", name, protoTypeName(fd))
+			return f, false
+		}
+	case fd.Type != nil:
+		if constructor, ok := scalarTypes[fd.GetType()]; ok {
+			f.Constructor = constructor
+		} else if widened, ok := widenedTypes[fd.GetType()]; ok {
+			b.report("field %s is %s, imported as field.%s which is %s in proto, clients must move to it", name, protoTypeName(fd), widened.constructor, widened.proto)
+			f.Constructor = widened.constructor
+		} else {
+			b.report("field %s of %s is not imported, it has no field type", name, protoTypeName(fd))
+			return f, false
+		}
+	default:
+		typeName := strings.TrimPrefix(fd.GetTypeName(), ".")
+		local := b.file.localName(typeName)
+		if mapped, ok := messageTypes[typeName]; ok {
+			f.Constructor = mapped[0]
+			f.Modifiers = append(f.Modifiers, mapped[1:]...)
+		} else if b.file.enums[local] || b.hasNestedEnum(local) {
+			// an enum is an int32 on the wire
+			b.report("field %s is enum %s, imported as field.Int", name, local)
+			f.Constructor = "Int"
+		} else {
+			b.report("field %s of message %s is not imported, a message has no field type", name, local)
+			return f, false
+		}
+	}
+
+	f.Modifiers = append([]string{fmt.Sprintf("ProtoField(%d)", fd.GetNumber())}, f.Modifiers...)
+	if fd.GetProto3Optional() {
+		if f.Constructor == "Bool" {
+			b.report("field %s is an optional bool, field.Bool has no Optional()", name)
+		} else {
+			f.Modifiers = append(f.Modifiers, "Optional()")
+		}
+	}
+	if comment != "" {
+		f.Modifiers = append(f.Modifiers, fmt.Sprintf("Comment(%s)", strconv.Quote(comment)))
+	}
+	return f, true
+}
+
+func (b *entityBuilder) isMapEntry(typeName string) bool {
+	for _, nested := range b.message.GetNestedType() {
+		if nested.GetOptions().GetMapEntry() && strings.HasSuffix(typeName, nested.GetName()) {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *entityBuilder) hasNestedEnum(name string) bool {
+	for _, enum := range b.message.GetEnumType() {
+		if name == enum.GetName() || name == b.name+"."+enum.GetName() {
+			return true
+		}
+	}
+	return false
+}
+
+// findField finds a field of a message by name, msg may be nil
+func findField(msg *descriptorpb.DescriptorProto, name string) (*descriptorpb.FieldDescriptorProto, bool) {
+	for _, fd := range msg.GetField() {
+		if strings.EqualFold(fd.GetName(), name) {
+			return fd, true
+		}
+	}
+	return nil, false
+}
+
+// protoTypeName is the type of a field as written, e.g. uint32
+func protoTypeName(fd *descriptorpb.FieldDescriptorProto) string {
+	if fd.Type == nil {
+		return strings.TrimPrefix(fd.GetTypeName(), ".")
+	}
+	return strings.ToLower(strings.TrimPrefix(fd.GetType().String(), "TYPE_"))
+}
+
+func isID(name string) bool {
+	return strings.EqualFold(name, "id")
+}
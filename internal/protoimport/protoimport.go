@@ -0,0 +1,245 @@
+// Package protoimport reads the messages and services of a proto file and
+// writes the entlite schema that generates them, keeping the field numbers
+// deployed clients use.
+package protoimport
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bufbuild/protocompile/parser"
+	"github.com/bufbuild/protocompile/reporter"
+	"github.com/bufbuild/protocompile/sourceinfo"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/guntisdev/entlite/internal/schemafile"
+)
+
+// Entity is the schema file of one message and what of the message and its
+// rpcs it couldn't express. The schema still builds without those.
+type Entity struct {
+	Name     string
+	Source   []byte
+	Unmapped []string
+}
+
+// FileName is the schema file of the entity, e.g. user.go
+func (e Entity) FileName() string {
+	return strings.ToLower(e.Name) + ".go"
+}
+
+// file is a parsed proto file, its imports are not read
+type file struct {
+	name     string
+	pkg      string
+	messages map[string]*descriptorpb.DescriptorProto // top level, by name
+	order    []string                                 // top level message names
+	enums    map[string]bool
+	comments map[string]string // leading comments by source path
+}
+
+// Entities maps the messages of a proto file to schema files, in file order.
+// A message is an entity when an rpc returns it, or when nothing else uses
+// it. The second result are the rpcs of no entity.
+func Entities(filename string, src []byte) ([]Entity, []string, error) {
+	handler := reporter.NewHandler(nil)
+	node, err := parser.Parse(filename, bytes.NewReader(src), handler)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := parser.ResultFromAST(node, true, handler)
+	if err != nil {
+		return nil, nil, err
+	}
+	fd := result.FileDescriptorProto()
+
+	f := &file{
+		name:     filename,
+		pkg:      fd.GetPackage(),
+		messages: map[string]*descriptorpb.DescriptorProto{},
+		enums:    map[string]bool{},
+		comments: map[string]string{},
+	}
+	for _, msg := range fd.GetMessageType() {
+		f.messages[msg.GetName()] = msg
+		f.order = append(f.order, msg.GetName())
+	}
+	for _, enum := range fd.GetEnumType() {
+		f.enums[enum.GetName()] = true
+	}
+	for _, location := range sourceinfo.GenerateSourceInfo(node, nil).GetLocation() {
+		if comment := strings.TrimSpace(location.GetLeadingComments()); comment != "" {
+			f.comments[fmt.Sprint(location.GetPath())] = comment
+		}
+	}
+
+	names := f.entityNames(fd)
+	builders := map[string]*entityBuilder{}
+	for _, name := range names {
+		builders[name] = &entityBuilder{file: f, name: name, message: f.messages[name]}
+	}
+
+	// rpcs go to the entity they return, or the entity their service is named for
+	var orphans []string
+	for _, service := range fd.GetService() {
+		for _, method := range service.GetMethod() {
+			name := f.rpcEntity(service, method, names)
+			if name == "" {
+				orphans = append(orphans, fmt.Sprintf("rpc %s.%s returns no entity", service.GetName(), method.GetName()))
+				continue
+			}
+			builders[name].methods = append(builders[name].methods, method)
+		}
+	}
+
+	var entities []Entity
+	for _, name := range names {
+		entity, err := builders[name].build(f.messageIndex(name))
+		if err != nil {
+			return nil, nil, fmt.Errorf("message %s: %w", name, err)
+		}
+		entities = append(entities, entity)
+	}
+	return entities, orphans, nil
+}
+
+// entityNames are the messages an rpc returns, alone or in a list, and the
+// messages no rpc or message uses
+func (f *file) entityNames(fd *descriptorpb.FileDescriptorProto) []string {
+	returned := map[string]bool{}
+	used := map[string]bool{}
+	for _, service := range fd.GetService() {
+		for _, method := range service.GetMethod() {
+			used[f.localName(method.GetInputType())] = true
+			out := f.localName(method.GetOutputType())
+			used[out] = true
+			if _, ok := f.messages[out]; ok && !isRequestOrResponse(out) {
+				returned[out] = true
+			}
+			if strings.HasPrefix(method.GetName(), "List") {
+				if listed := f.listedMessage(out); listed != "" {
+					returned[listed] = true
+				}
+			}
+		}
+	}
+	for _, msg := range fd.GetMessageType() {
+		for _, field := range msg.GetField() {
+			used[f.localName(field.GetTypeName())] = true
+		}
+	}
+
+	var names []string
+	for _, msg := range fd.GetMessageType() {
+		name := msg.GetName()
+		if returned[name] || (!used[name] && !isRequestOrResponse(name)) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// rpcEntity is the entity an rpc serves, empty for none
+func (f *file) rpcEntity(service *descriptorpb.ServiceDescriptorProto, method *descriptorpb.MethodDescriptorProto, names []string) string {
+	out := f.localName(method.GetOutputType())
+	if slices.Contains(names, out) {
+		return out
+	}
+	if listed := f.listedMessage(out); slices.Contains(names, listed) {
+		return listed
+	}
+	if name := strings.TrimSuffix(service.GetName(), "Service"); slices.Contains(names, name) {
+		return name
+	}
+	// e.g. DeleteUserRequest, the longest name wins over a prefix of it
+	var found string
+	in := f.localName(method.GetInputType())
+	for _, name := range names {
+		if strings.Contains(in, name) && len(name) > len(found) {
+			found = name
+		}
+	}
+	return found
+}
+
+// listedMessage is the message a list response repeats, e.g. User of
+// ListAllUserResponse, empty when it isn't one
+func (f *file) listedMessage(name string) string {
+	msg, ok := f.messages[name]
+	if !ok {
+		return ""
+	}
+	var listed string
+	for _, field := range msg.GetField() {
+		if field.GetLabel() != descriptorpb.FieldDescriptorProto_LABEL_REPEATED || field.GetTypeName() == "" {
+			continue
+		}
+		if listed != "" {
+			return ""
+		}
+		listed = f.localName(field.GetTypeName())
+	}
+	return listed
+}
+
+// localName is a type name as written without the file's package, e.g.
+// .entlite.User and User are User
+func (f *file) localName(typeName string) string {
+	typeName = strings.TrimPrefix(typeName, ".")
+	if f.pkg != "" {
+		typeName = strings.TrimPrefix(typeName, f.pkg+".")
+	}
+	return typeName
+}
+
+// messageIndex is the position of a top level message, -1 for none
+func (f *file) messageIndex(name string) int {
+	return slices.Index(f.order, name)
+}
+
+// comment is the leading comment of a message (4) field (2), or of the message
+// when field is negative
+func (f *file) comment(message, field int) string {
+	if field < 0 {
+		return f.comments[fmt.Sprint([]int32{4, int32(message)})]
+	}
+	return f.comments[fmt.Sprint([]int32{4, int32(message), 2, int32(field)})]
+}
+
+func isRequestOrResponse(name string) bool {
+	return strings.HasSuffix(name, "Request") || strings.HasSuffix(name, "Response")
+}
+
+// entityBuilder writes the schema file of one message and its rpcs
+type entityBuilder struct {
+	file     *file
+	name     string
+	message  *descriptorpb.DescriptorProto
+	methods  []*descriptorpb.MethodDescriptorProto
+	fields   []schemafile.Field
+	queries  []string
+	unmapped []string
+}
+
+func (b *entityBuilder) report(format string, args ...any) {
+	b.unmapped = append(b.unmapped, fmt.Sprintf(format, args...))
+}
+
+func (b *entityBuilder) build(index int) (Entity, error) {
+	doc := b.file.comment(index, -1)
+	if doc == "" {
+		doc = fmt.Sprintf("%s is imported from message %s of %s", b.name, b.name, b.file.name)
+	}
+
+	reqs := b.matchQueries()
+	b.addFields(index, reqs)
+
+	file := schemafile.File{Name: b.name, Doc: doc, Fields: b.fields, Queries: b.queries}
+	source, err := file.Source()
+	if err != nil {
+		return Entity{}, err
+	}
+	return Entity{Name: b.name, Source: source, Unmapped: b.unmapped}, nil
+}
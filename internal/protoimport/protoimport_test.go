@@ -0,0 +1,185 @@
+package protoimport
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/guntisdev/entlite/internal/generator/proto"
+	"github.com/guntisdev/entlite/internal/parser"
+)
+
+const handWritten = `
+syntax = "proto3";
+
+package shop.v1;
+
+import "google/protobuf/timestamp.proto";
+import "google/protobuf/empty.proto";
+
+enum Status {
+  STATUS_UNSPECIFIED = 0;
+  STATUS_ACTIVE = 1;
+}
+
+// Product is something the shop sells
+message Product {
+  string id = 1;
+  // Shown in the catalog
+  string title = 2;
+  optional string description = 3;
+  uint32 stock = 4;
+  repeated string tags = 5;
+  Status status = 6;
+  map<string, string> labels = 7;
+  Dimensions size = 8;
+  google.protobuf.Timestamp created_at = 10;
+  reserved 9;
+}
+
+message Dimensions {
+  double width = 1;
+  double height = 2;
+}
+
+message GetProductRequest {
+  string id = 1;
+}
+
+message ListProductsRequest {
+  int32 limit = 1;
+  int32 offset = 2;
+  Status status = 3;
+}
+
+message ListProductsResponse {
+  repeated Product products = 1;
+}
+
+message SearchProductsRequest {
+  string query = 1;
+}
+
+service ProductService {
+  rpc GetProduct(GetProductRequest) returns (Product);
+  rpc ListProducts(ListProductsRequest) returns (ListProductsResponse);
+  rpc WatchProducts(google.protobuf.Empty) returns (stream Product);
+  rpc SearchProducts(SearchProductsRequest) returns (ListProductsResponse);
+}
+
+service HealthService {
+  rpc Check(google.protobuf.Empty) returns (google.protobuf.Empty);
+}
+`
+
+func TestEntitiesHandWritten(t *testing.T) {
+	entities, orphans, err := Entities("shop.proto", []byte(handWritten))
+	if err != nil {
+		t.Fatalf("Entities: %v", err)
+	}
+	if len(entities) != 1 || entities[0].Name != "Product" {
+		t.Fatalf("expected only Product, got %+v", entities)
+	}
+	if !slices.Equal(orphans, []string{"rpc HealthService.Check returns no entity"}) {
+		t.Errorf("orphans = %q", orphans)
+	}
+
+	product := string(entities[0].Source)
+	for _, want := range []string{
+		"// Product is something the shop sells\ntype Product struct",
+		`field.String("id").ProtoField(1),`,
+		`field.String("title").ProtoField(2).Comment("Shown in the catalog"),`,
+		`field.String("description").ProtoField(3).Optional(),`,
+		`field.Int64("stock").ProtoField(4),`,
+		`field.Strings("tags").ProtoField(5),`,
+		`field.Int("status").ProtoField(6),`,
+		`field.Time("created_at").ProtoField(10),`,
+		`query.Get().Name("GetProduct"),`,
+		`query.ListBy("status").Name("ListProducts"),`,
+		`query.ListAll().Name("WatchProducts").Stream(),`,
+		`// TODO rpc SearchProducts(SearchProductsRequest) returns (ListProductsResponse) has no CRUD shape`,
+	} {
+		if !strings.Contains(product, want) {
+			t.Errorf("product.go does not contain %s:\n%s", want, product)
+		}
+	}
+
+	wantUnmapped := []string{
+		"rpc SearchProducts has no CRUD shape, it is left as a comment in Queries()",
+		"reserved numbers and names are not imported, entlite reserves the fields it removes once entlite.lock has them",
+		"field id is ID in entlite's proto, its json name changes",
+		"field stock is uint32, imported as field.Int64 which is int64 in proto, clients must move to it",
+		"field status is enum Status, imported as field.Int",
+		"map field labels is not imported, a map has no field type",
+		"field size of message Dimensions is not imported, a message has no field type",
+	}
+	if !slices.Equal(entities[0].Unmapped, wantUnmapped) {
+		t.Errorf("unmapped = %q, want %q", entities[0].Unmapped, wantUnmapped)
+	}
+
+	if generated := generateProto(t, entities); !strings.Contains(generated, "  rpc GetProduct(GetProductRequest) returns (Product);") {
+		t.Errorf("generated proto has no GetProduct rpc:\n%s", generated)
+	}
+}
+
+var service = regexp.MustCompile(`(?s)service \w+ \{.*?\n\}`)
+
+// the proto entlite generates imports back to a schema generating the same rpcs
+func TestEntitiesRoundTrip(t *testing.T) {
+	original, err := os.ReadFile("../../examples/01-basic-entity/sqlite/ent/contract/proto/schema.proto")
+	if err != nil {
+		t.Fatalf("reading example: %v", err)
+	}
+	entities, _, err := Entities("schema.proto", original)
+	if err != nil {
+		t.Fatalf("Entities: %v", err)
+	}
+
+	generated := generateProto(t, entities)
+
+	// the rpcs entlite can't read back from a request are left out
+	want := strings.Replace(service.FindString(string(original)),
+		"  rpc FilterByAgeName(ListUserFilterByAgeNameRequest) returns (ListUserFilterByAgeNameResponse);\n", "", 1)
+	if got := service.FindString(generated); got != want {
+		t.Errorf("service changed:\n%s\nwant:\n%s", got, want)
+	}
+	for _, line := range []string{
+		`  string password = 5 [(buf.validate.field).required = true];`,
+		`  google.protobuf.Timestamp created_at = 11 [(buf.validate.field).required = true];`,
+	} {
+		if !strings.Contains(generated, line) {
+			t.Errorf("generated proto has no %s", line)
+		}
+	}
+}
+
+// generateProto parses the schema files and generates their proto
+func generateProto(t *testing.T, entities []Entity) string {
+	t.Helper()
+	schemaDir := t.TempDir()
+	for _, entity := range entities {
+		if err := os.WriteFile(filepath.Join(schemaDir, entity.FileName()), entity.Source, 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	discovered, err := parser.DiscoverEntities(schemaDir)
+	if err != nil {
+		t.Fatalf("DiscoverEntities: %v", err)
+	}
+	parsed, err := parser.ParseEntities(discovered, nil)
+	if err != nil {
+		t.Fatalf("ParseEntities: %v", err)
+	}
+	protoDir := t.TempDir()
+	if err := proto.Generate(parsed, protoDir, nil); err != nil {
+		t.Fatalf("proto.Generate: %v", err)
+	}
+	generated, err := os.ReadFile(filepath.Join(protoDir, "schema.proto"))
+	if err != nil {
+		t.Fatalf("reading generated proto: %v", err)
+	}
+	return string(generated)
+}
@@ -0,0 +1,209 @@
+package protoimport
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/guntisdev/entlite/internal/schema"
+	"github.com/guntisdev/entlite/internal/util"
+)
+
+// requests are the request messages of the matched rpcs that write fields
+type requests struct {
+	create *descriptorpb.DescriptorProto // of Create, else the item of CreateBulk or CreateStream
+	update *descriptorpb.DescriptorProto
+}
+
+// listParams are the paging fields entlite puts in front of ListBy params
+var listParams = []string{"limit", "offset"}
+
+// matchQueries turns the rpcs of the entity into queries where they have one
+// of the shapes the proto generator writes. The others are left as comments.
+func (b *entityBuilder) matchQueries() requests {
+	var reqs requests
+	for _, method := range b.methods {
+		query, ok := b.matchQuery(method, &reqs)
+		if !ok {
+			b.queries = append(b.queries, fmt.Sprintf("// TODO rpc %s has no CRUD shape", rpcSignature(method)))
+			b.report("rpc %s has no CRUD shape, it is left as a comment in Queries()", method.GetName())
+			continue
+		}
+		b.queries = append(b.queries, query)
+	}
+
+	// the four DefaultCRUD expands to, when none is renamed
+	crud := []string{"query.Create()", "query.Get()", "query.Update()", "query.Delete()"}
+	if !slices.ContainsFunc(crud, func(q string) bool { return !slices.Contains(b.queries, q) }) {
+		b.queries[slices.Index(b.queries, crud[0])] = "query.DefaultCRUD()"
+		b.queries = slices.DeleteFunc(b.queries, func(q string) bool { return slices.Contains(crud[1:], q) })
+	}
+	return reqs
+}
+
+// matchQuery is the query expression of an rpc, e.g. query.GetBy("email")
+func (b *entityBuilder) matchQuery(method *descriptorpb.MethodDescriptorProto, reqs *requests) (string, bool) {
+	name := method.GetName()
+	in := b.file.localName(method.GetInputType())
+	out := b.file.localName(method.GetOutputType())
+	inMsg := b.file.messages[in]
+	serverStream := method.GetServerStreaming()
+
+	query := schema.Query{}
+	switch {
+	case method.GetClientStreaming():
+		if serverStream {
+			return "", false
+		}
+		query.Type = schema.QueryCreateStream
+		if reqs.create == nil {
+			reqs.create = inMsg
+		}
+	case out == b.name && !serverStream:
+		switch {
+		case strings.HasPrefix(name, "Create"):
+			query.Type = schema.QueryCreate
+			reqs.create = inMsg
+		case strings.HasPrefix(name, "Update"):
+			query.Type = schema.QueryUpdate
+			reqs.update = inMsg
+		case strings.HasPrefix(name, "Get"):
+			params, ok := b.params(in, nil)
+			if !ok || len(params) == 0 {
+				return "", false
+			}
+			query.Type = schema.QueryGetBy
+			query.Fields = params
+		default:
+			return "", false
+		}
+	case out == "google.protobuf.Empty":
+		params, ok := b.params(in, nil)
+		switch {
+		case !ok || !strings.HasPrefix(name, "Delete"):
+			return "", false
+		case len(params) == 0:
+			query.Type = schema.QueryDeleteAll
+		case len(params) == 1 && isID(params[0]):
+			query.Type = schema.QueryDelete
+		default:
+			return "", false
+		}
+	case (out == b.name && serverStream) || b.file.listedMessage(out) == b.name:
+		if !serverStream && strings.HasPrefix(name, "Create") {
+			item := b.bulkItem(inMsg)
+			if item == nil {
+				return "", false
+			}
+			query.Type = schema.QueryCreateBulk
+			if reqs.create == nil {
+				reqs.create = item
+			}
+			break
+		}
+		params, ok := b.params(in, listParams)
+		if !ok {
+			return "", false
+		}
+		query.Type = schema.QueryListAll
+		if len(params) > 0 {
+			query.Type = schema.QueryListBy
+			query.Fields = params
+		}
+		query.Stream = serverStream
+	default:
+		return "", false
+	}
+
+	if query.Type == schema.QueryGetBy && len(query.Fields) == 1 && isID(query.Fields[0]) {
+		query.Fields = []string{"ID"}
+	}
+	expr := queryExpr(query)
+	if name != util.GenQueryRpcName(query, b.name) {
+		expr += fmt.Sprintf(".Name(%q)", name)
+	}
+	if query.Stream {
+		expr += ".Stream()"
+	}
+	return expr, true
+}
+
+// queryExpr is the query.* call of a query, without Name or Stream
+func queryExpr(query schema.Query) string {
+	switch query.Type {
+	case schema.QueryCreate:
+		return "query.Create()"
+	case schema.QueryCreateBulk:
+		return "query.CreateBulk()"
+	case schema.QueryCreateStream:
+		return "query.CreateStream()"
+	case schema.QueryUpdate:
+		return "query.Update()"
+	case schema.QueryDelete:
+		return "query.Delete()"
+	case schema.QueryDeleteAll:
+		return "query.DeleteAll()"
+	case schema.QueryGetBy:
+		if len(query.Fields) == 1 && query.Fields[0] == "ID" {
+			return "query.Get()"
+		}
+		return fmt.Sprintf("query.GetBy(%s)", quoteAll(query.Fields))
+	case schema.QueryListBy:
+		return fmt.Sprintf("query.ListBy(%s)", quoteAll(query.Fields))
+	default:
+		return "query.ListAll()"
+	}
+}
+
+// params are the fields of a request message, less skip, when each is a field
+// of the entity. google.protobuf.Empty has none.
+func (b *entityBuilder) params(request string, skip []string) ([]string, bool) {
+	msg, ok := b.file.messages[request]
+	if !ok {
+		return nil, request == "google.protobuf.Empty"
+	}
+	var params []string
+	for _, fd := range msg.GetField() {
+		if slices.Contains(skip, fd.GetName()) {
+			continue
+		}
+		if _, ok := findField(b.message, fd.GetName()); !ok {
+			return nil, false
+		}
+		params = append(params, fd.GetName())
+	}
+	return params, true
+}
+
+// bulkItem is the message a CreateBulk request repeats, nil when the request
+// isn't one
+func (b *entityBuilder) bulkItem(request *descriptorpb.DescriptorProto) *descriptorpb.DescriptorProto {
+	if len(request.GetField()) != 1 || request.GetField()[0].GetLabel() != descriptorpb.FieldDescriptorProto_LABEL_REPEATED {
+		return nil
+	}
+	return b.file.messages[b.file.localName(request.GetField()[0].GetTypeName())]
+}
+
+// rpcSignature is an rpc as written, e.g. Search(SearchRequest) returns (stream User)
+func rpcSignature(method *descriptorpb.MethodDescriptorProto) string {
+	stream := func(streaming bool) string {
+		if streaming {
+			return "stream "
+		}
+		return ""
+	}
+	return fmt.Sprintf("%s(%s%s) returns (%s%s)", method.GetName(),
+		stream(method.GetClientStreaming()), strings.TrimPrefix(method.GetInputType(), "."),
+		stream(method.GetServerStreaming()), strings.TrimPrefix(method.GetOutputType(), "."))
+}
+
+func quoteAll(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = strconv.Quote(name)
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,161 @@
+// Package protolock keeps the proto field numbers entlite has handed out, so
+// a field keeps its number when fields are added or moved around it.
+package protolock
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/guntisdev/entlite/internal/schema"
+)
+
+// FileName sits next to the schema directory and is committed with it
+const FileName = "entlite.lock"
+
+type Lock struct {
+	// Messages is keyed by proto message name, an entity's is the entity name
+	Messages map[string]Message `json:"messages"`
+
+	changed bool
+}
+
+type Message struct {
+	// Fields maps field name to number. A removed field stays, its number is
+	// never given to another field.
+	Fields map[string]int `json:"fields"`
+}
+
+// Read loads a lock file, an empty lock when there is none yet
+func Read(path string) (*Lock, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Lock{Messages: map[string]Message{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var lock Lock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	if lock.Messages == nil {
+		lock.Messages = map[string]Message{}
+	}
+	return &lock, nil
+}
+
+func (l *Lock) Write(path string) error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Fields returns the locked numbers of a message, nil on a nil lock
+func (l *Lock) Fields(message string) map[string]int {
+	if l == nil {
+		return nil
+	}
+	return l.Messages[message].Fields
+}
+
+// Changed reports numbers added since the lock was read
+func (l *Lock) Changed() bool {
+	return l != nil && l.changed
+}
+
+// Record adds the numbers of new entity fields. The entry of a renamed
+// entity or field moves to the new name, its number isn't reserved.
+func (l *Lock) Record(entities []schema.Entity) {
+	for _, entity := range entities {
+		l.rename(entity.RenamedFrom, entity.Name)
+		for _, field := range entity.Fields {
+			if number, ok := l.Fields(entity.Name)[field.RenamedFrom]; ok && number == field.ProtoField {
+				delete(l.Messages[entity.Name].Fields, field.RenamedFrom)
+				l.changed = true
+			}
+			l.set(entity.Name, field.Name, field.ProtoField)
+		}
+	}
+}
+
+// rename moves a message entry to its new name, unless the new name has one
+func (l *Lock) rename(from, to string) {
+	message, ok := l.Messages[from]
+	if from == "" || !ok {
+		return
+	}
+	if _, exists := l.Messages[to]; exists {
+		return
+	}
+	l.Messages[to] = message
+	delete(l.Messages, from)
+	l.changed = true
+}
+
+// Assign numbers the fields of a message in order. A locked field keeps its
+// number, a new one gets the smallest the message never used. A nil lock
+// numbers them 1, 2, 3...
+func (l *Lock) Assign(message string, fields []string) map[string]int {
+	locked := l.Fields(message)
+	used := make(map[int]bool)
+	for _, number := range locked {
+		used[number] = true
+	}
+
+	numbers := make(map[string]int, len(fields))
+	next := 1
+	for _, name := range fields {
+		if number, ok := locked[name]; ok {
+			numbers[name] = number
+			continue
+		}
+		for used[next] {
+			next++
+		}
+		numbers[name] = next
+		used[next] = true
+		if l != nil {
+			l.set(message, name, next)
+		}
+	}
+	return numbers
+}
+
+// Field is a field name and its number
+type Field struct {
+	Name   string
+	Number int
+}
+
+// Removed lists the locked fields of a message that are not in fields, by
+// number. They are reserved so no later field takes their number or name.
+func (l *Lock) Removed(message string, fields []string) []Field {
+	var removed []Field
+	for name, number := range l.Fields(message) {
+		if !slices.Contains(fields, name) {
+			removed = append(removed, Field{Name: name, Number: number})
+		}
+	}
+	slices.SortFunc(removed, func(a, b Field) int { return cmp.Compare(a.Number, b.Number) })
+	return removed
+}
+
+func (l *Lock) set(message, field string, number int) {
+	locked := l.Messages[message]
+	if locked.Fields == nil {
+		locked.Fields = map[string]int{}
+		l.Messages[message] = locked
+	}
+	if current, ok := locked.Fields[field]; ok && current == number {
+		return
+	}
+	locked.Fields[field] = number
+	l.changed = true
+}
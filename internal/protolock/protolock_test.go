@@ -0,0 +1,58 @@
+package protolock
+
+import (
+	"maps"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestAssignKeepsRequestNumbers(t *testing.T) {
+	lock, err := Read(filepath.Join(t.TempDir(), FileName))
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	first := lock.Assign("ListUserRequest", []string{"limit", "offset", "name", "age"})
+	if want := map[string]int{"limit": 1, "offset": 2, "name": 3, "age": 4}; !maps.Equal(first, want) {
+		t.Fatalf("first Assign = %v, want %v", first, want)
+	}
+	if !lock.Changed() {
+		t.Fatalf("new numbers should mark the lock changed")
+	}
+
+	// email is added before age and name is dropped
+	params := []string{"limit", "offset", "email", "age"}
+	second := lock.Assign("ListUserRequest", params)
+	if want := map[string]int{"limit": 1, "offset": 2, "email": 5, "age": 4}; !maps.Equal(second, want) {
+		t.Fatalf("second Assign = %v, want %v", second, want)
+	}
+
+	removed := lock.Removed("ListUserRequest", params)
+	if want := []Field{{Name: "name", Number: 3}}; !slices.Equal(removed, want) {
+		t.Fatalf("Removed = %v, want %v", removed, want)
+	}
+
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := lock.Write(path); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	reread, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if !maps.Equal(reread.Fields("ListUserRequest"), lock.Fields("ListUserRequest")) || reread.Changed() {
+		t.Fatalf("lock did not round trip: %v", reread.Messages)
+	}
+}
+
+func TestAssignWithoutLock(t *testing.T) {
+	var lock *Lock
+	numbers := lock.Assign("ListUserRequest", []string{"limit", "offset", "name"})
+	if want := map[string]int{"limit": 1, "offset": 2, "name": 3}; !maps.Equal(numbers, want) {
+		t.Fatalf("Assign = %v, want %v", numbers, want)
+	}
+	if len(lock.Removed("ListUserRequest", nil)) != 0 {
+		t.Fatalf("a nil lock has no removed fields")
+	}
+}
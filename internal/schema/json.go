@@ -0,0 +1,92 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// fieldJSON is how a Field is kept in a schema snapshot. DefaultFunc and
+// Validate hold the name of the function the DSL passed, e.g. time.Now.
+type fieldJSON struct {
+	fieldAlias
+	DefaultValue json.RawMessage `json:",omitempty"`
+	DefaultFunc  string          `json:",omitempty"`
+	Validate     string          `json:",omitempty"`
+}
+
+type fieldAlias Field
+
+func (f Field) MarshalJSON() ([]byte, error) {
+	out := fieldJSON{fieldAlias: fieldAlias(f)}
+	if f.DefaultValue != nil {
+		value := f.DefaultValue
+		// a Duration is an int64 of nanoseconds, strconv keeps all its digits
+		if d, ok := value.(time.Duration); ok {
+			value = json.Number(strconv.FormatInt(int64(d), 10))
+		}
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("field %q default: %w", f.Name, err)
+		}
+		out.DefaultValue = raw
+	}
+	if f.DefaultFunc != nil {
+		out.DefaultFunc = fmt.Sprint(f.DefaultFunc())
+	}
+	if f.Validate != nil {
+		out.Validate = fmt.Sprint(f.Validate())
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON restores a default to the Go type the parser gives it, so
+// generators format it the same as on a freshly parsed schema
+func (f *Field) UnmarshalJSON(data []byte) error {
+	var in fieldJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	*f = Field(in.fieldAlias)
+
+	if in.DefaultFunc != "" {
+		name := in.DefaultFunc
+		f.DefaultFunc = func() any { return name }
+	}
+	if in.Validate != "" {
+		name := in.Validate
+		f.Validate = func() any { return name }
+	}
+	if len(in.DefaultValue) == 0 {
+		return nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(in.DefaultValue))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return fmt.Errorf("field %q default: %w", f.Name, err)
+	}
+	number, ok := value.(json.Number)
+	if !ok {
+		f.DefaultValue = value
+		return nil
+	}
+
+	switch f.Type {
+	case FieldTypeFloat:
+		v, err := strconv.ParseFloat(number.String(), 32)
+		f.DefaultValue = float32(v)
+		return err
+	case FieldTypeDuration:
+		v, err := number.Int64()
+		f.DefaultValue = time.Duration(v)
+		return err
+	default:
+		v, err := strconv.Atoi(number.String())
+		f.DefaultValue = v
+		return err
+	}
+}
@@ -51,11 +51,12 @@ func FilterPROTO(entities []Entity) []Entity {
 }
 
 type Entity struct {
-	Name      string
-	Fields    []Field
-	Contracts []Contract
-	Queries   []Query
-	Indexes   []Index
+	Name        string
+	Fields      []Field
+	Contracts   []Contract
+	Queries     []Query
+	Indexes     []Index
+	RenamedFrom string // old entity name, its table and lock entry move to Name
 }
 
 func (e Entity) GetIdField() Field {
@@ -78,6 +79,22 @@ func (e Entity) GetFieldByName(name string) (Field, bool) {
 	return Field{}, false
 }
 
+// IsLowerIndexed reports a field covered by a Lower() index, lookups on it
+// compare lowercased values.
+func (e Entity) IsLowerIndexed(fieldName string) bool {
+	for _, idx := range e.Indexes {
+		if !idx.Lower {
+			continue
+		}
+		for _, column := range idx.Columns {
+			if strings.EqualFold(column.Name, fieldName) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 type Field struct {
 	Name         string
 	Type         FieldType
@@ -91,12 +108,46 @@ type Field struct {
 	Immutable    bool
 	Optional     bool
 	Validate     func() any
+	GoType       string        // JSONOf fields only: the Go type held in the json, e.g. logic.Settings
+	ProtoJSON    ProtoJSONType // json fields only: how the field travels in proto
+	Precision    int           // decimal fields only: total digits
+	Scale        int           // decimal fields only: digits after the decimal point
+	RenamedFrom  string        // old field name, its column is renamed and it keeps its proto number
+	Backfill     string        // Go function filling existing rows when a migration adds the column, e.g. logic.ComputeSlug
+	BackfillSQL  string        // SQL expression filling existing rows when a migration adds the column
 }
 
 func (f Field) IsID() bool {
 	return strings.ToLower(f.Name) == "id"
 }
 
+// IsTypedJSON reports a JSONOf field, generated code handles it as its Go type
+func (f Field) IsTypedJSON() bool {
+	return f.Type == FieldTypeJSON && f.GoType != ""
+}
+
+// IsList reports a field.Strings/field.Ints field
+func (f Field) IsList() bool {
+	return f.Type == FieldTypeStrings || f.Type == FieldTypeInts
+}
+
+// ElemType is the type of a list field's elements, e.g. what filter.Contains binds
+func (f Field) ElemType() FieldType {
+	switch f.Type {
+	case FieldTypeStrings:
+		return FieldTypeString
+	case FieldTypeInts:
+		return FieldTypeInt
+	}
+	return f.Type
+}
+
+// LatLngColumns are the two columns a field.LatLng is stored in, e.g.
+// position_lat and position_lng
+func (f Field) LatLngColumns() (string, string) {
+	return f.Name + "_lat", f.Name + "_lng"
+}
+
 // IsVirtual reports a field that live only in proto and not in sqlc
 func (f Field) IsVirtual() bool {
 	return f.Permissions&(permissions.DbRead|permissions.DbWrite) == 0
@@ -105,14 +156,29 @@ func (f Field) IsVirtual() bool {
 type FieldType string
 
 const (
-	FieldTypeString FieldType = "string"
-	FieldTypeInt    FieldType = "int32"
-	FieldTypeInt64  FieldType = "int64"
-	FieldTypeFloat  FieldType = "float64"
-	FieldTypeBool   FieldType = "bool"
-	FieldTypeTime   FieldType = "time"
-	FieldTypeByte   FieldType = "[]byte"
-	FieldTypeJSON   FieldType = "json"
+	FieldTypeString   FieldType = "string"
+	FieldTypeInt      FieldType = "int32"
+	FieldTypeInt64    FieldType = "int64"
+	FieldTypeFloat    FieldType = "float64"
+	FieldTypeBool     FieldType = "bool"
+	FieldTypeTime     FieldType = "time"
+	FieldTypeByte     FieldType = "[]byte"
+	FieldTypeJSON     FieldType = "json"
+	FieldTypeStrings  FieldType = "[]string"
+	FieldTypeInts     FieldType = "[]int32"
+	FieldTypeDecimal  FieldType = "decimal"
+	FieldTypeDate     FieldType = "date"
+	FieldTypeDuration FieldType = "duration"
+	FieldTypeLatLng   FieldType = "latlng"
+)
+
+// ProtoJSONType is the proto type of a json field
+type ProtoJSONType string
+
+const (
+	ProtoJSONText   ProtoJSONType = ""       // string with the raw json text
+	ProtoJSONStruct ProtoJSONType = "struct" // google.protobuf.Struct
+	ProtoJSONValue  ProtoJSONType = "value"  // google.protobuf.Value
 )
 
 type Contract struct {
@@ -133,27 +199,80 @@ type Query struct {
 	Count   bool
 	OrderBy string
 	Name    string // custom query name; empty means auto-generated
+	Stream  bool   // list queries only: rows go out over a server-streaming rpc
 }
 
 type QueryFilter struct {
-	Type     QueryFilterType
-	Field    string
-	Optional bool
+	Type            QueryFilterType
+	Field           string
+	Optional        bool
+	Match           SearchMatch   // search filters only
+	CaseInsensitive bool          // search filters only
+	Filters         []QueryFilter // anyof only: the ORed filters, all bound to Field as one param
+	OptionalMin     bool          // range filters only: min_ bound may be null
+	OptionalMax     bool          // range filters only: max_ bound may be null
+	ExclusiveMin    bool          // range filters only: > instead of >=
+	ExclusiveMax    bool          // range filters only: < instead of <=
+	JSONPath        []string      // jsonpath filters only: keys into the json Field
+}
+
+// ParamName is the query param a filter binds. It's the field itself except
+// for jsonpath filters, which add the path keys, e.g. settings_theme.
+func (f QueryFilter) ParamName() string {
+	if f.Type == QueryFilterJSONPath {
+		return JSONPathName(f.Field, f.JSONPath)
+	}
+	return f.Field
+}
+
+// IsOpenRange reports a range filter that can't be written as a plain BETWEEN
+func (f QueryFilter) IsOpenRange() bool {
+	return f.Type == QueryFilterRange && (f.OptionalMin || f.OptionalMax || f.ExclusiveMin || f.ExclusiveMax)
 }
 
 type QueryFilterType string
 
 const (
-	QueryFilterRange  QueryFilterType = "range"
-	QueryFilterSearch QueryFilterType = "search"
-	QueryFilterEq     QueryFilterType = "eq"
+	QueryFilterRange    QueryFilterType = "range"
+	QueryFilterSearch   QueryFilterType = "search"
+	QueryFilterEq       QueryFilterType = "eq"
+	QueryFilterAnyOf    QueryFilterType = "anyof"
+	QueryFilterJSONPath QueryFilterType = "jsonpath"
+	QueryFilterContains QueryFilterType = "contains"
+	QueryFilterNear     QueryFilterType = "near"
+)
+
+// JSONPathName joins a json field and path keys, e.g. settings_ui_theme
+func JSONPathName(field string, path []string) string {
+	return strings.Join(append([]string{field}, path...), "_")
+}
+
+// AnyOfParam names the single param an AnyOf group shares, e.g. code_or_label
+func AnyOfParam(filters []QueryFilter) string {
+	fields := make([]string, len(filters))
+	for i, filter := range filters {
+		fields[i] = filter.Field
+	}
+	return strings.Join(fields, "_or_")
+}
+
+// SearchMatch is how a search value becomes a LIKE pattern
+type SearchMatch string
+
+const (
+	SearchPattern  SearchMatch = ""         // value is the pattern itself
+	SearchContains SearchMatch = "contains" // %value%, wildcards escaped
+	SearchPrefix   SearchMatch = "prefix"   // value%, wildcards escaped
 )
 
 type Index struct {
-	Type    IndexType
-	Columns []IndexColumn
-	Unique  bool
-	Name    string
+	Type     IndexType
+	Columns  []IndexColumn
+	Unique   bool
+	Name     string
+	Where    string   // partial index predicate, raw SQL
+	Lower    bool     // index LOWER() of the columns, all string fields
+	JSONPath []string // index the value at these keys of its single json column
 }
 
 func (i Index) FieldNames() []string {
@@ -179,12 +298,13 @@ const (
 type QueryType string
 
 const (
-	QueryCreate     QueryType = "create"
-	QueryCreateBulk QueryType = "create_bulk"
-	QueryUpdate     QueryType = "update"
-	QueryDelete     QueryType = "delete"
-	QueryDeleteAll  QueryType = "delete_all"
-	QueryGetBy      QueryType = "get_by"
-	QueryListBy     QueryType = "list_by"
-	QueryListAll    QueryType = "list_all"
+	QueryCreate       QueryType = "create"
+	QueryCreateBulk   QueryType = "create_bulk"
+	QueryCreateStream QueryType = "create_stream"
+	QueryUpdate       QueryType = "update"
+	QueryDelete       QueryType = "delete"
+	QueryDeleteAll    QueryType = "delete_all"
+	QueryGetBy        QueryType = "get_by"
+	QueryListBy       QueryType = "list_by"
+	QueryListAll      QueryType = "list_all"
 )
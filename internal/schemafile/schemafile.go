@@ -0,0 +1,100 @@
+// Package schemafile writes the Go source of an entlite schema file, the
+// importers build one from a database or a proto file.
+package schemafile
+
+import (
+	"fmt"
+	"go/format"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// File is one schema file, an entity with its fields, indexes and queries
+type File struct {
+	Name    string
+	Doc     string // comment above the type, without the //
+	Fields  []Field
+	Indexes []string // index.* expressions
+	Queries []string // query.* expressions, or // lines left as a note
+}
+
+// Field is one field.* call of Fields()
+type Field struct {
+	Constructor string // e.g. String, Decimal
+	Name        string
+	Modifiers   []string // e.g. Optional(), Default(0)
+}
+
+var (
+	stringLiteral = regexp.MustCompile(`"(?:[^"\\\n]|\\.)*"`)
+	lineComment   = regexp.MustCompile(`//[^\n]*`)
+)
+
+// packages of entlite the body may use besides field, imported when it does
+var packages = []struct{ name, path string }{
+	{"filter", "github.com/guntisdev/entlite/pkg/entlite/filter"},
+	{"index", "github.com/guntisdev/entlite/pkg/entlite/index"},
+	{"permissions", "github.com/guntisdev/entlite/pkg/entlite/permissions"},
+	{"query", "github.com/guntisdev/entlite/pkg/entlite/query"},
+}
+
+// Source is the gofmt-ed file
+func (f File) Source() ([]byte, error) {
+	var body strings.Builder
+	for line := range strings.Lines(f.Doc) {
+		fmt.Fprintf(&body, "// %s\n", strings.TrimRight(line, "\n"))
+	}
+	fmt.Fprintf(&body, "type %s struct {\n\tentlite.Schema\n}\n\n", f.Name)
+	fmt.Fprintf(&body, "func (%s) Contracts() []entlite.Contract {\n\treturn []entlite.Contract{\n\t\tentlite.SQLC(),\n\t\tentlite.PROTO(),\n\t}\n}\n\n", f.Name)
+
+	fmt.Fprintf(&body, "func (%s) Fields() []entlite.Field {\n\treturn []entlite.Field{\n", f.Name)
+	for _, field := range f.Fields {
+		fmt.Fprintf(&body, "\t\tfield.%s(%s)", field.Constructor, strconv.Quote(field.Name))
+		for _, modifier := range field.Modifiers {
+			body.WriteString("." + modifier)
+		}
+		body.WriteString(",\n")
+	}
+	body.WriteString("\t}\n}\n")
+
+	writeList(&body, f.Name, "Queries", "Query", f.Queries)
+	writeList(&body, f.Name, "Indexes", "Index", f.Indexes)
+
+	// a package named only in a comment or a string isn't used
+	code := lineComment.ReplaceAllString(stringLiteral.ReplaceAllString(body.String(), `""`), "")
+	uses := func(pkg string) bool { return strings.Contains(code, pkg+".") }
+
+	var src strings.Builder
+	src.WriteString("package schema\n\nimport (\n")
+	if uses("time") {
+		src.WriteString("\t\"time\"\n\n")
+	}
+	src.WriteString("\t\"github.com/guntisdev/entlite/pkg/entlite\"\n")
+	src.WriteString("\t\"github.com/guntisdev/entlite/pkg/entlite/field\"\n")
+	for _, pkg := range packages {
+		if uses(pkg.name) {
+			fmt.Fprintf(&src, "\t%q\n", pkg.path)
+		}
+	}
+	src.WriteString(")\n\n")
+	src.WriteString(body.String())
+
+	return format.Source([]byte(src.String()))
+}
+
+// writeList writes a method returning the expressions, a // line stays a comment
+func writeList(body *strings.Builder, entity, method, elem string, exprs []string) {
+	if len(exprs) == 0 {
+		return
+	}
+	fmt.Fprintf(body, "\nfunc (%s) %s() []entlite.%s {\n\treturn []entlite.%s{\n", entity, method, elem, elem)
+	for _, expr := range exprs {
+		if strings.HasPrefix(expr, "//") {
+			fmt.Fprintf(body, "\t\t%s\n", expr)
+			continue
+		}
+		fmt.Fprintf(body, "\t\t%s,\n", expr)
+	}
+	body.WriteString("\t}\n}\n")
+}
@@ -0,0 +1,44 @@
+// Package snapshot keeps the parsed schema a migration was generated from, the
+// next migration is the diff against it.
+package snapshot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/guntisdev/entlite/internal/schema"
+)
+
+// FileName sits next to the migrations, golang-migrate and goose skip it
+const FileName = "schema_snapshot.json"
+
+type Snapshot struct {
+	Entities []schema.Entity `json:"entities"`
+}
+
+// Read loads the entities of a snapshot, none when there is no snapshot yet
+func Read(path string) ([]schema.Entity, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("reading snapshot %s: %w", path, err)
+	}
+	return snapshot.Entities, nil
+}
+
+func Write(path string, entities []schema.Entity) error {
+	data, err := json.MarshalIndent(Snapshot{Entities: entities}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("writing snapshot %s: %w", path, err)
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
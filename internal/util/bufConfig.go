@@ -1,6 +1,7 @@
 package util
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"strings"
@@ -53,3 +54,56 @@ func GetBufConfigFromYaml(bufYamlPath string) (*BufGenConfig, error) {
 
 	return nil, fmt.Errorf("no protocolbuffers/go plugin found in buf.gen.yaml")
 }
+
+// GoogleAPIsDep is the buf module with google/type, which protoc doesn't
+// bundle. Date and LatLng fields import it.
+const GoogleAPIsDep = "buf.build/googleapis/googleapis"
+
+// EnsureBufDep adds the module to the deps of buf.yaml unless it is listed
+// already. Reports whether the file was rewritten.
+func EnsureBufDep(bufYamlPath, dep string) (bool, error) {
+	data, err := os.ReadFile(bufYamlPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to read buf.yaml: %w", err)
+	}
+
+	// edit the node tree rather than a struct so comments and unknown keys survive
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return false, fmt.Errorf("failed to parse buf.yaml: %w", err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return false, fmt.Errorf("buf.yaml is empty")
+	}
+
+	deps := yamlMappingValue(doc.Content[0], "deps")
+	if deps == nil {
+		deps = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		doc.Content[0].Content = append(doc.Content[0].Content, yamlScalar("deps", "!!str"), deps)
+	}
+	if deps.Kind != yaml.SequenceNode {
+		return false, fmt.Errorf("deps in buf.yaml is not a list")
+	}
+	for _, existing := range deps.Content {
+		// a pinned dep is <module>:<commit or label>
+		if name, _, _ := strings.Cut(existing.Value, ":"); name == dep {
+			return false, nil
+		}
+	}
+	deps.Content = append(deps.Content, yamlScalar(dep, "!!str"))
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return false, fmt.Errorf("failed to write buf.yaml: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return false, fmt.Errorf("failed to write buf.yaml: %w", err)
+	}
+
+	if err := os.WriteFile(bufYamlPath, buf.Bytes(), 0644); err != nil {
+		return false, fmt.Errorf("failed to write buf.yaml: %w", err)
+	}
+	return true, nil
+}
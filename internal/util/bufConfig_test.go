@@ -0,0 +1,77 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEnsureBufDep(t *testing.T) {
+	yamlContent := `version: v2
+modules:
+  - path: contract/proto
+lint:
+  use:
+    - STANDARD
+# protovalidate rules are imported by every schema.proto
+deps:
+  - buf.build/bufbuild/protovalidate
+`
+	tmpFile := filepath.Join(t.TempDir(), "buf.yaml")
+	if err := os.WriteFile(tmpFile, []byte(yamlContent), 0644); err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+
+	updated, err := EnsureBufDep(tmpFile, GoogleAPIsDep)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !updated {
+		t.Fatal("Expected googleapis to be added")
+	}
+
+	data, err := os.ReadFile(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to read back buf.yaml: %v", err)
+	}
+	content := string(data)
+	for _, want := range []string{
+		`# protovalidate rules are imported by every schema.proto`,
+		"deps:\n  - buf.build/bufbuild/protovalidate\n  - buf.build/googleapis/googleapis\n",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("Expected buf.yaml to contain %q, got:\n%s", want, content)
+		}
+	}
+
+	updated, err = EnsureBufDep(tmpFile, GoogleAPIsDep)
+	if err != nil || updated {
+		t.Errorf("Expected second run to leave buf.yaml alone, got updated=%v err=%v", updated, err)
+	}
+
+	// a pinned dep counts as listed
+	pinned := "version: v2\ndeps:\n  - buf.build/googleapis/googleapis:e7f8d366f5264595bcc4cd4139af9973\n"
+	if err := os.WriteFile(tmpFile, []byte(pinned), 0644); err != nil {
+		t.Fatalf("Failed to write buf.yaml: %v", err)
+	}
+	updated, err = EnsureBufDep(tmpFile, GoogleAPIsDep)
+	if err != nil || updated {
+		t.Errorf("Expected a pinned googleapis to be kept, got updated=%v err=%v", updated, err)
+	}
+
+	// no deps yet
+	if err := os.WriteFile(tmpFile, []byte("version: v2\n"), 0644); err != nil {
+		t.Fatalf("Failed to write buf.yaml: %v", err)
+	}
+	if updated, err := EnsureBufDep(tmpFile, GoogleAPIsDep); err != nil || !updated {
+		t.Fatalf("Expected deps to be added, got updated=%v err=%v", updated, err)
+	}
+	data, err = os.ReadFile(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to read back buf.yaml: %v", err)
+	}
+	if want := "version: v2\ndeps:\n  - buf.build/googleapis/googleapis\n"; string(data) != want {
+		t.Errorf("Expected buf.yaml %q, got %q", want, string(data))
+	}
+}
@@ -26,7 +26,7 @@ func FieldsToStr(fields []string) string {
 func FiltersToStr(filters []schema.QueryFilter) string {
 	var builder strings.Builder
 	for _, filter := range filters {
-		for _, part := range strings.Split(filter.Field, "_") {
+		for _, part := range strings.Split(filter.ParamName(), "_") {
 			if part == "" {
 				continue
 			}
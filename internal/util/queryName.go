@@ -20,6 +20,8 @@ func GenQueryName(query schema.Query, entityName string) string {
 		return fmt.Sprintf("Create%s", entityName)
 	case schema.QueryCreateBulk:
 		return fmt.Sprintf("CreateBulk%s", entityName)
+	case schema.QueryCreateStream:
+		return fmt.Sprintf("CreateStream%s", entityName)
 	case schema.QueryUpdate:
 		return fmt.Sprintf("Update%s", entityName)
 	case schema.QueryDelete:
@@ -47,6 +49,8 @@ func GenQueryRpcName(query schema.Query, entityName string) string {
 		return "Create"
 	case schema.QueryCreateBulk:
 		return "CreateBulk"
+	case schema.QueryCreateStream:
+		return "CreateStream"
 	case schema.QueryUpdate:
 		return "Update"
 	case schema.QueryDelete:
@@ -1,6 +1,7 @@
 package util
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 
@@ -61,3 +62,128 @@ func GetSqlcConfigFromYaml(sqlcYamlPath string) (*SqlcGenConfig, error) {
 		Dialect:  dialect,
 	}, nil
 }
+
+// jsonGoType is what sqlc generates for json columns once overridden. Left alone
+// postgres gives pqtype.NullRawMessage for a nullable JSONB column, an extra dependency.
+const jsonGoType = "encoding/json.RawMessage"
+
+// JSONDBType is the column type json fields are stored as, empty on sqlite
+// which keeps them as TEXT.
+func JSONDBType(dialect schema.SQLDialect) string {
+	switch dialect {
+	case schema.PostgreSQL:
+		return "jsonb"
+	case schema.MySQL:
+		return "json"
+	}
+	return ""
+}
+
+// EnsureJSONOverrides adds the sqlc overrides mapping the dialect's json column
+// type to json.RawMessage, nullable or not, to the first sql block of sqlc.yaml.
+// Overrides already there for that type are kept as they are. Reports whether
+// the file was rewritten.
+func EnsureJSONOverrides(sqlcYamlPath string, dialect schema.SQLDialect) (bool, error) {
+	dbType := JSONDBType(dialect)
+	if dbType == "" {
+		return false, nil
+	}
+
+	data, err := os.ReadFile(sqlcYamlPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to read sqlc.yaml: %w", err)
+	}
+
+	// edit the node tree rather than a struct so comments and unknown keys survive
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return false, fmt.Errorf("failed to parse sqlc.yaml: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return false, fmt.Errorf("sqlc.yaml is empty")
+	}
+
+	sqlBlocks := yamlMappingValue(doc.Content[0], "sql")
+	if sqlBlocks == nil || sqlBlocks.Kind != yaml.SequenceNode || len(sqlBlocks.Content) == 0 {
+		return false, fmt.Errorf("no SQL configurations found in sqlc.yaml")
+	}
+	goGen := yamlMappingValue(yamlMappingValue(sqlBlocks.Content[0], "gen"), "go")
+	if goGen == nil || goGen.Kind != yaml.MappingNode {
+		return false, fmt.Errorf("gen.go not specified in sqlc.yaml")
+	}
+
+	overrides := yamlMappingValue(goGen, "overrides")
+	if overrides == nil {
+		overrides = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		goGen.Content = append(goGen.Content, yamlScalar("overrides", "!!str"), overrides)
+	}
+
+	changed := false
+	for _, nullable := range []bool{false, true} {
+		if hasOverride(overrides, dbType, nullable) {
+			continue
+		}
+		override := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		override.Content = append(override.Content,
+			yamlScalar("db_type", "!!str"), yamlQuoted(dbType),
+			yamlScalar("go_type", "!!str"), yamlQuoted(jsonGoType),
+		)
+		if nullable {
+			override.Content = append(override.Content, yamlScalar("nullable", "!!str"), yamlScalar("true", "!!bool"))
+		}
+		overrides.Content = append(overrides.Content, override)
+		changed = true
+	}
+	if !changed {
+		return false, nil
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return false, fmt.Errorf("failed to write sqlc.yaml: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return false, fmt.Errorf("failed to write sqlc.yaml: %w", err)
+	}
+
+	if err := os.WriteFile(sqlcYamlPath, buf.Bytes(), 0644); err != nil {
+		return false, fmt.Errorf("failed to write sqlc.yaml: %w", err)
+	}
+	return true, nil
+}
+
+func hasOverride(overrides *yaml.Node, dbType string, nullable bool) bool {
+	for _, override := range overrides.Content {
+		overrideType := yamlMappingValue(override, "db_type")
+		if overrideType == nil || overrideType.Value != dbType {
+			continue
+		}
+		overrideNullable := yamlMappingValue(override, "nullable")
+		if (overrideNullable != nil && overrideNullable.Value == "true") == nullable {
+			return true
+		}
+	}
+	return false
+}
+
+func yamlMappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func yamlScalar(value, tag string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: value}
+}
+
+func yamlQuoted(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value, Style: yaml.DoubleQuotedStyle}
+}
@@ -3,6 +3,7 @@ package util
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/guntisdev/entlite/internal/schema"
@@ -129,3 +130,63 @@ sql: []`,
 		})
 	}
 }
+
+func TestEnsureJSONOverrides(t *testing.T) {
+	yamlContent := `version: "2"
+sql:
+  - schema: "contract/sqlc/schema.sql"
+    queries: "contract/sqlc/queries.sql"
+    engine: "postgresql" # postgresql or sqlite or mysql
+    gen:
+      go:
+        package: "internal"
+        out: "gen/db/internal"
+        overrides:
+          - db_type: "jsonb"
+            go_type: "string"
+`
+	tmpFile := filepath.Join(t.TempDir(), "sqlc.yaml")
+	if err := os.WriteFile(tmpFile, []byte(yamlContent), 0644); err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+
+	updated, err := EnsureJSONOverrides(tmpFile, schema.PostgreSQL)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !updated {
+		t.Fatal("Expected the nullable override to be added")
+	}
+
+	data, err := os.ReadFile(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to read back sqlc.yaml: %v", err)
+	}
+	content := string(data)
+	for _, want := range []string{
+		`# postgresql or sqlite or mysql`,
+		`go_type: "string"`,
+		"go_type: \"encoding/json.RawMessage\"\n            nullable: true",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("Expected sqlc.yaml to contain %q, got:\n%s", want, content)
+		}
+	}
+	if strings.Count(content, `db_type: "jsonb"`) != 2 {
+		t.Errorf("Expected the user's override to be kept and one added, got:\n%s", content)
+	}
+
+	if _, err := GetSqlcConfigFromYaml(tmpFile); err != nil {
+		t.Errorf("Expected rewritten sqlc.yaml to stay readable, got: %v", err)
+	}
+
+	updated, err = EnsureJSONOverrides(tmpFile, schema.PostgreSQL)
+	if err != nil || updated {
+		t.Errorf("Expected second run to leave sqlc.yaml alone, got updated=%v err=%v", updated, err)
+	}
+
+	updated, err = EnsureJSONOverrides(tmpFile, schema.SQLite)
+	if err != nil || updated {
+		t.Errorf("Expected sqlite to need no overrides, got updated=%v err=%v", updated, err)
+	}
+}
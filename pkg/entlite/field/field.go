@@ -1,8 +1,10 @@
 package field
 
 import (
+	"context"
 	"time"
 
+	"github.com/guntisdev/entlite/pkg/entlite/migrate"
 	"github.com/guntisdev/entlite/pkg/entlite/permissions"
 )
 
@@ -13,6 +15,15 @@ type StringFieldBuilder interface {
 	DefaultFunc(func() string) StringFieldBuilder
 	ProtoField(int) StringFieldBuilder
 	Comment(string) StringFieldBuilder
+	// RenamedFrom is the old name of a renamed field, it keeps its column
+	// data and proto number
+	RenamedFrom(string) StringFieldBuilder
+	// Backfill computes the value of existing rows when a migration adds
+	// the field NOT NULL without a default, in batches from migrate.Up
+	Backfill(func(context.Context, migrate.Row) (string, error)) StringFieldBuilder
+	// BackfillSQL sets existing rows to an SQL expression of the row when a
+	// migration adds the field NOT NULL without a default, e.g. lower(title)
+	BackfillSQL(string) StringFieldBuilder
 	Permissions(permissions.Permission) StringFieldBuilder
 	Immutable() StringFieldBuilder
 	Optional() StringFieldBuilder
@@ -29,6 +40,9 @@ type StringField struct {
 	defaultFunc func() string
 	protoField  *int
 	comment     *string
+	renamedFrom string
+	backfill    func(context.Context, migrate.Row) (string, error)
+	backfillSQL string
 	permissions permissions.Permission
 	immutable   bool
 	optional    bool
@@ -63,6 +77,18 @@ func (f *StringField) GetComment() *string {
 	return f.comment
 }
 
+func (f *StringField) GetRenamedFrom() string {
+	return f.renamedFrom
+}
+
+func (f *StringField) GetBackfill() func(context.Context, migrate.Row) (string, error) {
+	return f.backfill
+}
+
+func (f *StringField) GetBackfillSQL() string {
+	return f.backfillSQL
+}
+
 func (f *StringField) GetPermissions() permissions.Permission {
 	return f.permissions
 }
@@ -107,6 +133,23 @@ func (f *StringField) Comment(text string) StringFieldBuilder {
 	return f
 }
 
+func (f *StringField) RenamedFrom(name string) StringFieldBuilder {
+	f.renamedFrom = name
+	return f
+}
+
+func (f *StringField) Backfill(fn func(context.Context, migrate.Row) (string, error)) StringFieldBuilder {
+	f.backfill = fn
+	f.backfillSQL = ""
+	return f
+}
+
+func (f *StringField) BackfillSQL(expr string) StringFieldBuilder {
+	f.backfillSQL = expr
+	f.backfill = nil
+	return f
+}
+
 func (f *StringField) Permissions(permission permissions.Permission) StringFieldBuilder {
 	f.permissions = permission
 	return f
@@ -132,6 +175,9 @@ type BoolFieldBuilder interface {
 	Default(bool) BoolFieldBuilder
 	ProtoField(int) BoolFieldBuilder
 	Comment(string) BoolFieldBuilder
+	RenamedFrom(string) BoolFieldBuilder
+	Backfill(func(context.Context, migrate.Row) (bool, error)) BoolFieldBuilder
+	BackfillSQL(string) BoolFieldBuilder
 	Permissions(permissions.Permission) BoolFieldBuilder
 	Validate(func(bool) bool) BoolFieldBuilder
 
@@ -144,6 +190,9 @@ type BoolField struct {
 	defaultVal  *bool
 	protoField  *int
 	comment     *string
+	renamedFrom string
+	backfill    func(context.Context, migrate.Row) (bool, error)
+	backfillSQL string
 	permissions permissions.Permission
 	validate    func(bool) bool
 }
@@ -166,6 +215,18 @@ func (f *BoolField) GetComment() *string {
 	return f.comment
 }
 
+func (f *BoolField) GetRenamedFrom() string {
+	return f.renamedFrom
+}
+
+func (f *BoolField) GetBackfill() func(context.Context, migrate.Row) (bool, error) {
+	return f.backfill
+}
+
+func (f *BoolField) GetBackfillSQL() string {
+	return f.backfillSQL
+}
+
 func (f *BoolField) GetPermissions() permissions.Permission {
 	return f.permissions
 }
@@ -189,6 +250,23 @@ func (f *BoolField) Comment(text string) BoolFieldBuilder {
 	return f
 }
 
+func (f *BoolField) RenamedFrom(name string) BoolFieldBuilder {
+	f.renamedFrom = name
+	return f
+}
+
+func (f *BoolField) Backfill(fn func(context.Context, migrate.Row) (bool, error)) BoolFieldBuilder {
+	f.backfill = fn
+	f.backfillSQL = ""
+	return f
+}
+
+func (f *BoolField) BackfillSQL(expr string) BoolFieldBuilder {
+	f.backfillSQL = expr
+	f.backfill = nil
+	return f
+}
+
 func (f *BoolField) Permissions(permission permissions.Permission) BoolFieldBuilder {
 	f.permissions = permission
 	return f
@@ -205,6 +283,9 @@ type IntFieldBuilder interface {
 	Default(int32) IntFieldBuilder
 	ProtoField(int) IntFieldBuilder
 	Comment(string) IntFieldBuilder
+	RenamedFrom(string) IntFieldBuilder
+	Backfill(func(context.Context, migrate.Row) (int32, error)) IntFieldBuilder
+	BackfillSQL(string) IntFieldBuilder
 	Permissions(permissions.Permission) IntFieldBuilder
 	Optional() IntFieldBuilder
 	Validate(func(int32) bool) IntFieldBuilder
@@ -218,6 +299,9 @@ type IntField struct {
 	defaultVal  *int32
 	protoField  *int
 	comment     *string
+	renamedFrom string
+	backfill    func(context.Context, migrate.Row) (int32, error)
+	backfillSQL string
 	permissions permissions.Permission
 	optional    bool
 	validate    func(int32) bool
@@ -241,6 +325,18 @@ func (f *IntField) GetComment() *string {
 	return f.comment
 }
 
+func (f *IntField) GetRenamedFrom() string {
+	return f.renamedFrom
+}
+
+func (f *IntField) GetBackfill() func(context.Context, migrate.Row) (int32, error) {
+	return f.backfill
+}
+
+func (f *IntField) GetBackfillSQL() string {
+	return f.backfillSQL
+}
+
 func (f *IntField) GetPermissions() permissions.Permission {
 	return f.permissions
 }
@@ -268,6 +364,23 @@ func (f *IntField) Comment(text string) IntFieldBuilder {
 	return f
 }
 
+func (f *IntField) RenamedFrom(name string) IntFieldBuilder {
+	f.renamedFrom = name
+	return f
+}
+
+func (f *IntField) Backfill(fn func(context.Context, migrate.Row) (int32, error)) IntFieldBuilder {
+	f.backfill = fn
+	f.backfillSQL = ""
+	return f
+}
+
+func (f *IntField) BackfillSQL(expr string) IntFieldBuilder {
+	f.backfillSQL = expr
+	f.backfill = nil
+	return f
+}
+
 func (f *IntField) Permissions(permission permissions.Permission) IntFieldBuilder {
 	f.permissions = permission
 	return f
@@ -288,6 +401,9 @@ type Int64FieldBuilder interface {
 	Default(int64) Int64FieldBuilder
 	ProtoField(int) Int64FieldBuilder
 	Comment(string) Int64FieldBuilder
+	RenamedFrom(string) Int64FieldBuilder
+	Backfill(func(context.Context, migrate.Row) (int64, error)) Int64FieldBuilder
+	BackfillSQL(string) Int64FieldBuilder
 	Permissions(permissions.Permission) Int64FieldBuilder
 	Optional() Int64FieldBuilder
 	Validate(func(int64) bool) Int64FieldBuilder
@@ -301,6 +417,9 @@ type Int64Field struct {
 	defaultVal  *int64
 	protoField  *int
 	comment     *string
+	renamedFrom string
+	backfill    func(context.Context, migrate.Row) (int64, error)
+	backfillSQL string
 	permissions permissions.Permission
 	optional    bool
 	validate    func(int64) bool
@@ -324,6 +443,18 @@ func (f *Int64Field) GetComment() *string {
 	return f.comment
 }
 
+func (f *Int64Field) GetRenamedFrom() string {
+	return f.renamedFrom
+}
+
+func (f *Int64Field) GetBackfill() func(context.Context, migrate.Row) (int64, error) {
+	return f.backfill
+}
+
+func (f *Int64Field) GetBackfillSQL() string {
+	return f.backfillSQL
+}
+
 func (f *Int64Field) GetPermissions() permissions.Permission {
 	return f.permissions
 }
@@ -351,6 +482,23 @@ func (f *Int64Field) Comment(text string) Int64FieldBuilder {
 	return f
 }
 
+func (f *Int64Field) RenamedFrom(name string) Int64FieldBuilder {
+	f.renamedFrom = name
+	return f
+}
+
+func (f *Int64Field) Backfill(fn func(context.Context, migrate.Row) (int64, error)) Int64FieldBuilder {
+	f.backfill = fn
+	f.backfillSQL = ""
+	return f
+}
+
+func (f *Int64Field) BackfillSQL(expr string) Int64FieldBuilder {
+	f.backfillSQL = expr
+	f.backfill = nil
+	return f
+}
+
 func (f *Int64Field) Permissions(permission permissions.Permission) Int64FieldBuilder {
 	f.permissions = permission
 	return f
@@ -371,6 +519,9 @@ type FloatFieldBuilder interface {
 	Default(float64) FloatFieldBuilder
 	ProtoField(int) FloatFieldBuilder
 	Comment(string) FloatFieldBuilder
+	RenamedFrom(string) FloatFieldBuilder
+	Backfill(func(context.Context, migrate.Row) (float64, error)) FloatFieldBuilder
+	BackfillSQL(string) FloatFieldBuilder
 	Permissions(permissions.Permission) FloatFieldBuilder
 	Optional() FloatFieldBuilder
 	Validate(func(float64) bool) FloatFieldBuilder
@@ -383,6 +534,9 @@ type FloatField struct {
 	defaultVal  *float64
 	protoField  *int
 	comment     *string
+	renamedFrom string
+	backfill    func(context.Context, migrate.Row) (float64, error)
+	backfillSQL string
 	permissions permissions.Permission
 	optional    bool
 	validate    func(float64) bool
@@ -406,6 +560,18 @@ func (f *FloatField) GetComment() *string {
 	return f.comment
 }
 
+func (f *FloatField) GetRenamedFrom() string {
+	return f.renamedFrom
+}
+
+func (f *FloatField) GetBackfill() func(context.Context, migrate.Row) (float64, error) {
+	return f.backfill
+}
+
+func (f *FloatField) GetBackfillSQL() string {
+	return f.backfillSQL
+}
+
 func (f *FloatField) GetPermissions() permissions.Permission {
 	return f.permissions
 }
@@ -433,6 +599,23 @@ func (f *FloatField) Comment(text string) FloatFieldBuilder {
 	return f
 }
 
+func (f *FloatField) RenamedFrom(name string) FloatFieldBuilder {
+	f.renamedFrom = name
+	return f
+}
+
+func (f *FloatField) Backfill(fn func(context.Context, migrate.Row) (float64, error)) FloatFieldBuilder {
+	f.backfill = fn
+	f.backfillSQL = ""
+	return f
+}
+
+func (f *FloatField) BackfillSQL(expr string) FloatFieldBuilder {
+	f.backfillSQL = expr
+	f.backfill = nil
+	return f
+}
+
 func (f *FloatField) Permissions(permission permissions.Permission) FloatFieldBuilder {
 	f.permissions = permission
 	return f
@@ -454,6 +637,9 @@ type TimeFieldBuilder interface {
 	DefaultFunc(func() time.Time) TimeFieldBuilder
 	ProtoField(int) TimeFieldBuilder
 	Comment(string) TimeFieldBuilder
+	RenamedFrom(string) TimeFieldBuilder
+	Backfill(func(context.Context, migrate.Row) (time.Time, error)) TimeFieldBuilder
+	BackfillSQL(string) TimeFieldBuilder
 	Permissions(permissions.Permission) TimeFieldBuilder
 	Immutable() TimeFieldBuilder
 	Optional() TimeFieldBuilder
@@ -468,6 +654,9 @@ type TimeField struct {
 	defaultFunc func() time.Time
 	protoField  *int
 	comment     *string
+	renamedFrom string
+	backfill    func(context.Context, migrate.Row) (time.Time, error)
+	backfillSQL string
 	permissions permissions.Permission
 	immutable   bool
 	optional    bool
@@ -496,6 +685,18 @@ func (f *TimeField) GetComment() *string {
 	return f.comment
 }
 
+func (f *TimeField) GetRenamedFrom() string {
+	return f.renamedFrom
+}
+
+func (f *TimeField) GetBackfill() func(context.Context, migrate.Row) (time.Time, error) {
+	return f.backfill
+}
+
+func (f *TimeField) GetBackfillSQL() string {
+	return f.backfillSQL
+}
+
 func (f *TimeField) GetPermissions() permissions.Permission {
 	return f.permissions
 }
@@ -534,6 +735,23 @@ func (f *TimeField) Comment(text string) TimeFieldBuilder {
 	return f
 }
 
+func (f *TimeField) RenamedFrom(name string) TimeFieldBuilder {
+	f.renamedFrom = name
+	return f
+}
+
+func (f *TimeField) Backfill(fn func(context.Context, migrate.Row) (time.Time, error)) TimeFieldBuilder {
+	f.backfill = fn
+	f.backfillSQL = ""
+	return f
+}
+
+func (f *TimeField) BackfillSQL(expr string) TimeFieldBuilder {
+	f.backfillSQL = expr
+	f.backfill = nil
+	return f
+}
+
 func (f *TimeField) Permissions(permission permissions.Permission) TimeFieldBuilder {
 	f.permissions = permission
 	return f
@@ -561,6 +779,9 @@ type ByteFieldBuilder interface {
 	Immutable() ByteFieldBuilder
 	ProtoField(int) ByteFieldBuilder
 	Comment(string) ByteFieldBuilder
+	RenamedFrom(string) ByteFieldBuilder
+	Backfill(func(context.Context, migrate.Row) ([]byte, error)) ByteFieldBuilder
+	BackfillSQL(string) ByteFieldBuilder
 	Permissions(permissions.Permission) ByteFieldBuilder
 	DefaultFunc(func() []byte) ByteFieldBuilder
 	Validate(func([]byte) bool) ByteFieldBuilder
@@ -575,6 +796,9 @@ type ByteField struct {
 	immutable   bool
 	protoField  *int
 	comment     *string
+	renamedFrom string
+	backfill    func(context.Context, migrate.Row) ([]byte, error)
+	backfillSQL string
 	permissions permissions.Permission
 	defaultFunc func() []byte
 	validate    func([]byte) bool
@@ -606,6 +830,18 @@ func (f *ByteField) GetComment() *string {
 	return f.comment
 }
 
+func (f *ByteField) GetRenamedFrom() string {
+	return f.renamedFrom
+}
+
+func (f *ByteField) GetBackfill() func(context.Context, migrate.Row) ([]byte, error) {
+	return f.backfill
+}
+
+func (f *ByteField) GetBackfillSQL() string {
+	return f.backfillSQL
+}
+
 func (f *ByteField) GetPermissions() permissions.Permission {
 	return f.permissions
 }
@@ -643,6 +879,23 @@ func (f *ByteField) Comment(text string) ByteFieldBuilder {
 	return f
 }
 
+func (f *ByteField) RenamedFrom(name string) ByteFieldBuilder {
+	f.renamedFrom = name
+	return f
+}
+
+func (f *ByteField) Backfill(fn func(context.Context, migrate.Row) ([]byte, error)) ByteFieldBuilder {
+	f.backfill = fn
+	f.backfillSQL = ""
+	return f
+}
+
+func (f *ByteField) BackfillSQL(expr string) ByteFieldBuilder {
+	f.backfillSQL = expr
+	f.backfill = nil
+	return f
+}
+
 func (f *ByteField) Permissions(permission permissions.Permission) ByteFieldBuilder {
 	f.permissions = permission
 	return f
@@ -664,11 +917,17 @@ type JSONFieldBuilder interface {
 	Immutable() JSONFieldBuilder
 	ProtoField(int) JSONFieldBuilder
 	Comment(string) JSONFieldBuilder
+	RenamedFrom(string) JSONFieldBuilder
 	Permissions(permissions.Permission) JSONFieldBuilder
 	// Default takes raw json text, e.g. `{}` or `{"theme":"dark"}`
 	Default(string) JSONFieldBuilder
 	DefaultFunc(func() string) JSONFieldBuilder
 	Validate(func(string) bool) JSONFieldBuilder
+	// ProtoStruct sends the field as google.protobuf.Struct instead of text,
+	// it must hold a json object. ToProto returns an error for one that doesn't
+	ProtoStruct() JSONFieldBuilder
+	// ProtoValue sends the field as google.protobuf.Value instead of text
+	ProtoValue() JSONFieldBuilder
 
 	Field()
 }
@@ -679,10 +938,12 @@ type JSONField struct {
 	immutable   bool
 	protoField  *int
 	comment     *string
+	renamedFrom string
 	permissions permissions.Permission
 	defaultVal  *string
 	defaultFunc func() string
 	validate    func(string) bool
+	protoType   string // "", "struct" or "value"
 }
 
 func (*JSONField) Field() {}
@@ -691,6 +952,14 @@ func JSON(name string) JSONFieldBuilder {
 	return &JSONField{name: name}
 }
 
+// JSONOf is a json field typed by T, e.g. JSONOf[logic.Settings]("settings").
+// Generated code marshals T into the column and back, so T should be a named
+// type that encodes as a json object. It goes to proto as a Struct unless
+// ProtoValue is set, ToProto returns an error for a T that isn't an object.
+func JSONOf[T any](name string) JSONFieldBuilder {
+	return &JSONField{name: name}
+}
+
 func (f *JSONField) GetOptional() bool {
 	return f.optional
 }
@@ -707,6 +976,10 @@ func (f *JSONField) GetComment() *string {
 	return f.comment
 }
 
+func (f *JSONField) GetRenamedFrom() string {
+	return f.renamedFrom
+}
+
 func (f *JSONField) GetPermissions() permissions.Permission {
 	return f.permissions
 }
@@ -723,6 +996,10 @@ func (f *JSONField) GetValidate() func(string) bool {
 	return f.validate
 }
 
+func (f *JSONField) GetProtoType() string {
+	return f.protoType
+}
+
 func (f *JSONField) Optional() JSONFieldBuilder {
 	f.optional = true
 	return f
@@ -743,6 +1020,11 @@ func (f *JSONField) Comment(text string) JSONFieldBuilder {
 	return f
 }
 
+func (f *JSONField) RenamedFrom(name string) JSONFieldBuilder {
+	f.renamedFrom = name
+	return f
+}
+
 func (f *JSONField) Permissions(permission permissions.Permission) JSONFieldBuilder {
 	f.permissions = permission
 	return f
@@ -764,3 +1046,588 @@ func (f *JSONField) Validate(fn func(string) bool) JSONFieldBuilder {
 	f.validate = fn
 	return f
 }
+
+func (f *JSONField) ProtoStruct() JSONFieldBuilder {
+	f.protoType = "struct"
+	return f
+}
+
+func (f *JSONField) ProtoValue() JSONFieldBuilder {
+	f.protoType = "value"
+	return f
+}
+
+// --------------------------------- strings ---------------------------------
+// strings is a TEXT[] on postgres and a json array in TEXT on sqlite and mysql,
+// a repeated string in proto. An Optional one stores a nil list as NULL.
+type StringsFieldBuilder interface {
+	Optional() StringsFieldBuilder
+	Immutable() StringsFieldBuilder
+	ProtoField(int) StringsFieldBuilder
+	Comment(string) StringsFieldBuilder
+	RenamedFrom(string) StringsFieldBuilder
+	Permissions(permissions.Permission) StringsFieldBuilder
+
+	Field()
+}
+
+type StringsField struct {
+	name        string
+	optional    bool
+	immutable   bool
+	protoField  *int
+	comment     *string
+	renamedFrom string
+	permissions permissions.Permission
+}
+
+func (*StringsField) Field() {}
+
+func Strings(name string) StringsFieldBuilder {
+	return &StringsField{name: name}
+}
+
+func (f *StringsField) GetOptional() bool {
+	return f.optional
+}
+
+func (f *StringsField) GetImmutable() bool {
+	return f.immutable
+}
+
+func (f *StringsField) GetProtoField() *int {
+	return f.protoField
+}
+
+func (f *StringsField) GetComment() *string {
+	return f.comment
+}
+
+func (f *StringsField) GetRenamedFrom() string {
+	return f.renamedFrom
+}
+
+func (f *StringsField) GetPermissions() permissions.Permission {
+	return f.permissions
+}
+
+func (f *StringsField) Optional() StringsFieldBuilder {
+	f.optional = true
+	return f
+}
+
+func (f *StringsField) Immutable() StringsFieldBuilder {
+	f.immutable = true
+	return f
+}
+
+func (f *StringsField) ProtoField(num int) StringsFieldBuilder {
+	f.protoField = &num
+	return f
+}
+
+func (f *StringsField) Comment(text string) StringsFieldBuilder {
+	f.comment = &text
+	return f
+}
+
+func (f *StringsField) RenamedFrom(name string) StringsFieldBuilder {
+	f.renamedFrom = name
+	return f
+}
+
+func (f *StringsField) Permissions(permission permissions.Permission) StringsFieldBuilder {
+	f.permissions = permission
+	return f
+}
+
+// --------------------------------- ints ---------------------------------
+// ints is a list of int32, stored like strings: INTEGER[] on postgres and a
+// json array in TEXT on sqlite and mysql, a repeated int32 in proto.
+type IntsFieldBuilder interface {
+	Optional() IntsFieldBuilder
+	Immutable() IntsFieldBuilder
+	ProtoField(int) IntsFieldBuilder
+	Comment(string) IntsFieldBuilder
+	RenamedFrom(string) IntsFieldBuilder
+	Permissions(permissions.Permission) IntsFieldBuilder
+
+	Field()
+}
+
+type IntsField struct {
+	name        string
+	optional    bool
+	immutable   bool
+	protoField  *int
+	comment     *string
+	renamedFrom string
+	permissions permissions.Permission
+}
+
+func (*IntsField) Field() {}
+
+func Ints(name string) IntsFieldBuilder {
+	return &IntsField{name: name}
+}
+
+func (f *IntsField) GetOptional() bool {
+	return f.optional
+}
+
+func (f *IntsField) GetImmutable() bool {
+	return f.immutable
+}
+
+func (f *IntsField) GetProtoField() *int {
+	return f.protoField
+}
+
+func (f *IntsField) GetComment() *string {
+	return f.comment
+}
+
+func (f *IntsField) GetRenamedFrom() string {
+	return f.renamedFrom
+}
+
+func (f *IntsField) GetPermissions() permissions.Permission {
+	return f.permissions
+}
+
+func (f *IntsField) Optional() IntsFieldBuilder {
+	f.optional = true
+	return f
+}
+
+func (f *IntsField) Immutable() IntsFieldBuilder {
+	f.immutable = true
+	return f
+}
+
+func (f *IntsField) ProtoField(num int) IntsFieldBuilder {
+	f.protoField = &num
+	return f
+}
+
+func (f *IntsField) Comment(text string) IntsFieldBuilder {
+	f.comment = &text
+	return f
+}
+
+func (f *IntsField) RenamedFrom(name string) IntsFieldBuilder {
+	f.renamedFrom = name
+	return f
+}
+
+func (f *IntsField) Permissions(permission permissions.Permission) IntsFieldBuilder {
+	f.permissions = permission
+	return f
+}
+
+// --------------------------------- decimal ---------------------------------
+// decimal is an exact number for money and the like: NUMERIC(p,s) on postgres,
+// DECIMAL(p,s) on mysql and checked TEXT on sqlite, a string in proto.
+// Precision is required. Default takes the value as text, e.g. "0.00".
+type DecimalFieldBuilder interface {
+	Precision(precision, scale int) DecimalFieldBuilder
+	Default(string) DecimalFieldBuilder
+	Optional() DecimalFieldBuilder
+	Immutable() DecimalFieldBuilder
+	ProtoField(int) DecimalFieldBuilder
+	Comment(string) DecimalFieldBuilder
+	RenamedFrom(string) DecimalFieldBuilder
+	Permissions(permissions.Permission) DecimalFieldBuilder
+
+	Field()
+}
+
+type DecimalField struct {
+	name        string
+	precision   int
+	scale       int
+	defaultVal  *string
+	optional    bool
+	immutable   bool
+	protoField  *int
+	comment     *string
+	renamedFrom string
+	permissions permissions.Permission
+}
+
+func (*DecimalField) Field() {}
+
+func Decimal(name string) DecimalFieldBuilder {
+	return &DecimalField{name: name}
+}
+
+func (f *DecimalField) GetPrecision() (int, int) {
+	return f.precision, f.scale
+}
+
+func (f *DecimalField) GetDefault() *string {
+	return f.defaultVal
+}
+
+func (f *DecimalField) GetOptional() bool {
+	return f.optional
+}
+
+func (f *DecimalField) GetImmutable() bool {
+	return f.immutable
+}
+
+func (f *DecimalField) GetProtoField() *int {
+	return f.protoField
+}
+
+func (f *DecimalField) GetComment() *string {
+	return f.comment
+}
+
+func (f *DecimalField) GetRenamedFrom() string {
+	return f.renamedFrom
+}
+
+func (f *DecimalField) GetPermissions() permissions.Permission {
+	return f.permissions
+}
+
+func (f *DecimalField) Precision(precision, scale int) DecimalFieldBuilder {
+	f.precision = precision
+	f.scale = scale
+	return f
+}
+
+func (f *DecimalField) Default(value string) DecimalFieldBuilder {
+	f.defaultVal = &value
+	return f
+}
+
+func (f *DecimalField) Optional() DecimalFieldBuilder {
+	f.optional = true
+	return f
+}
+
+func (f *DecimalField) Immutable() DecimalFieldBuilder {
+	f.immutable = true
+	return f
+}
+
+func (f *DecimalField) ProtoField(num int) DecimalFieldBuilder {
+	f.protoField = &num
+	return f
+}
+
+func (f *DecimalField) Comment(text string) DecimalFieldBuilder {
+	f.comment = &text
+	return f
+}
+
+func (f *DecimalField) RenamedFrom(name string) DecimalFieldBuilder {
+	f.renamedFrom = name
+	return f
+}
+
+func (f *DecimalField) Permissions(permission permissions.Permission) DecimalFieldBuilder {
+	f.permissions = permission
+	return f
+}
+
+// --------------------------------- date ---------------------------------
+// date is a calendar day without a time of day: DATE in sql, google.type.Date
+// in proto. Go code holds it as a time.Time at midnight UTC.
+type DateFieldBuilder interface {
+	DefaultFunc(func() time.Time) DateFieldBuilder
+	Optional() DateFieldBuilder
+	Immutable() DateFieldBuilder
+	ProtoField(int) DateFieldBuilder
+	Comment(string) DateFieldBuilder
+	RenamedFrom(string) DateFieldBuilder
+	Backfill(func(context.Context, migrate.Row) (time.Time, error)) DateFieldBuilder
+	BackfillSQL(string) DateFieldBuilder
+	Permissions(permissions.Permission) DateFieldBuilder
+
+	Field()
+}
+
+type DateField struct {
+	name        string
+	defaultFunc func() time.Time
+	optional    bool
+	immutable   bool
+	protoField  *int
+	comment     *string
+	renamedFrom string
+	backfill    func(context.Context, migrate.Row) (time.Time, error)
+	backfillSQL string
+	permissions permissions.Permission
+}
+
+func (*DateField) Field() {}
+
+func Date(name string) DateFieldBuilder {
+	return &DateField{name: name}
+}
+
+func (f *DateField) GetDefaultFunc() func() time.Time {
+	return f.defaultFunc
+}
+
+func (f *DateField) GetOptional() bool {
+	return f.optional
+}
+
+func (f *DateField) GetImmutable() bool {
+	return f.immutable
+}
+
+func (f *DateField) GetProtoField() *int {
+	return f.protoField
+}
+
+func (f *DateField) GetComment() *string {
+	return f.comment
+}
+
+func (f *DateField) GetRenamedFrom() string {
+	return f.renamedFrom
+}
+
+func (f *DateField) GetBackfill() func(context.Context, migrate.Row) (time.Time, error) {
+	return f.backfill
+}
+
+func (f *DateField) GetBackfillSQL() string {
+	return f.backfillSQL
+}
+
+func (f *DateField) GetPermissions() permissions.Permission {
+	return f.permissions
+}
+
+func (f *DateField) DefaultFunc(fn func() time.Time) DateFieldBuilder {
+	f.defaultFunc = fn
+	return f
+}
+
+func (f *DateField) Optional() DateFieldBuilder {
+	f.optional = true
+	return f
+}
+
+func (f *DateField) Immutable() DateFieldBuilder {
+	f.immutable = true
+	return f
+}
+
+func (f *DateField) ProtoField(num int) DateFieldBuilder {
+	f.protoField = &num
+	return f
+}
+
+func (f *DateField) Comment(text string) DateFieldBuilder {
+	f.comment = &text
+	return f
+}
+
+func (f *DateField) RenamedFrom(name string) DateFieldBuilder {
+	f.renamedFrom = name
+	return f
+}
+
+func (f *DateField) Backfill(fn func(context.Context, migrate.Row) (time.Time, error)) DateFieldBuilder {
+	f.backfill = fn
+	f.backfillSQL = ""
+	return f
+}
+
+func (f *DateField) BackfillSQL(expr string) DateFieldBuilder {
+	f.backfillSQL = expr
+	f.backfill = nil
+	return f
+}
+
+func (f *DateField) Permissions(permission permissions.Permission) DateFieldBuilder {
+	f.permissions = permission
+	return f
+}
+
+// --------------------------------- duration ---------------------------------
+// duration is a BIGINT of nanoseconds in sql, google.protobuf.Duration in proto
+type DurationFieldBuilder interface {
+	Default(time.Duration) DurationFieldBuilder
+	Optional() DurationFieldBuilder
+	Immutable() DurationFieldBuilder
+	ProtoField(int) DurationFieldBuilder
+	Comment(string) DurationFieldBuilder
+	RenamedFrom(string) DurationFieldBuilder
+	Permissions(permissions.Permission) DurationFieldBuilder
+
+	Field()
+}
+
+type DurationField struct {
+	name        string
+	defaultVal  *time.Duration
+	optional    bool
+	immutable   bool
+	protoField  *int
+	comment     *string
+	renamedFrom string
+	permissions permissions.Permission
+}
+
+func (*DurationField) Field() {}
+
+func Duration(name string) DurationFieldBuilder {
+	return &DurationField{name: name}
+}
+
+func (f *DurationField) GetDefault() *time.Duration {
+	return f.defaultVal
+}
+
+func (f *DurationField) GetOptional() bool {
+	return f.optional
+}
+
+func (f *DurationField) GetImmutable() bool {
+	return f.immutable
+}
+
+func (f *DurationField) GetProtoField() *int {
+	return f.protoField
+}
+
+func (f *DurationField) GetComment() *string {
+	return f.comment
+}
+
+func (f *DurationField) GetRenamedFrom() string {
+	return f.renamedFrom
+}
+
+func (f *DurationField) GetPermissions() permissions.Permission {
+	return f.permissions
+}
+
+func (f *DurationField) Default(value time.Duration) DurationFieldBuilder {
+	f.defaultVal = &value
+	return f
+}
+
+func (f *DurationField) Optional() DurationFieldBuilder {
+	f.optional = true
+	return f
+}
+
+func (f *DurationField) Immutable() DurationFieldBuilder {
+	f.immutable = true
+	return f
+}
+
+func (f *DurationField) ProtoField(num int) DurationFieldBuilder {
+	f.protoField = &num
+	return f
+}
+
+func (f *DurationField) Comment(text string) DurationFieldBuilder {
+	f.comment = &text
+	return f
+}
+
+func (f *DurationField) RenamedFrom(name string) DurationFieldBuilder {
+	f.renamedFrom = name
+	return f
+}
+
+func (f *DurationField) Permissions(permission permissions.Permission) DurationFieldBuilder {
+	f.permissions = permission
+	return f
+}
+
+// --------------------------------- latlng ---------------------------------
+// latlng is a point on the globe in degrees, stored as two double columns
+// <name>_lat and <name>_lng so it works without PostGIS, google.type.LatLng
+// in proto. Query it with filter.Near.
+type LatLngFieldBuilder interface {
+	Optional() LatLngFieldBuilder
+	Immutable() LatLngFieldBuilder
+	ProtoField(int) LatLngFieldBuilder
+	Comment(string) LatLngFieldBuilder
+	RenamedFrom(string) LatLngFieldBuilder
+	Permissions(permissions.Permission) LatLngFieldBuilder
+
+	Field()
+}
+
+type LatLngField struct {
+	name        string
+	optional    bool
+	immutable   bool
+	protoField  *int
+	comment     *string
+	renamedFrom string
+	permissions permissions.Permission
+}
+
+func (*LatLngField) Field() {}
+
+func LatLng(name string) LatLngFieldBuilder {
+	return &LatLngField{name: name}
+}
+
+func (f *LatLngField) GetOptional() bool {
+	return f.optional
+}
+
+func (f *LatLngField) GetImmutable() bool {
+	return f.immutable
+}
+
+func (f *LatLngField) GetProtoField() *int {
+	return f.protoField
+}
+
+func (f *LatLngField) GetComment() *string {
+	return f.comment
+}
+
+func (f *LatLngField) GetRenamedFrom() string {
+	return f.renamedFrom
+}
+
+func (f *LatLngField) GetPermissions() permissions.Permission {
+	return f.permissions
+}
+
+func (f *LatLngField) Optional() LatLngFieldBuilder {
+	f.optional = true
+	return f
+}
+
+func (f *LatLngField) Immutable() LatLngFieldBuilder {
+	f.immutable = true
+	return f
+}
+
+func (f *LatLngField) ProtoField(num int) LatLngFieldBuilder {
+	f.protoField = &num
+	return f
+}
+
+func (f *LatLngField) Comment(text string) LatLngFieldBuilder {
+	f.comment = &text
+	return f
+}
+
+func (f *LatLngField) RenamedFrom(name string) LatLngFieldBuilder {
+	f.renamedFrom = name
+	return f
+}
+
+func (f *LatLngField) Permissions(permission permissions.Permission) LatLngFieldBuilder {
+	f.permissions = permission
+	return f
+}
@@ -1,5 +1,7 @@
 package filter
 
+import "strings"
+
 type Filter interface {
 	Filter()
 	GetField() string
@@ -7,16 +9,51 @@ type Filter interface {
 }
 
 type RangeFilter struct {
-	field    string
-	optional bool
+	field        string
+	optional     bool
+	optionalMin  bool
+	optionalMax  bool
+	exclusiveMin bool
+	exclusiveMax bool
 }
 
-func (rf RangeFilter) Filter()          {}
-func (rf RangeFilter) GetField() string { return rf.field }
-func (rf RangeFilter) IsOptional() bool { return rf.optional }
+func (rf RangeFilter) Filter()              {}
+func (rf RangeFilter) GetField() string     { return rf.field }
+func (rf RangeFilter) IsOptional() bool     { return rf.optional }
+func (rf RangeFilter) IsOptionalMin() bool  { return rf.optionalMin }
+func (rf RangeFilter) IsOptionalMax() bool  { return rf.optionalMax }
+func (rf RangeFilter) IsExclusiveMin() bool { return rf.exclusiveMin }
+func (rf RangeFilter) IsExclusiveMax() bool { return rf.exclusiveMax }
 
+// Optional makes both bounds optional, see OptionalMin and OptionalMax
 func (rf RangeFilter) Optional() RangeFilter {
 	rf.optional = true
+	rf.optionalMin = true
+	rf.optionalMax = true
+	return rf
+}
+
+// OptionalMin lets the lower bound be left out, the range is then open below
+func (rf RangeFilter) OptionalMin() RangeFilter {
+	rf.optionalMin = true
+	return rf
+}
+
+// OptionalMax lets the upper bound be left out, the range is then open above
+func (rf RangeFilter) OptionalMax() RangeFilter {
+	rf.optionalMax = true
+	return rf
+}
+
+// ExclusiveMin compares the lower bound with > instead of >=
+func (rf RangeFilter) ExclusiveMin() RangeFilter {
+	rf.exclusiveMin = true
+	return rf
+}
+
+// ExclusiveMax compares the upper bound with < instead of <=, e.g. for [from, to) time windows
+func (rf RangeFilter) ExclusiveMax() RangeFilter {
+	rf.exclusiveMax = true
 	return rf
 }
 
@@ -24,20 +61,54 @@ func Range(field string) RangeFilter {
 	return RangeFilter{field: field, optional: false}
 }
 
+// SearchMatch selects how a Search value is turned into a LIKE pattern.
+type SearchMatch string
+
+const (
+	// MatchPattern passes the value through as a LIKE pattern, wildcards included.
+	MatchPattern SearchMatch = ""
+	// MatchContains matches the value anywhere in the column, wildcards in it are escaped.
+	MatchContains SearchMatch = "contains"
+	// MatchPrefix matches columns starting with the value, wildcards in it are escaped.
+	MatchPrefix SearchMatch = "prefix"
+)
+
 type SearchFilter struct {
-	field    string
-	optional bool
+	field           string
+	optional        bool
+	match           SearchMatch
+	caseInsensitive bool
 }
 
-func (sf SearchFilter) Filter()          {}
-func (sf SearchFilter) GetField() string { return sf.field }
-func (sf SearchFilter) IsOptional() bool { return sf.optional }
+func (sf SearchFilter) Filter()                 {}
+func (sf SearchFilter) GetField() string        { return sf.field }
+func (sf SearchFilter) IsOptional() bool        { return sf.optional }
+func (sf SearchFilter) GetMatch() SearchMatch   { return sf.match }
+func (sf SearchFilter) IsCaseInsensitive() bool { return sf.caseInsensitive }
 
 func (sf SearchFilter) Optional() SearchFilter {
 	sf.optional = true
 	return sf
 }
 
+// Contains matches the value as a substring, % and _ in it are matched literally
+func (sf SearchFilter) Contains() SearchFilter {
+	sf.match = MatchContains
+	return sf
+}
+
+// Prefix matches columns starting with the value, % and _ in it are matched literally
+func (sf SearchFilter) Prefix() SearchFilter {
+	sf.match = MatchPrefix
+	return sf
+}
+
+// CaseInsensitive compares ignoring case on every dialect
+func (sf SearchFilter) CaseInsensitive() SearchFilter {
+	sf.caseInsensitive = true
+	return sf
+}
+
 func Search(field string) SearchFilter {
 	return SearchFilter{field: field, optional: false}
 }
@@ -59,3 +130,89 @@ func (ef EqFilter) Optional() EqFilter {
 func Eq(field string) EqFilter {
 	return EqFilter{field: field, optional: false}
 }
+
+// AnyOfFilter matches rows where at least one of its filters does. The filters
+// share a single request parameter, named after their fields joined by _or_.
+type AnyOfFilter struct {
+	filters  []Filter
+	optional bool
+}
+
+func (af AnyOfFilter) Filter()              {}
+func (af AnyOfFilter) IsOptional() bool     { return af.optional }
+func (af AnyOfFilter) GetFilters() []Filter { return af.filters }
+
+func (af AnyOfFilter) GetField() string {
+	fields := make([]string, len(af.filters))
+	for i, f := range af.filters {
+		fields[i] = f.GetField()
+	}
+	return strings.Join(fields, "_or_")
+}
+
+func (af AnyOfFilter) Optional() AnyOfFilter {
+	af.optional = true
+	return af
+}
+
+func AnyOf(filters ...Filter) AnyOfFilter {
+	return AnyOfFilter{filters: filters, optional: false}
+}
+
+// JSONPathFilter matches rows whose json field holds the param at path,
+// compared as text. The param is named after the field and path keys, e.g. settings_theme.
+type JSONPathFilter struct {
+	field    string
+	path     string
+	optional bool
+}
+
+func (jf JSONPathFilter) Filter()          {}
+func (jf JSONPathFilter) GetField() string { return jf.field }
+func (jf JSONPathFilter) GetPath() string  { return jf.path }
+func (jf JSONPathFilter) IsOptional() bool { return jf.optional }
+
+func (jf JSONPathFilter) Optional() JSONPathFilter {
+	jf.optional = true
+	return jf
+}
+
+// JSONPath filters on a value inside a json field, path is dot separated keys
+// like "theme" or "ui.theme" ("$." prefix optional). Pair it with index.JSONPath.
+func JSONPath(field, path string) JSONPathFilter {
+	return JSONPathFilter{field: field, path: path, optional: false}
+}
+
+// ContainsFilter matches rows whose field.Strings/field.Ints list holds the
+// param, a single element named after the field.
+type ContainsFilter struct {
+	field    string
+	optional bool
+}
+
+func (cf ContainsFilter) Filter()          {}
+func (cf ContainsFilter) GetField() string { return cf.field }
+func (cf ContainsFilter) IsOptional() bool { return cf.optional }
+
+func (cf ContainsFilter) Optional() ContainsFilter {
+	cf.optional = true
+	return cf
+}
+
+func Contains(field string) ContainsFilter {
+	return ContainsFilter{field: field, optional: false}
+}
+
+// NearFilter matches rows whose field.LatLng lies within a radius in meters
+// of a point, nearest first. SQL prefilters on a bounding box and then checks
+// the haversine distance, so no PostGIS is needed.
+type NearFilter struct {
+	field string
+}
+
+func (nf NearFilter) Filter()          {}
+func (nf NearFilter) GetField() string { return nf.field }
+
+func Near(field string) NearFilter {
+	return NearFilter{field: field}
+}
@@ -30,6 +30,10 @@ type IndexOperations interface {
 	Name(name string) IndexOperations
 	Asc(field string) IndexOperations
 	Desc(field string) IndexOperations
+	// Where makes it a partial index over the rows matching the SQL predicate
+	Where(predicate string) IndexOperations
+	// Lower indexes LOWER() of the (string) columns for case-insensitive lookups
+	Lower() IndexOperations
 }
 
 type Index struct {
@@ -37,6 +41,9 @@ type Index struct {
 	columns  []Column
 	unique   bool
 	name     string
+	where    string
+	lower    bool
+	jsonPath string
 }
 
 // marker method for sealed interface
@@ -50,6 +57,12 @@ func Fields(fields ...string) IndexOperations {
 	return Index{typeName: TypeIndex, columns: columnsFromFields(fields)}
 }
 
+// JSONPath indexes the value at path inside a json field, e.g.
+// index.JSONPath("settings", "$.theme"), so filter.JSONPath lookups can use it.
+func JSONPath(field, path string) IndexOperations {
+	return Index{typeName: TypeIndex, columns: columnsFromFields([]string{field}), jsonPath: path}
+}
+
 func columnsFromFields(fields []string) []Column {
 	cols := make([]Column, len(fields))
 	for i, f := range fields {
@@ -82,6 +95,20 @@ func (i Index) Desc(field string) IndexOperations {
 	return i
 }
 
+// Where restricts the index to rows matching predicate, e.g. "deleted_at IS NULL".
+// A unique partial index only enforces uniqueness among those rows.
+func (i Index) Where(predicate string) IndexOperations {
+	i.where = predicate
+	return i
+}
+
+// Lower indexes the lowercased columns instead, so a unique index ignores case.
+// GetBy queries on these fields compare lowercased values to use the index.
+func (i Index) Lower() IndexOperations {
+	i.lower = true
+	return i
+}
+
 func (i Index) GetType() Type {
 	return i.typeName
 }
@@ -106,3 +133,15 @@ func (i Index) IsUnique() bool {
 func (i Index) GetName() string {
 	return i.name
 }
+
+func (i Index) GetWhere() string {
+	return i.where
+}
+
+func (i Index) IsLower() bool {
+	return i.lower
+}
+
+func (i Index) GetJSONPath() string {
+	return i.jsonPath
+}
@@ -0,0 +1,349 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// BackfillDirective starts the line of a migration that fills a column from Go:
+//
+//	-- entlite:backfill <table> <column> <key columns> <import path>.<function>
+//
+// The statements before it run first, the ones after it once every row has
+// a value. entlite migrate diff writes it for a field with Backfill().
+const BackfillDirective = "-- entlite:backfill"
+
+// BackfillTable keeps how far a migration with a Go backfill got, so a run
+// that was interrupted resumes where it stopped
+const BackfillTable = "entlite_backfills"
+
+// Row is a row a backfill computes a value for, by column name. Values are
+// what the driver scans into an any, e.g. int64, string, []byte or time.Time.
+type Row map[string]any
+
+// BackfillFunc computes the value of the backfilled column of a row. A nil
+// value fails the migration, the column is about to become NOT NULL.
+type BackfillFunc func(ctx context.Context, row Row) (any, error)
+
+// Backfill adapts the function a field's Backfill names, e.g.
+// migrate.Backfill(logic.ComputeSlug)
+func Backfill[T any](fn func(context.Context, Row) (T, error)) BackfillFunc {
+	return func(ctx context.Context, row Row) (any, error) {
+		return fn(ctx, row)
+	}
+}
+
+// backfillBatchSize is how many rows one transaction of a backfill updates
+var backfillBatchSize = 500
+
+// backfillStep is a parsed BackfillDirective
+type backfillStep struct {
+	table    string
+	column   string
+	key      []string
+	function string
+}
+
+// part is statements of a migration, or the backfill between them
+type part struct {
+	script   string
+	backfill *backfillStep
+}
+
+// BackfillFunctions lists the functions the Go backfills of the migration
+// name, each as <import path>.<function>
+func (m Migration) BackfillFunctions() ([]string, error) {
+	var functions []string
+	for _, script := range []string{m.Up, m.Down} {
+		parts, err := splitParts(script)
+		if err != nil {
+			return nil, fmt.Errorf("migration %d_%s: %w", m.Version, m.Name, err)
+		}
+		for _, p := range parts {
+			if p.backfill != nil {
+				functions = append(functions, p.backfill.function)
+			}
+		}
+	}
+	return functions, nil
+}
+
+// splitParts splits a migration at its backfill directives. It starts and
+// ends with statements, either may be empty.
+func splitParts(script string) ([]part, error) {
+	var parts []part
+	var current strings.Builder
+	for line := range strings.SplitSeq(script, "\n") {
+		rest, ok := strings.CutPrefix(strings.TrimSpace(line), BackfillDirective)
+		if !ok {
+			current.WriteString(line + "\n")
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("%q needs a table, column, key columns and function", strings.TrimSpace(line))
+		}
+		parts = append(parts,
+			part{script: current.String()},
+			part{backfill: &backfillStep{table: fields[0], column: fields[1], key: strings.Split(fields[2], ","), function: fields[3]}},
+		)
+		current.Reset()
+	}
+	return append(parts, part{script: current.String()}), nil
+}
+
+// applyInParts runs a migration with Go backfills. Each part commits on its
+// own and a backfill commits every batch, BackfillTable records the parts
+// done, so a failed or killed run picks up from the part it was in.
+func (m *Migrator) applyInParts(ctx context.Context, conn *sql.Conn, migration Migration, direction string, parts []part, record string, args []any) error {
+	name := fmt.Sprintf("%d_%s %s", migration.Version, migration.Name, direction)
+	for _, p := range parts {
+		if p.backfill != nil && m.backfills[p.backfill.function] == nil {
+			return fmt.Errorf("migration %s backfills %s.%s with %s, which is not registered: pass migrations.Backfills to WithBackfills",
+				name, p.backfill.table, p.backfill.column, p.backfill.function)
+		}
+	}
+
+	if err := m.createBackfillTable(ctx, conn); err != nil {
+		return err
+	}
+	done, err := m.partsDone(ctx, conn, migration.Version)
+	if err != nil {
+		return err
+	}
+
+	for i := done; i < len(parts); i++ {
+		// on sqlite another run may have taken the lock between two parts
+		if m.dialect == SQLite && i > done {
+			if current, err := m.partsDone(ctx, conn, migration.Version); err != nil {
+				return err
+			} else if current != i {
+				return fmt.Errorf("migration %s was moved on by another run, run it again", name)
+			}
+		}
+
+		p := parts[i]
+		if p.backfill != nil {
+			if err := m.backfill(ctx, conn, *p.backfill); err != nil {
+				return fmt.Errorf("migration %s: %w", name, err)
+			}
+			if err := m.inTx(ctx, conn, func(tx execer) error { return m.savePartsDone(ctx, tx, migration.Version, i+1) }); err != nil {
+				return err
+			}
+			continue
+		}
+
+		last := i == len(parts)-1
+		err := m.inTx(ctx, conn, func(tx execer) error {
+			for _, statement := range m.statements(p.script) {
+				if _, err := tx.ExecContext(ctx, statement); err != nil {
+					return fmt.Errorf("migration %s: %w", name, err)
+				}
+			}
+			if !last {
+				return m.savePartsDone(ctx, tx, migration.Version, i+1)
+			}
+			if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+				return fmt.Errorf("recording migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			_, err := tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE version = %s", BackfillTable, m.placeholder(1)), int64(migration.Version))
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backfill sets the null rows of the column in batches of backfillBatchSize,
+// in key order. Rows a previous run filled aren't null anymore.
+func (m *Migrator) backfill(ctx context.Context, conn *sql.Conn, step backfillStep) error {
+	fn := m.backfills[step.function]
+	table := m.quote(step.table)
+	key := strings.Join(step.key, ", ")
+
+	var conditions []string
+	for i, column := range step.key {
+		conditions = append(conditions, fmt.Sprintf("%s = %s", column, m.placeholder(i+2)))
+	}
+	update := fmt.Sprintf("UPDATE %s SET %s = %s WHERE %s", table, step.column, m.placeholder(1), strings.Join(conditions, " AND "))
+
+	var after []any
+	for {
+		query := fmt.Sprintf("SELECT * FROM %s WHERE %s IS NULL", table, step.column)
+		if after != nil {
+			placeholders := make([]string, len(after))
+			for i := range after {
+				placeholders[i] = m.placeholder(i + 1)
+			}
+			query += fmt.Sprintf(" AND (%s) > (%s)", key, strings.Join(placeholders, ", "))
+		}
+		query += fmt.Sprintf(" ORDER BY %s LIMIT %d", key, backfillBatchSize)
+
+		var count int
+		err := m.inTx(ctx, conn, func(tx execer) error {
+			rows, err := readRows(ctx, tx, query, after)
+			if err != nil {
+				return fmt.Errorf("reading %s: %w", step.table, err)
+			}
+			count = len(rows)
+			for _, row := range rows {
+				keyValues := make([]any, len(step.key))
+				for i, column := range step.key {
+					keyValues[i] = row.value(column)
+				}
+				value, err := fn(ctx, row)
+				if err != nil {
+					return fmt.Errorf("backfilling %s.%s of row %v: %w", step.table, step.column, keyValues, err)
+				}
+				// the row would stay NULL and the batch after it skip it
+				if isNil(value) {
+					return fmt.Errorf("backfilling %s.%s of row %v: the function returned nil", step.table, step.column, keyValues)
+				}
+				if _, err := tx.ExecContext(ctx, update, append([]any{value}, keyValues...)...); err != nil {
+					return fmt.Errorf("backfilling %s.%s of row %v: %w", step.table, step.column, keyValues, err)
+				}
+				after = keyValues
+			}
+			return nil
+		})
+		if err != nil || count < backfillBatchSize {
+			return err
+		}
+	}
+}
+
+// isNil reports nil, also as a typed nil pointer, slice or map
+func isNil(value any) bool {
+	if value == nil {
+		return true
+	}
+	switch v := reflect.ValueOf(value); v.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Map, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
+
+// readRows reads a whole batch, the updates run on the same connection
+func readRows(ctx context.Context, db execer, query string, args []any) ([]Row, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	var result []Row
+	for rows.Next() {
+		values := make([]any, len(columns))
+		pointers := make([]any, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+		row := make(Row, len(columns))
+		for i, column := range columns {
+			row[column] = values[i]
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
+
+// value looks a column up case-insensitively, postgres folds unquoted names
+func (r Row) value(column string) any {
+	if value, ok := r[column]; ok {
+		return value
+	}
+	for name, value := range r {
+		if strings.EqualFold(name, column) {
+			return value
+		}
+	}
+	return nil
+}
+
+// inTx runs fn in a transaction of its own. On sqlite the run is one
+// exclusive transaction, it is committed and taken again.
+func (m *Migrator) inTx(ctx context.Context, conn *sql.Conn, fn func(execer) error) error {
+	if m.dialect == SQLite {
+		if err := fn(conn); err != nil {
+			return err
+		}
+		if _, err := conn.ExecContext(ctx, "COMMIT"); err != nil {
+			return err
+		}
+		_, err := conn.ExecContext(ctx, "BEGIN EXCLUSIVE")
+		return err
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (m *Migrator) createBackfillTable(ctx context.Context, db execer) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (version BIGINT PRIMARY KEY, parts_done INT NOT NULL)", BackfillTable))
+	if err != nil {
+		return fmt.Errorf("creating %s: %w", BackfillTable, err)
+	}
+	return nil
+}
+
+// partsDone is how many parts of the migration a previous run committed
+func (m *Migrator) partsDone(ctx context.Context, db execer, version uint64) (int, error) {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf("SELECT parts_done FROM %s WHERE version = %s", BackfillTable, m.placeholder(1)), int64(version))
+	if err != nil {
+		return 0, fmt.Errorf("reading %s: %w", BackfillTable, err)
+	}
+	defer rows.Close()
+
+	var done int
+	if rows.Next() {
+		if err := rows.Scan(&done); err != nil {
+			return 0, fmt.Errorf("reading %s: %w", BackfillTable, err)
+		}
+	}
+	return done, rows.Err()
+}
+
+func (m *Migrator) savePartsDone(ctx context.Context, db execer, version uint64, done int) error {
+	if _, err := db.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE version = %s", BackfillTable, m.placeholder(1)), int64(version)); err != nil {
+		return fmt.Errorf("recording backfill progress: %w", err)
+	}
+	_, err := db.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s (version, parts_done) VALUES (%s, %s)", BackfillTable, m.placeholder(1), m.placeholder(2)), int64(version), done)
+	if err != nil {
+		return fmt.Errorf("recording backfill progress: %w", err)
+	}
+	return nil
+}
+
+func (m *Migrator) placeholder(i int) string {
+	if m.dialect == PostgreSQL {
+		return fmt.Sprintf("$%d", i)
+	}
+	return "?"
+}
+
+func (m *Migrator) quote(name string) string {
+	if m.dialect == MySQL {
+		return "`" + name + "`"
+	}
+	return `"` + name + `"`
+}
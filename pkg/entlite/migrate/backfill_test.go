@@ -0,0 +1,172 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	_ "modernc.org/sqlite"
+)
+
+const backfillUp = `-- user table
+ALTER TABLE "user" ADD COLUMN slug TEXT;
+-- entlite:backfill user slug ID example.com/app/ent/logic.ComputeSlug
+CREATE UNIQUE INDEX "idx_user_slug" ON "user" (slug);
+`
+
+func TestMigratorBackfillResumes(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	fsys := fstest.MapFS{
+		"20260301120000_initial.up.sql":   {Data: []byte("CREATE TABLE \"user\"(\n  ID INTEGER PRIMARY KEY AUTOINCREMENT,\n  name TEXT NOT NULL\n);\n")},
+		"20260301120000_initial.down.sql": {Data: []byte("DROP TABLE \"user\";\n")},
+	}
+	initial, err := New(db, SQLite, fsys)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if err := initial.Up(ctx); err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+	for i := 1; i <= 7; i++ {
+		if _, err := db.ExecContext(ctx, `INSERT INTO "user" (name) VALUES (?)`, fmt.Sprintf("User %d", i)); err != nil {
+			t.Fatalf("insert: %v", err)
+		}
+	}
+
+	fsys["20260302120000_add_slug.up.sql"] = &fstest.MapFile{Data: []byte(backfillUp)}
+	fsys["20260302120000_add_slug.down.sql"] = &fstest.MapFile{Data: []byte("DROP INDEX \"idx_user_slug\";\nALTER TABLE \"user\" DROP COLUMN slug;\n")}
+	migrator, err := New(db, SQLite, fsys)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	// without the function nothing of the migration runs
+	if err := migrator.Up(ctx); err == nil || !strings.Contains(err.Error(), "not registered") {
+		t.Fatalf("Up without backfills = %v, want a not registered error", err)
+	}
+
+	defer func(size int) { backfillBatchSize = size }(backfillBatchSize)
+	backfillBatchSize = 2
+
+	// the first run fails in the third batch, the first two stay committed
+	calls := 0
+	failing := errors.New("slug service down")
+	migrator.WithBackfills(map[string]BackfillFunc{
+		"example.com/app/ent/logic.ComputeSlug": Backfill(func(ctx context.Context, row Row) (string, error) {
+			calls++
+			if calls == 5 {
+				return "", failing
+			}
+			return strings.ReplaceAll(strings.ToLower(row["name"].(string)), " ", "-"), nil
+		}),
+	})
+	if err := migrator.Up(ctx); !errors.Is(err, failing) {
+		t.Fatalf("Up = %v, want the backfill error", err)
+	}
+	var filled int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM "user" WHERE slug IS NOT NULL`).Scan(&filled); err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if filled != 4 {
+		t.Errorf("filled rows after the failed run = %d, want 4", filled)
+	}
+
+	// the second run only computes the rows left
+	calls = 10
+	if err := migrator.Up(ctx); err != nil {
+		t.Fatalf("resumed Up failed: %v", err)
+	}
+	if calls != 13 {
+		t.Errorf("resumed run computed %d rows, want 3", calls-10)
+	}
+
+	var slug string
+	if err := db.QueryRowContext(ctx, `SELECT slug FROM "user" WHERE ID = 7`).Scan(&slug); err != nil || slug != "user-7" {
+		t.Errorf("slug = %q, %v, want user-7", slug, err)
+	}
+	var progress int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+BackfillTable).Scan(&progress); err != nil || progress != 0 {
+		t.Errorf("%s rows = %d, %v, want none once the migration is recorded", BackfillTable, progress, err)
+	}
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if len(statuses) != 2 || !statuses[1].Applied {
+		t.Errorf("Status = %+v, want the backfill migration applied", statuses)
+	}
+}
+
+func TestMigratorBackfillRejectsNil(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	fsys := fstest.MapFS{
+		"20260301120000_initial.up.sql":   {Data: []byte("CREATE TABLE \"user\"(\n  ID INTEGER PRIMARY KEY AUTOINCREMENT,\n  name TEXT NOT NULL\n);\nINSERT INTO \"user\" (name) VALUES ('Ann'), ('Bob');\n")},
+		"20260301120000_initial.down.sql": {Data: []byte("DROP TABLE \"user\";\n")},
+		"20260302120000_add_slug.up.sql":  {Data: []byte(backfillUp)},
+	}
+	migrator, err := New(db, SQLite, fsys)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	migrator.WithBackfills(map[string]BackfillFunc{
+		"example.com/app/ent/logic.ComputeSlug": Backfill(func(ctx context.Context, row Row) (*string, error) {
+			if row["name"] == "Bob" {
+				return nil, nil
+			}
+			slug := "ann"
+			return &slug, nil
+		}),
+	})
+
+	want := "backfilling user.slug of row [2]: the function returned nil"
+	if err := migrator.Up(ctx); err == nil || !strings.Contains(err.Error(), want) {
+		t.Fatalf("Up = %v, want an error containing %q", err, want)
+	}
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if len(statuses) != 2 || statuses[1].Applied {
+		t.Errorf("Status = %+v, want the backfill migration pending", statuses)
+	}
+}
+
+func TestSplitParts(t *testing.T) {
+	parts, err := splitParts(backfillUp)
+	if err != nil {
+		t.Fatalf("splitParts failed: %v", err)
+	}
+	if len(parts) != 3 || parts[1].backfill == nil {
+		t.Fatalf("parts = %+v, want statements, a backfill and statements", parts)
+	}
+	want := backfillStep{table: "user", column: "slug", key: []string{"ID"}, function: "example.com/app/ent/logic.ComputeSlug"}
+	if got := *parts[1].backfill; got.table != want.table || got.column != want.column || strings.Join(got.key, ",") != "ID" || got.function != want.function {
+		t.Errorf("backfill = %+v, want %+v", got, want)
+	}
+	if !strings.Contains(parts[2].script, "idx_user_slug") {
+		t.Errorf("statements after the backfill = %q", parts[2].script)
+	}
+
+	if _, err := splitParts("-- entlite:backfill user slug\n"); err == nil {
+		t.Errorf("splitParts accepted a directive without key and function")
+	}
+}
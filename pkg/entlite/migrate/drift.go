@@ -0,0 +1,294 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Table is a table as entlite generates it, or as a database catalog has it.
+// Index columns are as written, e.g. "code DESC" or "lower(email)".
+// ForeignKeys, HasCheck, Default, Comment, Where and Constraint are only read
+// by Inspect, for entlite import db, Compare doesn't look at them.
+type Table struct {
+	Name        string
+	Columns     []Column
+	PrimaryKey  []string
+	Indexes     []Index
+	ForeignKeys []ForeignKey
+	HasCheck    bool // has CHECK constraints, they are not read
+}
+
+type Column struct {
+	Name    string
+	Type    string // declared type without the key clause, e.g. VARCHAR(255)
+	NotNull bool
+	Unique  bool   // a one column UNIQUE constraint
+	Default string // SQL of the default, e.g. 'unnamed' or CURRENT_TIMESTAMP, empty for none
+	Comment string
+}
+
+type Index struct {
+	Name       string
+	Unique     bool
+	Columns    []string
+	Partial    bool   // has a WHERE, the predicate isn't compared
+	Where      string // the predicate as the catalog has it
+	Constraint bool   // a UNIQUE of the CREATE TABLE rather than a CREATE INDEX
+}
+
+type ForeignKey struct {
+	Column    string
+	RefTable  string
+	RefColumn string
+}
+
+// Querier is what CheckSchema reads the catalog through, sqlc's DBTX, a
+// *sql.DB or a *sql.Tx
+type Querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+type DriftKind string
+
+const (
+	TableMissing      DriftKind = "table_missing"
+	TableExtra        DriftKind = "table_extra"
+	ColumnMissing     DriftKind = "column_missing"
+	ColumnExtra       DriftKind = "column_extra"
+	ColumnChanged     DriftKind = "column_changed"
+	PrimaryKeyChanged DriftKind = "primary_key_changed"
+	IndexMissing      DriftKind = "index_missing"
+	IndexExtra        DriftKind = "index_extra"
+	IndexChanged      DriftKind = "index_changed"
+)
+
+// Drift is one way the database differs from the generated schema, Name is
+// the column or index and empty for a whole table
+type Drift struct {
+	Kind    DriftKind `json:"kind"`
+	Table   string    `json:"table"`
+	Name    string    `json:"name,omitempty"`
+	Message string    `json:"message"`
+}
+
+// DriftError lists every drift CheckSchema found
+type DriftError struct {
+	Drifts []Drift
+}
+
+func (e *DriftError) Error() string {
+	lines := make([]string, len(e.Drifts))
+	for i, drift := range e.Drifts {
+		lines[i] = fmt.Sprintf("%s: %s", drift.Table, drift.Message)
+	}
+	return fmt.Sprintf("database schema drifted from the generated one:\n%s", strings.Join(lines, "\n"))
+}
+
+// CheckSchema compares the database catalog to the tables entlite generates.
+// The error is a *DriftError when a table, column or index is missing, extra
+// or changed, e.g. a column someone added by hand.
+func CheckSchema(ctx context.Context, db Querier, dialect Dialect, want []Table) error {
+	got, err := Inspect(ctx, db, dialect)
+	if err != nil {
+		return err
+	}
+	if drifts := Compare(dialect, want, got); len(drifts) > 0 {
+		return &DriftError{Drifts: drifts}
+	}
+	return nil
+}
+
+// Compare lists the drifts of the got tables from the wanted ones, in want
+// order. Names compare case-insensitively, postgres folds unquoted ones.
+// The migration tools' bookkeeping tables are not part of the schema.
+func Compare(dialect Dialect, want, got []Table) []Drift {
+	var drifts []Drift
+	gotByName := map[string]Table{}
+	for _, table := range got {
+		gotByName[strings.ToLower(table.Name)] = table
+	}
+
+	for _, table := range want {
+		actual, ok := gotByName[strings.ToLower(table.Name)]
+		if !ok {
+			drifts = append(drifts, Drift{Kind: TableMissing, Table: table.Name, Message: "table is missing"})
+			continue
+		}
+		drifts = append(drifts, compareTable(dialect, table, actual)...)
+	}
+
+	for _, table := range got {
+		isWanted := slices.ContainsFunc(want, func(t Table) bool { return strings.EqualFold(t.Name, table.Name) })
+		if !isWanted && !isBookkeeping(table.Name) {
+			drifts = append(drifts, Drift{Kind: TableExtra, Table: table.Name, Message: "table is not in the schema"})
+		}
+	}
+	return drifts
+}
+
+func compareTable(dialect Dialect, want, got Table) []Drift {
+	var drifts []Drift
+	add := func(kind DriftKind, name, format string, args ...any) {
+		drifts = append(drifts, Drift{Kind: kind, Table: want.Name, Name: name, Message: fmt.Sprintf(format, args...)})
+	}
+
+	key := lowerAll(want.PrimaryKey)
+	gotColumns := map[string]Column{}
+	for _, column := range got.Columns {
+		gotColumns[strings.ToLower(column.Name)] = column
+	}
+	for _, column := range want.Columns {
+		actual, ok := gotColumns[strings.ToLower(column.Name)]
+		if !ok {
+			add(ColumnMissing, column.Name, "column %s %s is missing", column.Name, column.Type)
+			continue
+		}
+		if wantType, gotType := canonicalType(dialect, column.Type), canonicalType(dialect, actual.Type); wantType != gotType {
+			add(ColumnChanged, column.Name, "column %s is %s, want %s", column.Name, actual.Type, column.Type)
+		}
+		// key columns are NOT NULL however the catalog says it
+		if column.NotNull != actual.NotNull && !slices.Contains(key, strings.ToLower(column.Name)) {
+			add(ColumnChanged, column.Name, "column %s is %s, want %s", column.Name, nullability(actual.NotNull), nullability(column.NotNull))
+		}
+		if column.Unique != actual.Unique {
+			add(ColumnChanged, column.Name, "column %s is %s, want %s", column.Name, uniqueness(actual.Unique), uniqueness(column.Unique))
+		}
+	}
+	for _, column := range got.Columns {
+		if !slices.ContainsFunc(want.Columns, func(c Column) bool { return strings.EqualFold(c.Name, column.Name) }) {
+			add(ColumnExtra, column.Name, "column %s %s is not in the schema", column.Name, column.Type)
+		}
+	}
+
+	if !slices.Equal(key, lowerAll(got.PrimaryKey)) {
+		add(PrimaryKeyChanged, "", "primary key is (%s), want (%s)", strings.Join(got.PrimaryKey, ", "), strings.Join(want.PrimaryKey, ", "))
+	}
+
+	gotIndexes := map[string]Index{}
+	for _, idx := range got.Indexes {
+		gotIndexes[strings.ToLower(idx.Name)] = idx
+	}
+	for _, idx := range want.Indexes {
+		actual, ok := gotIndexes[strings.ToLower(idx.Name)]
+		switch {
+		case !ok:
+			add(IndexMissing, idx.Name, "index %s (%s) is missing", idx.Name, strings.Join(idx.Columns, ", "))
+		case !sameIndexShape(idx, actual):
+			add(IndexChanged, idx.Name, "index %s is %s, want %s", idx.Name, indexShape(actual), indexShape(idx))
+		}
+	}
+	for _, idx := range got.Indexes {
+		if !slices.ContainsFunc(want.Indexes, func(i Index) bool { return strings.EqualFold(i.Name, idx.Name) }) {
+			add(IndexExtra, idx.Name, "index %s (%s) is not in the schema", idx.Name, strings.Join(idx.Columns, ", "))
+		}
+	}
+	return drifts
+}
+
+func sameIndexShape(a, b Index) bool {
+	if a.Unique != b.Unique || a.Partial != b.Partial || len(a.Columns) != len(b.Columns) {
+		return false
+	}
+	for i := range a.Columns {
+		if indexColumnKey(a.Columns[i]) != indexColumnKey(b.Columns[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+var plainIndexColumn = regexp.MustCompile("^[\"`]?([A-Za-z_][A-Za-z0-9_]*)[\"`]?( (?i:asc|desc))?$")
+
+// indexColumnKey compares a column by name and order. An expression is
+// rendered differently by each catalog, so only its place is compared.
+func indexColumnKey(column string) string {
+	match := plainIndexColumn.FindStringSubmatch(strings.TrimSpace(column))
+	if match == nil {
+		return "(expression)"
+	}
+	if strings.EqualFold(strings.TrimSpace(match[2]), "desc") {
+		return strings.ToLower(match[1]) + " desc"
+	}
+	return strings.ToLower(match[1])
+}
+
+func indexShape(idx Index) string {
+	shape := fmt.Sprintf("(%s)", strings.Join(idx.Columns, ", "))
+	if idx.Unique {
+		shape = "UNIQUE " + shape
+	}
+	if idx.Partial {
+		shape += " WHERE ..."
+	}
+	return shape
+}
+
+func nullability(notNull bool) string {
+	if notNull {
+		return "NOT NULL"
+	}
+	return "nullable"
+}
+
+func uniqueness(unique bool) string {
+	if unique {
+		return "UNIQUE"
+	}
+	return "not unique"
+}
+
+func lowerAll(names []string) []string {
+	lowered := make([]string, len(names))
+	for i, name := range names {
+		lowered[i] = strings.ToLower(name)
+	}
+	return lowered
+}
+
+var (
+	typeSpaces   = regexp.MustCompile(`\s*([(),])\s*`)
+	intTypeWidth = regexp.MustCompile(`^(smallint|mediumint|int|bigint)\(\d+\)`)
+)
+
+// postgresTypes are the names information_schema gives the types entlite writes
+var postgresTypes = map[string]string{
+	"serial":      "integer",
+	"bigserial":   "bigint",
+	"int":         "integer",
+	"int4":        "integer",
+	"int8":        "bigint",
+	"int[]":       "integer[]",
+	"bool":        "boolean",
+	"float8":      "double precision",
+	"timestamptz": "timestamp with time zone",
+	"varchar":     "character varying",
+}
+
+// canonicalType is a column type the way the catalog of the dialect names it
+func canonicalType(dialect Dialect, sqlType string) string {
+	t := strings.ToLower(strings.Join(strings.Fields(sqlType), " "))
+	t = typeSpaces.ReplaceAllString(t, "$1")
+	switch dialect {
+	case PostgreSQL:
+		if alias, ok := postgresTypes[t]; ok {
+			return alias
+		}
+		if rest, ok := strings.CutPrefix(t, "decimal"); ok {
+			return "numeric" + rest
+		}
+	case MySQL:
+		// mysql 5.7 reports display widths, 8.0 only keeps tinyint(1)
+		t = intTypeWidth.ReplaceAllString(t, "$1")
+		switch t {
+		case "integer":
+			return "int"
+		case "bool", "boolean":
+			return "tinyint(1)"
+		}
+	}
+	return t
+}
@@ -0,0 +1,172 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+// driftSchema is what the sqlite generator writes for it
+var driftSchema = []Table{
+	{
+		Name: "user",
+		Columns: []Column{
+			{Name: "ID", Type: "INTEGER"},
+			{Name: "email", Type: "TEXT", NotNull: true, Unique: true},
+			{Name: "age", Type: "INTEGER"},
+			{Name: "deleted_at", Type: "DATETIME"},
+		},
+		PrimaryKey: []string{"ID"},
+		Indexes: []Index{
+			{Name: "idx_user_age", Columns: []string{"age DESC"}},
+			{Name: "idx_user_email_lower", Unique: true, Columns: []string{"LOWER(email)"}, Partial: true},
+		},
+	},
+	{
+		Name: "membership",
+		Columns: []Column{
+			{Name: "ID", Type: "INTEGER"},
+			{Name: "user_id", Type: "INTEGER", NotNull: true},
+			{Name: "group_id", Type: "INTEGER", NotNull: true},
+		},
+		PrimaryKey: []string{"user_id", "group_id"},
+	},
+}
+
+const driftDDL = `
+CREATE TABLE "user"(
+  ID INTEGER PRIMARY KEY AUTOINCREMENT,
+  email TEXT UNIQUE NOT NULL,
+  age INTEGER,
+  deleted_at DATETIME
+);
+CREATE INDEX "idx_user_age" ON "user" (age DESC);
+CREATE UNIQUE INDEX "idx_user_email_lower" ON "user" (LOWER(email)) WHERE deleted_at IS NULL;
+CREATE TABLE "membership"(
+  ID INTEGER,
+  user_id INTEGER NOT NULL,
+  group_id INTEGER NOT NULL,
+  PRIMARY KEY (user_id, group_id)
+);
+CREATE TABLE "entlite_migrations"(version INTEGER PRIMARY KEY);
+CREATE TABLE "entlite_backfills"(version BIGINT PRIMARY KEY, parts_done INT NOT NULL);
+`
+
+func TestCheckSchemaSQLite(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(driftDDL); err != nil {
+		t.Fatalf("Failed to create tables: %v", err)
+	}
+
+	if err := CheckSchema(ctx, db, SQLite, driftSchema); err != nil {
+		t.Fatalf("CheckSchema on the generated tables: %v", err)
+	}
+
+	for _, stmt := range []string{
+		`ALTER TABLE "user" ADD COLUMN note TEXT`,
+		`DROP INDEX "idx_user_age"`,
+		`CREATE INDEX "idx_user_age" ON "user" (age)`,
+		`CREATE INDEX "idx_user_note" ON "user" (note)`,
+		`CREATE TABLE "audit"(ID INTEGER PRIMARY KEY)`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+
+	err = CheckSchema(ctx, db, SQLite, driftSchema)
+	var driftErr *DriftError
+	if !errors.As(err, &driftErr) {
+		t.Fatalf("CheckSchema error = %v, want a *DriftError", err)
+	}
+	want := []Drift{
+		{Kind: ColumnExtra, Table: "user", Name: "note", Message: "column note TEXT is not in the schema"},
+		{Kind: IndexChanged, Table: "user", Name: "idx_user_age", Message: "index idx_user_age is (age), want (age DESC)"},
+		{Kind: IndexExtra, Table: "user", Name: "idx_user_note", Message: "index idx_user_note (note) is not in the schema"},
+		{Kind: TableExtra, Table: "audit", Message: "table is not in the schema"},
+	}
+	if !slices.Equal(driftErr.Drifts, want) {
+		t.Errorf("drifts = %+v\nwant %+v", driftErr.Drifts, want)
+	}
+}
+
+func TestCompareTypes(t *testing.T) {
+	want := []Table{{
+		Name: "user",
+		Columns: []Column{
+			{Name: "ID", Type: "SERIAL"},
+			{Name: "tags", Type: "TEXT[]", NotNull: true},
+			{Name: "ids", Type: "INT[]", NotNull: true},
+			{Name: "price", Type: "NUMERIC(10, 2)", NotNull: true},
+			{Name: "created_at", Type: "TIMESTAMPTZ", NotNull: true},
+		},
+		PrimaryKey: []string{"ID"},
+	}}
+	got := []Table{{
+		Name: "user",
+		Columns: []Column{
+			{Name: "id", Type: "integer", NotNull: true},
+			{Name: "tags", Type: "text[]", NotNull: true},
+			{Name: "ids", Type: "integer[]", NotNull: true},
+			{Name: "price", Type: "numeric(10,2)", NotNull: true},
+			{Name: "created_at", Type: "timestamp without time zone"},
+		},
+		PrimaryKey: []string{"id"},
+	}}
+
+	drifts := Compare(PostgreSQL, want, got)
+	wantDrifts := []Drift{
+		{Kind: ColumnChanged, Table: "user", Name: "created_at", Message: "column created_at is timestamp without time zone, want TIMESTAMPTZ"},
+		{Kind: ColumnChanged, Table: "user", Name: "created_at", Message: "column created_at is nullable, want NOT NULL"},
+	}
+	if !slices.Equal(drifts, wantDrifts) {
+		t.Errorf("drifts = %+v\nwant %+v", drifts, wantDrifts)
+	}
+
+	if drifts := Compare(MySQL, []Table{{Name: "t", Columns: []Column{{Name: "n", Type: "INT"}, {Name: "b", Type: "BOOLEAN"}}}},
+		[]Table{{Name: "t", Columns: []Column{{Name: "n", Type: "int(11)"}, {Name: "b", Type: "tinyint(1)"}}}}); len(drifts) != 0 {
+		t.Errorf("mysql display widths drifted: %+v", drifts)
+	}
+}
+
+func TestIndexDefColumns(t *testing.T) {
+	def := `CREATE UNIQUE INDEX idx_user_name ON public."user" USING btree (lower(name), ((settings #>> '{a,b}'::text[])), age DESC) WHERE (deleted_at IS NULL)`
+	got := indexDefColumns(def)
+	want := []string{"lower(name)", "((settings #>> '{a,b}'::text[]))", "age DESC"}
+	if !slices.Equal(got, want) {
+		t.Errorf("indexDefColumns = %q, want %q", got, want)
+	}
+	if match := indexWhere.FindStringSubmatch(def); match == nil || unwrap(match[1]) != "deleted_at IS NULL" {
+		t.Errorf("index predicate = %q, want deleted_at IS NULL", match)
+	}
+	if got := unwrap("(a > 1) AND (b < 2)"); got != "(a > 1) AND (b < 2)" {
+		t.Errorf("unwrap kept a predicate of two parts as %q", got)
+	}
+}
+
+func TestCatalogDefaults(t *testing.T) {
+	for _, test := range []struct{ got, want string }{
+		{literalCast.ReplaceAllString(`'unnamed'::character varying`, "$1"), `'unnamed'`},
+		{literalCast.ReplaceAllString(`'it''s'::text`, "$1"), `'it''s'`},
+		{literalCast.ReplaceAllString(`now()`, "$1"), `now()`},
+		{mysqlDefault("unnamed", "varchar", ""), `'unnamed'`},
+		{mysqlDefault("it's", "text", ""), `'it''s'`},
+		{mysqlDefault("0.00", "decimal", ""), `0.00`},
+		{mysqlDefault("CURRENT_TIMESTAMP", "datetime", "DEFAULT_GENERATED"), `CURRENT_TIMESTAMP`},
+	} {
+		if test.got != test.want {
+			t.Errorf("default = %s, want %s", test.got, test.want)
+		}
+	}
+}
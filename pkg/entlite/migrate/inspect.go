@@ -0,0 +1,591 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// expressionColumn stands in for an indexed expression the catalog gives no
+// column name for
+const expressionColumn = "(expression)"
+
+// bookkeepingTables are where entlite, golang-migrate and goose keep track of
+// migrations, they are not part of the schema
+var bookkeepingTables = []string{VersionTable, BackfillTable, "schema_migrations", "goose_db_version"}
+
+func isBookkeeping(name string) bool {
+	return slices.ContainsFunc(bookkeepingTables, func(table string) bool { return strings.EqualFold(table, name) })
+}
+
+// Inspect reads the tables, columns, indexes and foreign keys of the database
+// from its catalog: the pragma functions on sqlite, information_schema and
+// pg_catalog on postgres, information_schema on mysql. Only the current
+// database or schema is read, bookkeeping tables of migration tools are left out.
+func Inspect(ctx context.Context, db Querier, dialect Dialect) ([]Table, error) {
+	c := &catalog{byName: map[string]*Table{}}
+	var err error
+	switch dialect {
+	case SQLite:
+		err = c.inspectSQLite(ctx, db)
+	case PostgreSQL:
+		err = c.inspectPostgres(ctx, db)
+	case MySQL:
+		err = c.inspectMySQL(ctx, db)
+	default:
+		return nil, fmt.Errorf("unknown dialect %q, must be postgresql, sqlite or mysql", dialect)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s catalog: %w", dialect, err)
+	}
+
+	var tables []Table
+	for _, table := range c.tables {
+		if !isBookkeeping(table.Name) {
+			tables = append(tables, *table)
+		}
+	}
+	return tables, nil
+}
+
+// catalog collects tables in the order the catalog lists them
+type catalog struct {
+	tables []*Table
+	byName map[string]*Table
+}
+
+func (c *catalog) table(name string) *Table {
+	if table, ok := c.byName[name]; ok {
+		return table
+	}
+	table := &Table{Name: name}
+	c.tables = append(c.tables, table)
+	c.byName[name] = table
+	return table
+}
+
+// index is the index of a table with that name, added when it has none yet
+func (c *catalog) index(tableName, name string, unique, partial bool) *Index {
+	table := c.table(tableName)
+	for i := range table.Indexes {
+		if table.Indexes[i].Name == name {
+			return &table.Indexes[i]
+		}
+	}
+	table.Indexes = append(table.Indexes, Index{Name: name, Unique: unique, Partial: partial})
+	return &table.Indexes[len(table.Indexes)-1]
+}
+
+// setUnique marks the column a one column UNIQUE constraint covers
+func (c *catalog) setUnique(tableName, column string) {
+	table := c.table(tableName)
+	for i := range table.Columns {
+		if table.Columns[i].Name == column {
+			table.Columns[i].Unique = true
+		}
+	}
+}
+
+// each runs scan for every row of the query
+func each(ctx context.Context, db Querier, query string, args []any, scan func(*sql.Rows) error) error {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+var (
+	checkConstraint = regexp.MustCompile(`(?i)\bCHECK\s*\(`)
+	indexWhere      = regexp.MustCompile(`(?is)\bWHERE\s+(.*?)\s*;?\s*$`)
+	columnName      = regexp.MustCompile("^[\"`\\[]?([A-Za-z_][A-Za-z0-9_]*)")
+)
+
+func (c *catalog) inspectSQLite(ctx context.Context, db Querier) error {
+	createSQL := map[string]string{}
+	err := each(ctx, db, `SELECT name, sql FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name`, nil, func(rows *sql.Rows) error {
+		var name, sql string
+		if err := rows.Scan(&name, &sql); err != nil {
+			return err
+		}
+		c.table(name)
+		createSQL[name] = sql
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, table := range c.tables {
+		name := table.Name
+		comments := columnComments(createSQL[name])
+		table.HasCheck = checkConstraint.MatchString(createSQL[name])
+		keyPositions := map[int]string{}
+		err := each(ctx, db, `SELECT name, type, "notnull", dflt_value, pk FROM pragma_table_info(?) ORDER BY cid`, []any{name}, func(rows *sql.Rows) error {
+			var column Column
+			var dflt sql.NullString
+			var pk int
+			if err := rows.Scan(&column.Name, &column.Type, &column.NotNull, &dflt, &pk); err != nil {
+				return err
+			}
+			column.Default = dflt.String
+			column.Comment = comments[strings.ToLower(column.Name)]
+			if pk > 0 {
+				keyPositions[pk] = column.Name
+			}
+			table.Columns = append(table.Columns, column)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for i := 1; i <= len(keyPositions); i++ {
+			table.PrimaryKey = append(table.PrimaryKey, keyPositions[i])
+		}
+
+		type indexInfo struct {
+			name, origin    string
+			unique, partial bool
+			sql             sql.NullString
+		}
+		var indexes []indexInfo
+		// seq counts down from the newest index, this reads them in creation order
+		err = each(ctx, db, `SELECT il.name, il."unique", il.origin, il.partial, m.sql FROM pragma_index_list(?) il
+LEFT JOIN sqlite_master m ON m.type = 'index' AND m.name = il.name ORDER BY il.seq DESC`, []any{name}, func(rows *sql.Rows) error {
+			var info indexInfo
+			if err := rows.Scan(&info.name, &info.unique, &info.origin, &info.partial, &info.sql); err != nil {
+				return err
+			}
+			indexes = append(indexes, info)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, info := range indexes {
+			// the primary key is read from table_info
+			if info.origin == "pk" {
+				continue
+			}
+			var columns []string
+			err := each(ctx, db, `SELECT name, "desc" FROM pragma_index_xinfo(?) WHERE key = 1 ORDER BY seqno`, []any{info.name}, func(rows *sql.Rows) error {
+				var column sql.NullString
+				var desc bool
+				if err := rows.Scan(&column, &desc); err != nil {
+					return err
+				}
+				name := column.String
+				if !column.Valid {
+					name = expressionColumn
+				}
+				if desc {
+					name += " DESC"
+				}
+				columns = append(columns, name)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if info.origin == "u" && len(columns) == 1 {
+				c.setUnique(name, columns[0])
+				continue
+			}
+			idx := c.index(name, info.name, info.unique, info.partial)
+			idx.Columns = columns
+			idx.Constraint = info.origin == "u"
+			if match := indexWhere.FindStringSubmatch(info.sql.String); info.partial && match != nil {
+				idx.Where = match[1]
+			}
+		}
+
+		err = each(ctx, db, `SELECT "from", "table", "to" FROM pragma_foreign_key_list(?) ORDER BY id, seq`, []any{name}, func(rows *sql.Rows) error {
+			var fk ForeignKey
+			var to sql.NullString
+			if err := rows.Scan(&fk.Column, &fk.RefTable, &to); err != nil {
+				return err
+			}
+			fk.RefColumn = to.String
+			table.ForeignKeys = append(table.ForeignKeys, fk)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// columnComments reads the comments of a CREATE TABLE, sqlite keeps the text
+// as written. A comment is the run of -- lines above a column, by lowercased
+// column name.
+func columnComments(createSQL string) map[string]string {
+	comments := map[string]string{}
+	var pending []string
+	for line := range strings.SplitSeq(createSQL, "\n") {
+		line = strings.TrimSpace(line)
+		if text, ok := strings.CutPrefix(line, "--"); ok {
+			pending = append(pending, strings.TrimSpace(text))
+			continue
+		}
+		if len(pending) > 0 {
+			if match := columnName.FindStringSubmatch(line); match != nil {
+				comments[strings.ToLower(match[1])] = strings.Join(pending, "\n")
+			}
+		}
+		pending = nil
+	}
+	return comments
+}
+
+func (c *catalog) inspectPostgres(ctx context.Context, db Querier) error {
+	err := each(ctx, db, `SELECT table_name FROM information_schema.tables
+WHERE table_schema = current_schema() AND table_type = 'BASE TABLE' ORDER BY table_name`, nil, func(rows *sql.Rows) error {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		c.table(name)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = each(ctx, db, `SELECT table_name, column_name, data_type, udt_name, is_nullable, numeric_precision, numeric_scale,
+  column_default, col_description(format('%I.%I', table_schema, table_name)::regclass, ordinal_position::int)
+FROM information_schema.columns WHERE table_schema = current_schema() ORDER BY table_name, ordinal_position`, nil, func(rows *sql.Rows) error {
+		var tableName, dataType, udtName, nullable string
+		var precision, scale sql.NullInt64
+		var dflt, comment sql.NullString
+		var column Column
+		if err := rows.Scan(&tableName, &column.Name, &dataType, &udtName, &nullable, &precision, &scale, &dflt, &comment); err != nil {
+			return err
+		}
+		if _, ok := c.byName[tableName]; !ok {
+			return nil // a view
+		}
+		column.Type = postgresColumnType(dataType, udtName, precision, scale)
+		column.NotNull = nullable == "NO"
+		column.Default = literalCast.ReplaceAllString(dflt.String, "$1")
+		column.Comment = comment.String
+		table := c.table(tableName)
+		table.Columns = append(table.Columns, column)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// a UNIQUE constraint on one column is the column's, on more it is an index
+	type constraint struct {
+		table, name, kind string
+		columns           []string
+	}
+	var constraints []*constraint
+	err = each(ctx, db, `SELECT tc.table_name, tc.constraint_name, tc.constraint_type, kcu.column_name
+FROM information_schema.table_constraints tc
+JOIN information_schema.key_column_usage kcu
+  ON kcu.constraint_schema = tc.constraint_schema AND kcu.constraint_name = tc.constraint_name AND kcu.table_name = tc.table_name
+WHERE tc.table_schema = current_schema() AND tc.constraint_type IN ('PRIMARY KEY', 'UNIQUE')
+ORDER BY tc.table_name, tc.constraint_name, kcu.ordinal_position`, nil, func(rows *sql.Rows) error {
+		var tableName, name, kind, column string
+		if err := rows.Scan(&tableName, &name, &kind, &column); err != nil {
+			return err
+		}
+		if n := len(constraints); n > 0 && constraints[n-1].table == tableName && constraints[n-1].name == name {
+			constraints[n-1].columns = append(constraints[n-1].columns, column)
+			return nil
+		}
+		constraints = append(constraints, &constraint{table: tableName, name: name, kind: kind, columns: []string{column}})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, con := range constraints {
+		switch {
+		case con.kind == "PRIMARY KEY":
+			c.table(con.table).PrimaryKey = con.columns
+		case len(con.columns) == 1:
+			c.setUnique(con.table, con.columns[0])
+		default:
+			idx := c.index(con.table, con.name, true, false)
+			idx.Columns = con.columns
+			idx.Constraint = true
+		}
+	}
+
+	// information_schema lists NOT NULL as CHECK constraints too, pg_constraint doesn't
+	err = each(ctx, db, `SELECT DISTINCT t.relname FROM pg_constraint con
+JOIN pg_class t ON t.oid = con.conrelid
+JOIN pg_namespace n ON n.oid = t.relnamespace
+WHERE n.nspname = current_schema() AND con.contype = 'c'`, nil, func(rows *sql.Rows) error {
+		var tableName string
+		if err := rows.Scan(&tableName); err != nil {
+			return err
+		}
+		if table, ok := c.byName[tableName]; ok {
+			table.HasCheck = true
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = each(ctx, db, `SELECT kcu.table_name, kcu.column_name, ref.table_name, ref.column_name
+FROM information_schema.referential_constraints rc
+JOIN information_schema.key_column_usage kcu
+  ON kcu.constraint_schema = rc.constraint_schema AND kcu.constraint_name = rc.constraint_name
+JOIN information_schema.key_column_usage ref
+  ON ref.constraint_schema = rc.unique_constraint_schema AND ref.constraint_name = rc.unique_constraint_name
+  AND ref.ordinal_position = kcu.position_in_unique_constraint
+WHERE rc.constraint_schema = current_schema()
+ORDER BY kcu.table_name, kcu.constraint_name, kcu.ordinal_position`, nil, func(rows *sql.Rows) error {
+		var tableName string
+		var fk ForeignKey
+		if err := rows.Scan(&tableName, &fk.Column, &fk.RefTable, &fk.RefColumn); err != nil {
+			return err
+		}
+		if table, ok := c.byName[tableName]; ok {
+			table.ForeignKeys = append(table.ForeignKeys, fk)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// indexes of constraints are read above
+	return each(ctx, db, `SELECT t.relname, i.relname, ix.indisunique, ix.indpred IS NOT NULL, pg_get_indexdef(ix.indexrelid)
+FROM pg_index ix
+JOIN pg_class i ON i.oid = ix.indexrelid
+JOIN pg_class t ON t.oid = ix.indrelid
+JOIN pg_namespace n ON n.oid = t.relnamespace
+WHERE n.nspname = current_schema()
+  AND NOT EXISTS (SELECT 1 FROM pg_constraint con WHERE con.conindid = ix.indexrelid)
+ORDER BY t.relname, i.relname`, nil, func(rows *sql.Rows) error {
+		var tableName, name, def string
+		var unique, partial bool
+		if err := rows.Scan(&tableName, &name, &unique, &partial, &def); err != nil {
+			return err
+		}
+		idx := c.index(tableName, name, unique, partial)
+		idx.Columns = indexDefColumns(def)
+		if match := indexWhere.FindStringSubmatch(def); partial && match != nil {
+			idx.Where = unwrap(match[1])
+		}
+		return nil
+	})
+}
+
+// literalCast is a postgres default with the cast it shows it with, e.g.
+// 'unnamed'::character varying
+var literalCast = regexp.MustCompile(`^('(?:[^']|'')*')::[a-z ]+(\[\])?$`)
+
+// unwrap drops the parentheses postgres puts around a predicate, e.g.
+// (active = true)
+func unwrap(expr string) string {
+	if !strings.HasPrefix(expr, "(") || !strings.HasSuffix(expr, ")") {
+		return expr
+	}
+	depth := 0
+	for i, r := range expr {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 && i < len(expr)-1 {
+				return expr // (a) AND (b)
+			}
+		}
+	}
+	return expr[1 : len(expr)-1]
+}
+
+// postgresColumnType is the type of an information_schema column, e.g. an
+// integer ARRAY is integer[]
+func postgresColumnType(dataType, udtName string, precision, scale sql.NullInt64) string {
+	switch dataType {
+	case "ARRAY":
+		return canonicalType(PostgreSQL, strings.TrimPrefix(udtName, "_")) + "[]"
+	case "numeric":
+		if precision.Valid {
+			return fmt.Sprintf("numeric(%d,%d)", precision.Int64, scale.Int64)
+		}
+	}
+	return dataType
+}
+
+// indexDefColumns are the columns of a pg_get_indexdef, e.g.
+// CREATE INDEX i ON public.t USING btree (lower(email), age DESC) WHERE ...
+func indexDefColumns(def string) []string {
+	start := strings.Index(def, " USING ")
+	if start < 0 {
+		return nil
+	}
+	start += strings.Index(def[start:], "(")
+
+	var columns []string
+	depth, from := 0, start+1
+	for i := start; i < len(def); i++ {
+		switch def[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return append(columns, strings.TrimSpace(def[from:i]))
+			}
+		case ',':
+			if depth == 1 {
+				columns = append(columns, strings.TrimSpace(def[from:i]))
+				from = i + 1
+			}
+		}
+	}
+	return columns
+}
+
+func (c *catalog) inspectMySQL(ctx context.Context, db Querier) error {
+	err := each(ctx, db, `SELECT TABLE_NAME FROM information_schema.TABLES
+WHERE TABLE_SCHEMA = DATABASE() AND TABLE_TYPE = 'BASE TABLE' ORDER BY TABLE_NAME`, nil, func(rows *sql.Rows) error {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		c.table(name)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = each(ctx, db, `SELECT TABLE_NAME, COLUMN_NAME, COLUMN_TYPE, IS_NULLABLE, DATA_TYPE, COLUMN_DEFAULT, EXTRA, COLUMN_COMMENT
+FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = DATABASE() ORDER BY TABLE_NAME, ORDINAL_POSITION`, nil, func(rows *sql.Rows) error {
+		var tableName, nullable, dataType, extra string
+		var dflt sql.NullString
+		var column Column
+		if err := rows.Scan(&tableName, &column.Name, &column.Type, &nullable, &dataType, &dflt, &extra, &column.Comment); err != nil {
+			return err
+		}
+		if _, ok := c.byName[tableName]; !ok {
+			return nil // a view
+		}
+		column.NotNull = nullable == "NO"
+		if dflt.Valid {
+			column.Default = mysqlDefault(dflt.String, dataType, extra)
+		}
+		table := c.table(tableName)
+		table.Columns = append(table.Columns, column)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// EXPRESSION is 8.0 only, an expression is a row without a column name
+	err = each(ctx, db, `SELECT TABLE_NAME, INDEX_NAME, NON_UNIQUE, COLUMN_NAME, COLLATION
+FROM information_schema.STATISTICS WHERE TABLE_SCHEMA = DATABASE() ORDER BY TABLE_NAME, INDEX_NAME, SEQ_IN_INDEX`, nil, func(rows *sql.Rows) error {
+		var tableName, name string
+		var nonUnique bool
+		var column, collation sql.NullString
+		if err := rows.Scan(&tableName, &name, &nonUnique, &column, &collation); err != nil {
+			return err
+		}
+		if _, ok := c.byName[tableName]; !ok {
+			return nil
+		}
+		columnName := column.String
+		if !column.Valid {
+			columnName = expressionColumn
+		}
+		if name == "PRIMARY" {
+			table := c.table(tableName)
+			table.PrimaryKey = append(table.PrimaryKey, columnName)
+			return nil
+		}
+		if collation.String == "D" {
+			columnName += " DESC"
+		}
+		idx := c.index(tableName, name, !nonUnique, false)
+		idx.Columns = append(idx.Columns, columnName)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// CHECK constraints are listed since 8.0.16
+	err = each(ctx, db, `SELECT DISTINCT TABLE_NAME FROM information_schema.TABLE_CONSTRAINTS
+WHERE TABLE_SCHEMA = DATABASE() AND CONSTRAINT_TYPE = 'CHECK'`, nil, func(rows *sql.Rows) error {
+		var tableName string
+		if err := rows.Scan(&tableName); err != nil {
+			return err
+		}
+		if table, ok := c.byName[tableName]; ok {
+			table.HasCheck = true
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = each(ctx, db, `SELECT TABLE_NAME, COLUMN_NAME, REFERENCED_TABLE_NAME, REFERENCED_COLUMN_NAME
+FROM information_schema.KEY_COLUMN_USAGE WHERE TABLE_SCHEMA = DATABASE() AND REFERENCED_TABLE_NAME IS NOT NULL
+ORDER BY TABLE_NAME, CONSTRAINT_NAME, ORDINAL_POSITION`, nil, func(rows *sql.Rows) error {
+		var tableName string
+		var fk ForeignKey
+		if err := rows.Scan(&tableName, &fk.Column, &fk.RefTable, &fk.RefColumn); err != nil {
+			return err
+		}
+		if table, ok := c.byName[tableName]; ok {
+			table.ForeignKeys = append(table.ForeignKeys, fk)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// an inline UNIQUE is an index named after its column
+	for _, table := range c.tables {
+		indexes := table.Indexes[:0]
+		for _, idx := range table.Indexes {
+			if idx.Unique && len(idx.Columns) == 1 && idx.Columns[0] == idx.Name {
+				c.setUnique(table.Name, idx.Name)
+				continue
+			}
+			indexes = append(indexes, idx)
+		}
+		table.Indexes = indexes
+	}
+	return nil
+}
+
+// mysqlDefault is the SQL of a COLUMN_DEFAULT, which has string defaults
+// without their quotes
+func mysqlDefault(dflt, dataType, extra string) string {
+	switch {
+	case strings.Contains(extra, "DEFAULT_GENERATED"), strings.HasPrefix(strings.ToUpper(dflt), "CURRENT_TIMESTAMP"), strings.HasPrefix(dflt, "'"):
+		return dflt
+	}
+	switch dataType {
+	case "tinyint", "smallint", "mediumint", "int", "bigint", "decimal", "float", "double", "bit":
+		return dflt
+	}
+	return "'" + strings.ReplaceAll(dflt, "'", "''") + "'"
+}
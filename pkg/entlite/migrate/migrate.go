@@ -0,0 +1,424 @@
+// Package migrate applies the migrations entlite migrate diff writes. The
+// migrations directory embeds its sql files, so a service can bring its
+// database up to date at startup:
+//
+//	migrator, err := migrate.New(db, migrate.SQLite, migrations.FS)
+//	...
+//	if err := migrator.Up(ctx); err != nil {
+//
+// Applied versions are kept in the entlite_migrations table. Every run holds a
+// lock, so service instances starting together apply each migration once.
+package migrate
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Dialect is the engine from sqlc.yaml
+type Dialect string
+
+const (
+	PostgreSQL Dialect = "postgresql"
+	MySQL      Dialect = "mysql"
+	SQLite     Dialect = "sqlite"
+)
+
+// VersionTable records the applied migrations
+const VersionTable = "entlite_migrations"
+
+// ManualStep starts the line entlite migrate diff writes for a change it
+// can't make safely. A migration is refused until every one is edited away.
+const ManualStep = "-- TODO"
+
+// Migration is one <version>_<name>.up.sql file and its .down.sql
+type Migration struct {
+	Version uint64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status is a migration and whether it is applied. A version applied in the
+// database but missing from the files has an empty Name.
+type Status struct {
+	Version   uint64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+type Migrator struct {
+	db         *sql.DB
+	dialect    Dialect
+	migrations []Migration
+	backfills  map[string]BackfillFunc
+}
+
+// New reads the migrations at the root of fsys, in golang-migrate's
+// <version>_<name>.up.sql and .down.sql layout
+func New(db *sql.DB, dialect Dialect, fsys fs.FS) (*Migrator, error) {
+	switch dialect {
+	case PostgreSQL, MySQL, SQLite:
+	default:
+		return nil, fmt.Errorf("unknown dialect %q, must be postgresql, sqlite or mysql", dialect)
+	}
+
+	migrations, err := Read(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, dialect: dialect, migrations: migrations}, nil
+}
+
+// WithBackfills registers the functions the Go backfills of the migrations
+// name, the Backfills of the generated migrations package
+func (m *Migrator) WithBackfills(backfills map[string]BackfillFunc) *Migrator {
+	m.backfills = backfills
+	return m
+}
+
+// Read loads the migrations at the root of fsys, ordered by version
+func Read(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("reading migrations: %w", err)
+	}
+
+	byVersion := make(map[uint64]*Migration)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".sql") {
+			continue
+		}
+		base, direction, ok := strings.Cut(strings.TrimSuffix(name, ".sql"), ".")
+		if !ok || (direction != "up" && direction != "down") {
+			continue
+		}
+		prefix, label, _ := strings.Cut(base, "_")
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: version %q is not a number", name, prefix)
+		}
+
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, fmt.Errorf("reading migration %s: %w", name, err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: label}
+			byVersion[version] = migration
+		}
+		if migration.Name != label {
+			return nil, fmt.Errorf("migration version %d is used by both %q and %q", version, migration.Name, label)
+		}
+		if direction == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	var migrations []Migration
+	for _, migration := range byVersion {
+		migrations = append(migrations, *migration)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+	return migrations, nil
+}
+
+// Up applies every migration that isn't applied yet, in version order
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.locked(ctx, func(ctx context.Context, conn *sql.Conn, applied map[uint64]time.Time) error {
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if err := m.apply(ctx, conn, migration, true); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Down rolls back the last n applied migrations, newest first
+func (m *Migrator) Down(ctx context.Context, n int) error {
+	if n < 1 {
+		return fmt.Errorf("down takes a positive number of migrations, got %d", n)
+	}
+	return m.locked(ctx, func(ctx context.Context, conn *sql.Conn, applied map[uint64]time.Time) error {
+		var versions []uint64
+		for version := range applied {
+			versions = append(versions, version)
+		}
+		slices.SortFunc(versions, func(a, b uint64) int { return cmp.Compare(b, a) })
+
+		for _, version := range versions[:min(n, len(versions))] {
+			i := slices.IndexFunc(m.migrations, func(migration Migration) bool { return migration.Version == version })
+			if i < 0 {
+				return fmt.Errorf("migration %d is applied but has no files to roll it back", version)
+			}
+			if err := m.apply(ctx, conn, m.migrations[i], false); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Status lists every migration in version order with whether it is applied
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	if err := m.createVersionTable(ctx, m.db); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied(ctx, m.db)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []Status
+	for _, migration := range m.migrations {
+		appliedAt, ok := applied[migration.Version]
+		statuses = append(statuses, Status{Version: migration.Version, Name: migration.Name, Applied: ok, AppliedAt: appliedAt})
+		delete(applied, migration.Version)
+	}
+	for version, appliedAt := range applied {
+		statuses = append(statuses, Status{Version: version, Applied: true, AppliedAt: appliedAt})
+	}
+	slices.SortFunc(statuses, func(a, b Status) int { return cmp.Compare(a.Version, b.Version) })
+	return statuses, nil
+}
+
+// execer is what both a connection and a transaction run statements on
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// locked runs fn on one connection while holding the migration lock, with the
+// versions applied so far. On sqlite the lock is an exclusive transaction
+// that every migration of the run goes into.
+func (m *Migrator) locked(ctx context.Context, fn func(context.Context, *sql.Conn, map[uint64]time.Time) error) (err error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	switch m.dialect {
+	case PostgreSQL:
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockKey); err != nil {
+			return fmt.Errorf("taking migration lock: %w", err)
+		}
+		defer func() {
+			if _, unlockErr := conn.ExecContext(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", lockKey); err == nil && unlockErr != nil {
+				err = fmt.Errorf("releasing migration lock: %w", unlockErr)
+			}
+		}()
+	case MySQL:
+		var locked sql.NullInt64
+		if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", VersionTable, lockTimeoutSeconds).Scan(&locked); err != nil {
+			return fmt.Errorf("taking migration lock: %w", err)
+		}
+		if locked.Int64 != 1 {
+			return fmt.Errorf("taking migration lock: another migration held it for %d seconds", lockTimeoutSeconds)
+		}
+		defer func() {
+			if _, unlockErr := conn.ExecContext(context.WithoutCancel(ctx), "SELECT RELEASE_LOCK(?)", VersionTable); err == nil && unlockErr != nil {
+				err = fmt.Errorf("releasing migration lock: %w", unlockErr)
+			}
+		}()
+	case SQLite:
+		if _, err := conn.ExecContext(ctx, "BEGIN EXCLUSIVE"); err != nil {
+			return fmt.Errorf("taking migration lock: %w", err)
+		}
+		defer func() {
+			statement := "COMMIT"
+			if err != nil {
+				statement = "ROLLBACK"
+			}
+			if _, endErr := conn.ExecContext(context.WithoutCancel(ctx), statement); err == nil && endErr != nil {
+				err = fmt.Errorf("committing migrations: %w", endErr)
+			}
+		}()
+	}
+
+	// read after locking, another instance may have just migrated
+	if err := m.createVersionTable(ctx, conn); err != nil {
+		return err
+	}
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return err
+	}
+	return fn(ctx, conn, applied)
+}
+
+// lockKey identifies entlite's postgres advisory lock, any int64 works as
+// long as nothing else takes the same one
+const lockKey int64 = 0x656e746c697465 // "entlite"
+
+const lockTimeoutSeconds = 60
+
+// apply runs one migration up or down and records it. On postgres and mysql
+// every migration gets its own transaction, sqlite is already in one.
+// mysql commits DDL as it goes, a failed migration there can be half applied.
+// A migration with a Go backfill commits in parts, see applyInParts, and one
+// with a ManualStep left in it is refused before anything runs.
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration Migration, up bool) error {
+	script, record, args := migration.Up, m.insertVersionSQL(), []any{int64(migration.Version), migration.Name, time.Now().UTC()}
+	direction := "up"
+	if !up {
+		script, record, args = migration.Down, m.deleteVersionSQL(), []any{int64(migration.Version)}
+		direction = "down"
+	}
+
+	if steps := ManualSteps(script); len(steps) > 0 {
+		return fmt.Errorf("migration %d_%s %s has steps to finish by hand:\n%s", migration.Version, migration.Name, direction, strings.Join(steps, "\n"))
+	}
+	parts, err := splitParts(script)
+	if err != nil {
+		return fmt.Errorf("migration %d_%s %s: %w", migration.Version, migration.Name, direction, err)
+	}
+	if len(parts) > 1 {
+		return m.applyInParts(ctx, conn, migration, direction, parts, record, args)
+	}
+
+	run := func(tx execer) error {
+		for _, statement := range m.statements(script) {
+			if _, err := tx.ExecContext(ctx, statement); err != nil {
+				return fmt.Errorf("migration %d_%s %s: %w", migration.Version, migration.Name, direction, err)
+			}
+		}
+		if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+			return fmt.Errorf("recording migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		return nil
+	}
+
+	if m.dialect == SQLite {
+		return run(conn)
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := run(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// ManualSteps lists the ManualStep lines of a migration script
+func ManualSteps(script string) []string {
+	var steps []string
+	for line := range strings.SplitSeq(script, "\n") {
+		if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, ManualStep) {
+			steps = append(steps, trimmed)
+		}
+	}
+	return steps
+}
+
+// statements splits a migration for drivers that run one statement per call.
+// mysql does unless the DSN sets multiStatements, postgres and sqlite take
+// the whole file at once.
+func (m *Migrator) statements(script string) []string {
+	if strings.TrimSpace(script) == "" {
+		return nil
+	}
+	if m.dialect != MySQL {
+		return []string{script}
+	}
+
+	var statements []string
+	var current strings.Builder
+	for line := range strings.SplitSeq(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line + "\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, current.String())
+			current.Reset()
+		}
+	}
+	if strings.TrimSpace(current.String()) != "" {
+		statements = append(statements, current.String())
+	}
+	return statements
+}
+
+func (m *Migrator) createVersionTable(ctx context.Context, db execer) error {
+	nameType := "TEXT"
+	if m.dialect == MySQL {
+		nameType = "VARCHAR(255)"
+	}
+	_, err := db.ExecContext(ctx, fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %s (version BIGINT PRIMARY KEY, name %s NOT NULL, applied_at TIMESTAMP NOT NULL)",
+		VersionTable, nameType,
+	))
+	if err != nil {
+		return fmt.Errorf("creating %s: %w", VersionTable, err)
+	}
+	return nil
+}
+
+func (m *Migrator) applied(ctx context.Context, db execer) (map[uint64]time.Time, error) {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf("SELECT version, applied_at FROM %s", VersionTable))
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", VersionTable, err)
+	}
+	defer rows.Close()
+
+	applied := make(map[uint64]time.Time)
+	for rows.Next() {
+		var version int64
+		var appliedAt string
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("reading %s: %w", VersionTable, err)
+		}
+		applied[uint64(version)] = parseAppliedAt(appliedAt)
+	}
+	return applied, rows.Err()
+}
+
+func (m *Migrator) insertVersionSQL() string {
+	if m.dialect == PostgreSQL {
+		return fmt.Sprintf("INSERT INTO %s (version, name, applied_at) VALUES ($1, $2, $3)", VersionTable)
+	}
+	return fmt.Sprintf("INSERT INTO %s (version, name, applied_at) VALUES (?, ?, ?)", VersionTable)
+}
+
+func (m *Migrator) deleteVersionSQL() string {
+	if m.dialect == PostgreSQL {
+		return fmt.Sprintf("DELETE FROM %s WHERE version = $1", VersionTable)
+	}
+	return fmt.Sprintf("DELETE FROM %s WHERE version = ?", VersionTable)
+}
+
+// parseAppliedAt reads applied_at as text, mysql only returns a time.Time
+// with parseTime in the DSN. A format it doesn't know is left zero.
+func parseAppliedAt(value string) time.Time {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999-07:00", "2006-01-02 15:04:05.999999999"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
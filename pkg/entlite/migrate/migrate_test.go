@@ -0,0 +1,175 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestMigratorSQLite(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	fsys := fstest.MapFS{
+		"20260301120000_initial.up.sql":   {Data: []byte("CREATE TABLE \"user\"(\n  ID INTEGER PRIMARY KEY AUTOINCREMENT,\n  name TEXT NOT NULL\n);\n")},
+		"20260301120000_initial.down.sql": {Data: []byte("DROP TABLE \"user\";\n")},
+		"20260302120000_add_age.up.sql":   {Data: []byte("-- user table\nALTER TABLE \"user\" ADD COLUMN age INTEGER;\nCREATE INDEX \"idx_user_age\" ON \"user\" (age);\n")},
+		"20260302120000_add_age.down.sql": {Data: []byte("DROP INDEX \"idx_user_age\";\nALTER TABLE \"user\" DROP COLUMN age;\n")},
+		"schema_snapshot.json":            {Data: []byte("{}")},
+		"migrations.go":                   {Data: []byte("package migrations")},
+	}
+
+	migrator, err := New(db, SQLite, fsys)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	if err := migrator.Up(ctx); err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+	// a second run finds nothing to apply
+	if err := migrator.Up(ctx); err != nil {
+		t.Fatalf("second Up failed: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, `INSERT INTO "user" (name, age) VALUES ('Jane', 30)`); err != nil {
+		t.Fatalf("migrated table is missing columns: %v", err)
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if len(statuses) != 2 || !statuses[0].Applied || !statuses[1].Applied || statuses[1].Name != "add_age" {
+		t.Fatalf("Status = %+v, want both migrations applied", statuses)
+	}
+	if statuses[0].AppliedAt.IsZero() {
+		t.Errorf("AppliedAt was not read back: %+v", statuses[0])
+	}
+
+	if err := migrator.Down(ctx, 1); err != nil {
+		t.Fatalf("Down failed: %v", err)
+	}
+	statuses, err = migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if !statuses[0].Applied || statuses[1].Applied {
+		t.Fatalf("Status after Down(1) = %+v, want only the first applied", statuses)
+	}
+	if _, err := db.ExecContext(ctx, `INSERT INTO "user" (name, age) VALUES ('Jane', 30)`); err == nil {
+		t.Fatalf("age column should be gone after Down(1)")
+	}
+}
+
+func TestMigratorFailedMigrationRollsBack(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	fsys := fstest.MapFS{
+		"1_first.up.sql":  {Data: []byte("CREATE TABLE a (id INTEGER);\n")},
+		"2_broken.up.sql": {Data: []byte("CREATE TABLE b (id INTEGER);\nNOT SQL;\n")},
+	}
+	migrator, err := New(db, SQLite, fsys)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if err := migrator.Up(ctx); err == nil {
+		t.Fatalf("expected the broken migration to fail")
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	for _, status := range statuses {
+		if status.Applied {
+			t.Errorf("migration %d_%s should have been rolled back with the rest of the run", status.Version, status.Name)
+		}
+	}
+}
+
+func TestMigratorRefusesManualSteps(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	fsys := fstest.MapFS{
+		"1_check.up.sql": {Data: []byte("CREATE TABLE a (id INTEGER);\n-- TODO a: check on id changes from \"\" to \"id > 0\", edit this migration by hand\n")},
+	}
+	migrator, err := New(db, SQLite, fsys)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	err = migrator.Up(ctx)
+	if err == nil || !strings.Contains(err.Error(), "migration 1_check up has steps to finish by hand:\n-- TODO a: check on id") {
+		t.Fatalf("Up = %v, want the manual step refused", err)
+	}
+	if _, err := db.ExecContext(ctx, "SELECT id FROM a"); err == nil {
+		t.Errorf("the statements before the manual step ran")
+	}
+}
+
+func TestMigratorDownRejectsNonPositive(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	fsys := fstest.MapFS{
+		"1_first.up.sql":   {Data: []byte("CREATE TABLE a (id INTEGER);\n")},
+		"1_first.down.sql": {Data: []byte("DROP TABLE a;\n")},
+	}
+	migrator, err := New(db, SQLite, fsys)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if err := migrator.Up(ctx); err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+
+	for _, n := range []int{0, -1} {
+		want := fmt.Sprintf("down takes a positive number of migrations, got %d", n)
+		if err := migrator.Down(ctx, n); err == nil || err.Error() != want {
+			t.Errorf("Down(%d) = %v, want %q", n, err, want)
+		}
+	}
+
+	// nothing was locked or rolled back by the rejected calls
+	if err := migrator.Down(ctx, 1); err != nil {
+		t.Fatalf("Down(1) failed: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, "SELECT id FROM a"); err == nil {
+		t.Errorf("table a should be gone after Down(1)")
+	}
+}
+
+func TestReadRejectsBadVersion(t *testing.T) {
+	_, err := Read(fstest.MapFS{"first.up.sql": {Data: []byte("SELECT 1;")}})
+	want := `migration first.up.sql: version "first" is not a number`
+	if err == nil || err.Error() != want {
+		t.Fatalf("expected error %q, got %v", want, err)
+	}
+}
@@ -5,16 +5,17 @@ import "github.com/guntisdev/entlite/pkg/entlite/filter"
 type Type string
 
 const (
-	TypeDefaultCRUD Type = "default_crud"
-	TypeCreate      Type = "create"
-	TypeCreateBulk  Type = "create_bulk"
-	TypeGet         Type = "get"
-	TypeUpdate      Type = "update"
-	TypeDelete      Type = "delete"
-	TypeDeleteAll   Type = "delete_all"
-	TypeListAll     Type = "list_all"
-	TypeGetBy       Type = "get_by"
-	TypeListBy      Type = "list_by"
+	TypeDefaultCRUD  Type = "default_crud"
+	TypeCreate       Type = "create"
+	TypeCreateBulk   Type = "create_bulk"
+	TypeCreateStream Type = "create_stream"
+	TypeGet          Type = "get"
+	TypeUpdate       Type = "update"
+	TypeDelete       Type = "delete"
+	TypeDeleteAll    Type = "delete_all"
+	TypeListAll      Type = "list_all"
+	TypeGetBy        Type = "get_by"
+	TypeListBy       Type = "list_by"
 )
 
 type QueryBuilder interface {
@@ -33,6 +34,16 @@ type ListByOperations interface {
 	OrderBy(field string) ListByOperations
 	// Name overrides the auto-generated query/method name
 	Name(name string) ListByOperations
+	// Stream sends rows one by one over a server-streaming rpc instead of a single response
+	Stream() ListByOperations
+}
+
+type ListAllOperations interface {
+	QueryBuilder
+	// Name overrides the auto-generated query/method name
+	Name(name string) ListAllOperations
+	// Stream sends rows one by one over a server-streaming rpc instead of a single response
+	Stream() ListAllOperations
 }
 
 type Query struct {
@@ -42,6 +53,7 @@ type Query struct {
 	count    bool            // For ListBy: whether to count
 	orderBy  string          // For ListBy: order by field
 	name     string          // Custom query name
+	stream   bool            // For ListBy/ListAll: server-streaming rpc
 }
 
 // marker method for sealed interface
@@ -77,6 +89,31 @@ func (q listByQuery) OrderBy(field string) ListByOperations {
 	return q
 }
 
+// Stream sends rows one by one over a server-streaming rpc
+func (q listByQuery) Stream() ListByOperations {
+	q.base.stream = true
+	return q
+}
+
+type listAllQuery struct {
+	base Query
+}
+
+// marker method for sealed interface
+func (listAllQuery) Query() {}
+
+// Name overrides the auto-generated query/method name
+func (q listAllQuery) Name(name string) ListAllOperations {
+	q.base.name = name
+	return q
+}
+
+// Stream sends rows one by one over a server-streaming rpc
+func (q listAllQuery) Stream() ListAllOperations {
+	q.base.stream = true
+	return q
+}
+
 // GetBy creates a query to get a record by one or more fields
 // Example: GetBy("id") or GetBy("org_id", "email")
 func GetBy(fields ...string) QueryOperations {
@@ -96,6 +133,12 @@ func CreateBulk() QueryOperations {
 	return Query{typeName: TypeCreateBulk}
 }
 
+// CreateStream accepts a client stream of items and inserts them in batches,
+// reporting items that fail validation instead of aborting the stream
+func CreateStream() QueryOperations {
+	return Query{typeName: TypeCreateStream}
+}
+
 func Get() QueryOperations {
 	return Query{typeName: TypeGet}
 }
@@ -112,8 +155,8 @@ func DeleteAll() QueryOperations {
 	return Query{typeName: TypeDeleteAll}
 }
 
-func ListAll() QueryOperations {
-	return Query{typeName: TypeListAll}
+func ListAll() ListAllOperations {
+	return listAllQuery{base: Query{typeName: TypeListAll}}
 }
 
 // ListBy creates a query to list records with filters
@@ -155,6 +198,11 @@ func (q Query) GetOrderBy() string {
 	return q.orderBy
 }
 
+// IsStream reports a list query served over a server-streaming rpc.
+func (q Query) IsStream() bool {
+	return q.stream
+}
+
 // GetName returns the custom query name, or "" when auto-generated.
 func (q Query) GetName() string {
 	return q.name